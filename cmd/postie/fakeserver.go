@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/javi11/postie/pkg/nntptest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fakeListen           string
+	fakeStorageDir       string
+	fakeUsername         string
+	fakePassword         string
+	fakeDropRate         float64
+	fakeRejectRate       float64
+	fakeResetRate        float64
+	fakePropagationDelay time.Duration
+	fakeSeed             uint64
+)
+
+var fakeServerCmd = &cobra.Command{
+	Use:   "fake-server",
+	Short: "Run a local NNTP server for testing uploads",
+	Long: `Fake-server runs an in-process NNTP server that accepts POST, streaming TAKETHIS/CHECK,
+STAT, ARTICLE, HEAD and BODY. Articles are kept in memory, or on disk with --storage-dir.
+
+Fault injection flags simulate a misbehaving provider: articles acknowledged but never stored
+(--drop-rate), articles that only show up after a delay (--propagation-delay), 441 rejections
+(--reject-rate) and connection resets (--reset-rate). Point a server entry of the configuration
+at the listen address to exercise post checks, retries and failover without a real provider.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setupLogging(verbose)
+
+		for name, rate := range map[string]float64{"drop-rate": fakeDropRate, "reject-rate": fakeRejectRate, "reset-rate": fakeResetRate} {
+			if rate < 0 || rate > 1 {
+				return fmt.Errorf("--%s must be between 0 and 1, got %v", name, rate)
+			}
+		}
+
+		var store nntptest.Store = nntptest.NewMemoryStore()
+		if fakeStorageDir != "" {
+			disk, err := nntptest.NewDiskStore(fakeStorageDir)
+			if err != nil {
+				return err
+			}
+			store = disk
+		}
+
+		srv, err := nntptest.Listen(fakeListen, nntptest.Options{
+			Username: fakeUsername,
+			Password: fakePassword,
+			Store:    store,
+			Seed:     fakeSeed,
+			Faults: nntptest.Faults{
+				DropRate:         fakeDropRate,
+				RejectRate:       fakeRejectRate,
+				ResetRate:        fakeResetRate,
+				PropagationDelay: fakePropagationDelay,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		slog.Info("Fake NNTP server listening",
+			"addr", srv.Addr(),
+			"storage", fakeStorageDir,
+			"dropRate", fakeDropRate,
+			"rejectRate", fakeRejectRate,
+			"resetRate", fakeResetRate,
+			"propagationDelay", fakePropagationDelay)
+
+		// Wait for shutdown signal
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		select {
+		case <-sigChan:
+		case <-cmd.Context().Done():
+		}
+
+		slog.Info("Shutting down...")
+		err = srv.Close()
+
+		s := srv.Stats()
+		slog.Info("Fake NNTP server stats",
+			"connections", s.Connections,
+			"posted", s.Posted,
+			"dropped", s.Dropped,
+			"rejected", s.Rejected,
+			"resets", s.Resets)
+		return err
+	},
+}
+
+func init() {
+	f := fakeServerCmd.Flags()
+	f.StringVar(&fakeListen, "listen", "127.0.0.1:1119", "Address to listen on")
+	f.StringVar(&fakeStorageDir, "storage-dir", "", "Directory to store articles in (default: in memory)")
+	f.StringVar(&fakeUsername, "username", "", "Require AUTHINFO with this username")
+	f.StringVar(&fakePassword, "password", "", "Password for --username")
+	f.Float64Var(&fakeDropRate, "drop-rate", 0, "Fraction of posts acknowledged but never stored (0-1)")
+	f.Float64Var(&fakeRejectRate, "reject-rate", 0, "Fraction of posts rejected with 441 (0-1)")
+	f.Float64Var(&fakeResetRate, "reset-rate", 0, "Fraction of article commands answered with a connection reset (0-1)")
+	f.DurationVar(&fakePropagationDelay, "propagation-delay", 0, "Delay before a posted article is visible to STAT/ARTICLE/HEAD")
+	f.Uint64Var(&fakeSeed, "seed", 0, "Seed for reproducible fault injection (default: random)")
+	rootCmd.AddCommand(fakeServerCmd)
+}
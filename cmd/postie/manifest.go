@@ -0,0 +1,370 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/manifest"
+	"github.com/javi11/postie/internal/nzbrebuild"
+	"github.com/spf13/cobra"
+)
+
+const manifestExt = ".jsonl.zst"
+
+var (
+	manifestInspectLimit int
+	manifestStatsJSON    bool
+	manifestExportFormat string
+	manifestExportOut    string
+)
+
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Inspect and export durable transfer manifests",
+	Long: `Transfer manifests are the zstd-compressed JSON Lines files postie records for every uploaded file
+under <database dir>/transfer-manifests/<transfer id>/. These commands decode them for debugging.`,
+}
+
+var manifestInspectCmd = &cobra.Command{
+	Use:   "inspect <manifest.jsonl.zst>",
+	Short: "Show the header, summary and article records of a manifest",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		out := cmd.OutOrStdout()
+
+		var stats manifestStats
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		printed := 0
+
+		_, _ = fmt.Fprintln(tw, "INDEX\tPART\tOFFSET\tSIZE\tMESSAGE-ID\tSUBJECT")
+		version, err := forEachManifestRecord(path, func(rec manifest.ArticleRecord) error {
+			stats.add(rec)
+			if manifestInspectLimit > 0 && printed >= manifestInspectLimit {
+				return nil
+			}
+			printed++
+			_, _ = fmt.Fprintf(tw, "%d\t%d/%d\t%d\t%d\t%s\t%s\n",
+				rec.Index, rec.PartNumber, rec.TotalParts, rec.Offset, rec.BodySize, rec.MessageID, rec.Subject)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		stats.Manifests = 1
+
+		_, _ = fmt.Fprintf(out, "Manifest: %s\nVersion:  %d\n", path, version)
+		printManifestStats(out, stats)
+		_, _ = fmt.Fprintln(out)
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if printed < stats.Articles {
+			_, _ = fmt.Fprintf(out, "... %d more records (use --limit 0 to show all)\n", stats.Articles-printed)
+		}
+		return nil
+	},
+}
+
+var manifestStatsCmd = &cobra.Command{
+	Use:   "stats <manifest.jsonl.zst|dir>...",
+	Short: "Summarize article counts, roles and byte totals across manifests",
+	Long: `Stats aggregates one or more manifests. Directories are walked for every .jsonl.zst file, so a
+transfer directory or the whole transfer-manifests directory can be passed.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths, err := collectManifestPaths(args)
+		if err != nil {
+			return err
+		}
+
+		stats := manifestStats{Versions: make(map[int]int)}
+		for _, path := range paths {
+			version, err := forEachManifestRecord(path, func(rec manifest.ArticleRecord) error {
+				stats.add(rec)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			stats.Manifests++
+			stats.Versions[version]++
+		}
+
+		out := cmd.OutOrStdout()
+		if manifestStatsJSON {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(stats)
+		}
+
+		printManifestStats(out, stats)
+		return nil
+	},
+}
+
+var manifestExportCmd = &cobra.Command{
+	Use:   "export <manifest.jsonl.zst|dir>...",
+	Short: "Export manifests as plain JSONL, CSV or NZB",
+	Long: `Export decodes one or more manifests. JSONL and CSV are streamed record by record to --out (or stdout).
+NZB replays every record through the NZB generator, the same way rebuild-nzb does, and requires --out.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths, err := collectManifestPaths(args)
+		if err != nil {
+			return err
+		}
+
+		format := strings.ToLower(manifestExportFormat)
+		if format == "nzb" {
+			if manifestExportOut == "" {
+				return fmt.Errorf("--out is required for the nzb format")
+			}
+			nzbPath, err := nzbrebuild.Generate(paths, config.NzbCompressionConfig{}, true, manifestExportOut)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "NZB written: %s\n", nzbPath)
+			return nil
+		}
+
+		// Check the format before --out is created, so a bad one does not
+		// truncate the target
+		var export func(io.Writer, []string) error
+		switch format {
+		case "jsonl":
+			export = exportManifestsJSONL
+		case "csv":
+			export = exportManifestsCSV
+		default:
+			return fmt.Errorf("unknown export format %q (supported: jsonl, csv, nzb)", manifestExportFormat)
+		}
+
+		if manifestExportOut == "" {
+			return export(cmd.OutOrStdout(), paths)
+		}
+		return exportManifestsToFile(manifestExportOut, paths, export)
+	},
+}
+
+func init() {
+	manifestInspectCmd.Flags().IntVar(&manifestInspectLimit, "limit", 20, "Maximum number of article records to print (0 = all)")
+	manifestStatsCmd.Flags().BoolVar(&manifestStatsJSON, "json", false, "Print the summary as JSON")
+	manifestExportCmd.Flags().StringVarP(&manifestExportFormat, "format", "f", "jsonl", "Export format: jsonl, csv or nzb")
+	manifestExportCmd.Flags().StringVar(&manifestExportOut, "out", "", "Output file (defaults to stdout for jsonl and csv)")
+
+	manifestCmd.AddCommand(manifestInspectCmd, manifestStatsCmd, manifestExportCmd)
+	rootCmd.AddCommand(manifestCmd)
+}
+
+// roleStats holds the totals of one file role.
+type roleStats struct {
+	Files    int    `json:"files"`
+	Articles int    `json:"articles"`
+	Bytes    uint64 `json:"bytes"`
+
+	sources map[string]struct{}
+}
+
+// manifestStats holds the totals of one or more manifests.
+type manifestStats struct {
+	Manifests int                              `json:"manifests"`
+	Versions  map[int]int                      `json:"versions,omitempty"`
+	Articles  int                              `json:"articles"`
+	Bytes     uint64                           `json:"bytes"`
+	Roles     map[manifest.FileRole]*roleStats `json:"roles"`
+	FirstDate time.Time                        `json:"first_date"`
+	LastDate  time.Time                        `json:"last_date"`
+}
+
+func (s *manifestStats) add(rec manifest.ArticleRecord) {
+	if s.Roles == nil {
+		s.Roles = make(map[manifest.FileRole]*roleStats)
+	}
+	rs, ok := s.Roles[rec.FileRole]
+	if !ok {
+		rs = &roleStats{sources: make(map[string]struct{})}
+		s.Roles[rec.FileRole] = rs
+	}
+	if _, seen := rs.sources[rec.SourcePath]; !seen {
+		rs.sources[rec.SourcePath] = struct{}{}
+		rs.Files++
+	}
+	rs.Articles++
+	rs.Bytes += rec.BodySize
+
+	s.Articles++
+	s.Bytes += rec.BodySize
+	if s.FirstDate.IsZero() || rec.Date.Before(s.FirstDate) {
+		s.FirstDate = rec.Date
+	}
+	if rec.Date.After(s.LastDate) {
+		s.LastDate = rec.Date
+	}
+}
+
+// printManifestStats writes a human-readable manifest summary.
+func printManifestStats(w io.Writer, s manifestStats) {
+	if s.Manifests > 1 {
+		_, _ = fmt.Fprintf(w, "Manifests: %d\n", s.Manifests)
+		versions := make([]int, 0, len(s.Versions))
+		for v := range s.Versions {
+			versions = append(versions, v)
+		}
+		sort.Ints(versions)
+		for _, v := range versions {
+			_, _ = fmt.Fprintf(w, "  version %d: %d\n", v, s.Versions[v])
+		}
+	}
+	_, _ = fmt.Fprintf(w, "Articles: %d\nBytes:    %d\n", s.Articles, s.Bytes)
+	if s.Articles > 0 {
+		_, _ = fmt.Fprintf(w, "Dates:    %s - %s\n", s.FirstDate.Format(time.RFC3339), s.LastDate.Format(time.RFC3339))
+	}
+
+	for _, role := range []manifest.FileRole{manifest.RoleOriginal, manifest.RoleGeneratedPar2, manifest.RoleExistingPar2, manifest.RolePackedVolume} {
+		rs, ok := s.Roles[role]
+		if !ok {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "  %-15s %d files, %d articles, %d bytes\n", role, rs.Files, rs.Articles, rs.Bytes)
+	}
+}
+
+// forEachManifestRecord streams every record of a manifest to fn and returns
+// the manifest version.
+func forEachManifestRecord(path string, fn func(manifest.ArticleRecord) error) (int, error) {
+	r, err := manifest.OpenReader(path)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	defer func() { _ = r.Close() }()
+
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return r.Version(), nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", path, err)
+		}
+		if err := fn(rec); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// collectManifestPaths expands directories in args to the manifests they
+// contain. Files are used as given.
+func collectManifestPaths(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			paths = append(paths, arg)
+			continue
+		}
+
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(path, manifestExt) {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no manifests found")
+	}
+	return paths, nil
+}
+
+// exportManifestsToFile writes the export of paths to the file out. Errors
+// closing the file are returned, so a short write is not missed.
+func exportManifestsToFile(out string, paths []string, export func(io.Writer, []string) error) (err error) {
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	return export(f, paths)
+}
+
+// exportManifestsJSONL writes every record as an uncompressed JSON line.
+func exportManifestsJSONL(w io.Writer, paths []string) error {
+	enc := json.NewEncoder(w)
+	for _, path := range paths {
+		if _, err := forEachManifestRecord(path, func(rec manifest.ArticleRecord) error {
+			return enc.Encode(rec)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var manifestCSVHeader = []string{
+	"index", "source_path", "role", "offset", "size", "message_id", "subject", "original_subject",
+	"from", "groups", "date", "file_name", "part", "parts", "file_size", "file_number",
+}
+
+// exportManifestsCSV writes every record as a CSV row.
+func exportManifestsCSV(w io.Writer, paths []string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(manifestCSVHeader); err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if _, err := forEachManifestRecord(path, func(rec manifest.ArticleRecord) error {
+			return cw.Write([]string{
+				strconv.Itoa(rec.Index),
+				rec.SourcePath,
+				string(rec.FileRole),
+				strconv.FormatInt(rec.Offset, 10),
+				strconv.FormatUint(rec.BodySize, 10),
+				rec.MessageID,
+				rec.Subject,
+				rec.OriginalSubject,
+				rec.From,
+				strings.Join(rec.Groups, ","),
+				rec.Date.Format(time.RFC3339),
+				rec.FileName,
+				strconv.Itoa(rec.PartNumber),
+				strconv.Itoa(rec.TotalParts),
+				strconv.FormatInt(rec.FileSize, 10),
+				strconv.Itoa(rec.FileNumber),
+			})
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
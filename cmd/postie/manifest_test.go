@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/javi11/postie/internal/manifest"
+)
+
+// writeTestManifests writes two manifests of two records each and returns
+// their paths.
+func writeTestManifests(t *testing.T) []string {
+	t.Helper()
+	dir := t.TempDir()
+	date := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var paths []string
+	for _, name := range []string{"a", "b"} {
+		path := filepath.Join(dir, name+manifestExt)
+		w, err := manifest.NewWriter(path)
+		if err != nil {
+			t.Fatalf("NewWriter: %v", err)
+		}
+		for i := range 2 {
+			if err := w.Write(manifest.ArticleRecord{
+				Index:      i,
+				SourcePath: "/data/" + name + ".bin",
+				FileRole:   manifest.RoleOriginal,
+				Offset:     int64(i) * 100,
+				BodySize:   100,
+				MessageID:  fmt.Sprintf("%s-%d@test", name, i+1),
+				Subject:    "subject, with comma",
+				Groups:     []string{"alt.a", "alt.b"},
+				Date:       date,
+				FileName:   name + ".bin",
+				PartNumber: i + 1,
+				TotalParts: 2,
+				FileSize:   200,
+			}); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatalf("Commit: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestExportManifestsJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := exportManifestsJSONL(&buf, writeTestManifests(t)); err != nil {
+		t.Fatalf("exportManifestsJSONL: %v", err)
+	}
+
+	var ids []string
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var rec manifest.ArticleRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		ids = append(ids, rec.MessageID)
+	}
+	want := []string{"a-1@test", "a-2@test", "b-1@test", "b-2@test"}
+	if len(ids) != len(want) {
+		t.Fatalf("ids = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("ids = %v, want %v", ids, want)
+			break
+		}
+	}
+}
+
+func TestExportManifestsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := exportManifestsCSV(&buf, writeTestManifests(t)); err != nil {
+		t.Fatalf("exportManifestsCSV: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("got %d rows, want a header and 4 records", len(rows))
+	}
+	if rows[0][0] != "index" || len(rows[0]) != len(manifestCSVHeader) {
+		t.Errorf("header = %v", rows[0])
+	}
+	row := rows[2]
+	if row[1] != "/data/a.bin" || row[3] != "100" || row[5] != "a-2@test" || row[6] != "subject, with comma" ||
+		row[9] != "alt.a,alt.b" || row[10] != "2026-01-02T03:04:05Z" || row[12] != "2" {
+		t.Errorf("row = %v", row)
+	}
+}
+
+func TestManifestExport_UnknownFormatKeepsOut(t *testing.T) {
+	paths := writeTestManifests(t)
+	out := filepath.Join(t.TempDir(), "export.txt")
+	if err := os.WriteFile(out, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manifestExportFormat, manifestExportOut = "xml", out
+	defer func() { manifestExportFormat, manifestExportOut = "jsonl", "" }()
+
+	if err := manifestExportCmd.RunE(manifestExportCmd, paths); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	if data, err := os.ReadFile(out); err != nil || string(data) != "keep" {
+		t.Errorf("--out = %q, %v; want it untouched", data, err)
+	}
+
+	// A known format writes --out
+	manifestExportFormat = "csv"
+	if err := manifestExportCmd.RunE(manifestExportCmd, paths); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if data, err := os.ReadFile(out); err != nil || !bytes.HasPrefix(data, []byte("index,")) {
+		t.Errorf("--out = %q, %v; want the CSV export", data, err)
+	}
+}
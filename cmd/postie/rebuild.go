@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/database"
+	"github.com/javi11/postie/internal/nzbrebuild"
+	"github.com/spf13/cobra"
+)
+
+var rebuildNzbPath string
+
+var rebuildCmd = &cobra.Command{
+	Use:   "rebuild-nzb <completed-item-id>",
+	Short: "Regenerate the NZB of a completed upload from its transfer manifests",
+	Long: `Rebuild-nzb reads the transfer manifests recorded while a completed item was uploaded and
+regenerates its NZB from them, without contacting the servers.
+
+By default the NZB is written back to the item's original NZB path; use --nzb-path to write it elsewhere.
+Manifests are removed once a transfer is verified unless post_check.maintain_manifests is enabled.
+The database is only read, apart from the item's NZB path, so this is safe to run while postie is running.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		id := args[0]
+
+		// Load configuration
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error loading configuration", "error", err)
+			return err
+		}
+
+		setupLogging(verbose)
+
+		// Initialize database
+		db, err := database.New(ctx, cfg.GetDatabaseConfig())
+		if err != nil {
+			slog.ErrorContext(ctx, "Error creating database", "error", err)
+			return err
+		}
+		defer func() {
+			if err := db.Close(); err != nil {
+				slog.ErrorContext(ctx, "Error closing database", "error", err)
+			}
+		}()
+
+		// Run database migrations
+		if err := db.EnsureMigrationCompatibility(); err != nil {
+			slog.ErrorContext(ctx, "Error running database migrations", "error", err)
+			return err
+		}
+
+		// Read the item straight from the database: opening a queue would
+		// re-queue the in-progress items of a running instance.
+		nzbPath, err := nzbrebuild.CompletedItem(ctx, db.DB, cfg, id, rebuildNzbPath)
+		if err != nil {
+			return fmt.Errorf("rebuilding NZB: %w", err)
+		}
+
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "NZB rebuilt: %s\n", nzbPath)
+		return nil
+	},
+}
+
+func init() {
+	rebuildCmd.Flags().StringVar(&rebuildNzbPath, "nzb-path", "", "Write the rebuilt NZB to this path instead of the item's original NZB path")
+	rootCmd.AddCommand(rebuildCmd)
+}
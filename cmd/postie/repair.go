@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/javi11/nzbparser"
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/manifest"
+	"github.com/javi11/postie/internal/nzb"
+	"github.com/javi11/postie/internal/par2"
+	"github.com/javi11/postie/internal/pool"
+	"github.com/javi11/postie/internal/poster"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	repairSourceDir   string
+	repairManifestDir string
+	repairDryRun      bool
+)
+
+var repairCmd = &cobra.Command{
+	Use:   "repair <file.nzb>",
+	Short: "Repost the missing segments of an existing NZB from its source files",
+	Long: `Repair STATs every segment of an existing NZB and reposts the missing ones from the original
+source files, reusing the same Message-IDs so the NZB stays valid.
+
+When the transfer manifests are available (--manifest-dir) the articles are reposted byte-for-byte with
+their original headers. Otherwise the articles are rebuilt from the NZB and the source file offsets.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		nzbPath := args[0]
+
+		if repairSourceDir == "" {
+			return fmt.Errorf("--source is required")
+		}
+
+		// Load configuration
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error loading configuration", "error", err)
+			return err
+		}
+
+		setupLogging(verbose)
+
+		nzbFile, err := nzb.Parse(nzbPath)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error parsing NZB", "error", err)
+			return err
+		}
+
+		// Index the durable manifests by Message-ID, if any were supplied.
+		manifestRecs := make(map[string]manifest.ArticleRecord)
+		if repairManifestDir != "" {
+			manifestRecs, err = loadManifestRecords(repairManifestDir)
+			if err != nil {
+				return fmt.Errorf("loading manifests: %w", err)
+			}
+		}
+
+		sources, err := indexSourceFiles(repairSourceDir)
+		if err != nil {
+			return fmt.Errorf("indexing source directory: %w", err)
+		}
+
+		records, err := repairRecords(nzbFile.Files, manifestRecs, sources)
+		if err != nil {
+			return err
+		}
+
+		// Initialize connection pool manager
+		poolManager, err := pool.New(cfg)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error creating connection pool manager", "error", err)
+			return err
+		}
+		defer func() {
+			if err := poolManager.Close(); err != nil {
+				slog.ErrorContext(ctx, "Error closing connection pool manager", "error", err)
+			}
+		}()
+
+		ids := segmentIDs(nzbFile)
+		missing, err := pool.StatMissing(ctx, poolManager.GetVerifyPool(), ids, cfg.GetPostCheckConfig().StatBatchSize)
+		if err != nil {
+			return fmt.Errorf("verifying segments: %w", err)
+		}
+
+		out := cmd.OutOrStdout()
+		if len(missing) == 0 {
+			_, _ = fmt.Fprintf(out, "All %d segments are available, nothing to repair\n", len(ids))
+			return nil
+		}
+
+		var toRepost []manifest.ArticleRecord
+		var unrecoverable int
+		for id := range missing {
+			rec, ok := records[id]
+			if !ok {
+				unrecoverable++
+				slog.WarnContext(ctx, "No source data for missing segment", "messageID", id)
+				continue
+			}
+			toRepost = append(toRepost, rec)
+		}
+
+		_, _ = fmt.Fprintf(out, "%d/%d segments missing, %d can be reposted from source\n", len(missing), len(ids), len(toRepost))
+		if repairDryRun {
+			return nil
+		}
+
+		// Bound concurrency and buffer memory the same way the upload runtime
+		// does, sized from the upload pool's connection capacity.
+		uploadPool := poolManager.GetUploadPool()
+		capacity := 0
+		for _, pr := range uploadPool.Stats().Providers {
+			capacity += pr.MaxConnections
+		}
+		postingCfg := cfg.GetPostingConfig()
+		engine := poster.NewEngine(postingCfg.MaxArticleSize(), postingCfg.UploadBufferMemoryLimit, capacity)
+		reposter := poster.NewReposter(uploadPool, engine, postingCfg.ThrottleRate)
+
+		var failed atomic.Int64
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(max(capacity, 1))
+		for _, rec := range toRepost {
+			g.Go(func() error {
+				if err := reposter.Repost(gctx, rec); err != nil {
+					if gctx.Err() != nil {
+						return gctx.Err()
+					}
+					failed.Add(1)
+					slog.ErrorContext(gctx, "Error reposting segment", "messageID", rec.MessageID, "file", rec.SourcePath, "error", err)
+				}
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return err
+		}
+
+		stats := reposter.Stats()
+		_, _ = fmt.Fprintf(out, "Reposted %d segments (%d bytes), %d failed\n", stats.ArticlesPosted, stats.BytesPosted, failed.Load())
+
+		if n := failed.Load() + int64(unrecoverable); n > 0 {
+			return fmt.Errorf("%d segments could not be repaired", n)
+		}
+		return nil
+	},
+}
+
+func init() {
+	repairCmd.Flags().StringVarP(&repairSourceDir, "source", "s", "", "Directory containing the original source files")
+	repairCmd.Flags().StringVar(&repairManifestDir, "manifest-dir", "", "Directory containing the transfer manifests (.jsonl.zst) of the upload")
+	repairCmd.Flags().BoolVar(&repairDryRun, "dry-run", false, "Only report the missing segments, do not repost them")
+	rootCmd.AddCommand(repairCmd)
+}
+
+// loadManifestRecords reads every manifest under dir and indexes its article
+// records by Message-ID.
+func loadManifestRecords(dir string) (map[string]manifest.ArticleRecord, error) {
+	paths, err := collectManifestPaths([]string{dir})
+	if err != nil {
+		return nil, err
+	}
+
+	recs := make(map[string]manifest.ArticleRecord)
+	for _, path := range paths {
+		if _, err := forEachManifestRecord(path, func(rec manifest.ArticleRecord) error {
+			recs[rec.MessageID] = rec
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return recs, nil
+}
+
+// indexSourceFiles maps the base name of every file under dir to its path.
+// NZBs only carry base names, so the first file found for a name wins.
+func indexSourceFiles(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if existing, ok := files[name]; ok {
+			slog.Warn("Duplicate source file name, using the first one", "name", name, "used", existing, "ignored", path)
+			return nil
+		}
+		files[name] = path
+		return nil
+	})
+	return files, err
+}
+
+// repairRecords returns the repost record for every segment whose source file
+// is available, keyed by Message-ID. Manifest records are preferred since they
+// carry the exact posted headers; the source path is re-resolved against the
+// source directory in case the files were moved since the upload.
+func repairRecords(files []nzbparser.NzbFile, manifestRecs map[string]manifest.ArticleRecord, sources map[string]string) (map[string]manifest.ArticleRecord, error) {
+	records := make(map[string]manifest.ArticleRecord)
+
+	for _, f := range files {
+		name := f.Filename
+		if len(f.Segments) > 0 {
+			if rec, ok := manifestRecs[f.Segments[0].ID]; ok {
+				name = filepath.Base(rec.SourcePath)
+			}
+		}
+
+		sourcePath, ok := sources[name]
+		if !ok {
+			slog.Warn("Source file not found, its segments cannot be reposted", "file", name)
+			continue
+		}
+
+		info, err := os.Stat(sourcePath)
+		if err != nil {
+			return nil, fmt.Errorf("stat source file: %w", err)
+		}
+
+		role := manifest.RoleOriginal
+		if par2.IsPar2File(sourcePath) {
+			role = manifest.RoleExistingPar2
+		}
+
+		for _, rec := range nzb.ArticleRecords(f, sourcePath, info.Size(), role) {
+			if mrec, ok := manifestRecs[rec.MessageID]; ok {
+				mrec.SourcePath = sourcePath
+				rec = mrec
+			}
+			records[rec.MessageID] = rec
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+
+	"github.com/javi11/nzbparser"
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/nzb"
+	"github.com/javi11/postie/internal/pool"
+	"github.com/spf13/cobra"
+)
+
+var verifyJSON bool
+
+// verifyFileReport is the per-file result of an NZB verification run.
+type verifyFileReport struct {
+	Filename        string `json:"filename"`
+	Subject         string `json:"subject"`
+	TotalSegments   int    `json:"total_segments"`
+	MissingSegments []int  `json:"missing_segments"`
+}
+
+// verifyReport is the full result of an NZB verification run.
+type verifyReport struct {
+	NzbPath         string             `json:"nzb_path"`
+	TotalSegments   int                `json:"total_segments"`
+	MissingSegments int                `json:"missing_segments"`
+	Files           []verifyFileReport `json:"files"`
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <file.nzb>",
+	Short: "Check that every segment of an existing NZB is still available",
+	Long: `Verify parses an existing NZB and sends a STAT for every segment through the verify pool.
+It prints a per-file report of missing segments without posting or requeueing anything.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		nzbPath := args[0]
+
+		// Load configuration
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error loading configuration", "error", err)
+			return err
+		}
+
+		setupLogging(verbose)
+
+		nzbFile, err := nzb.Parse(nzbPath)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error parsing NZB", "error", err)
+			return err
+		}
+
+		// Initialize connection pool manager
+		poolManager, err := pool.New(cfg)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error creating connection pool manager", "error", err)
+			return err
+		}
+		defer func() {
+			if err := poolManager.Close(); err != nil {
+				slog.ErrorContext(ctx, "Error closing connection pool manager", "error", err)
+			}
+		}()
+
+		slog.InfoContext(ctx, "Verifying NZB", "nzb", nzbPath, "files", len(nzbFile.Files))
+
+		ids := segmentIDs(nzbFile)
+		missing, err := pool.StatMissing(ctx, poolManager.GetVerifyPool(), ids, cfg.GetPostCheckConfig().StatBatchSize)
+		if err != nil {
+			return fmt.Errorf("verifying segments: %w", err)
+		}
+
+		report := buildVerifyReport(nzbPath, nzbFile, missing)
+
+		out := cmd.OutOrStdout()
+		if verifyJSON {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				return err
+			}
+		} else {
+			printVerifyReport(out, report)
+		}
+
+		if report.MissingSegments > 0 {
+			return fmt.Errorf("%d/%d segments missing", report.MissingSegments, report.TotalSegments)
+		}
+		return nil
+	},
+}
+
+func init() {
+	verifyCmd.Flags().BoolVar(&verifyJSON, "json", false, "Print the report as JSON")
+	rootCmd.AddCommand(verifyCmd)
+}
+
+// segmentIDs returns the Message-IDs of every segment in the NZB.
+func segmentIDs(nzbFile *nzbparser.Nzb) []string {
+	var ids []string
+	for _, f := range nzbFile.Files {
+		for _, s := range f.Segments {
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids
+}
+
+// buildVerifyReport groups the missing Message-IDs by NZB file.
+func buildVerifyReport(nzbPath string, nzbFile *nzbparser.Nzb, missing map[string]struct{}) verifyReport {
+	report := verifyReport{
+		NzbPath: nzbPath,
+		Files:   make([]verifyFileReport, 0, len(nzbFile.Files)),
+	}
+
+	for _, f := range nzbFile.Files {
+		fr := verifyFileReport{
+			Filename:        f.Filename,
+			Subject:         f.Subject,
+			TotalSegments:   len(f.Segments),
+			MissingSegments: []int{},
+		}
+		for _, s := range f.Segments {
+			if _, ok := missing[s.ID]; ok {
+				fr.MissingSegments = append(fr.MissingSegments, s.Number)
+			}
+		}
+		sort.Ints(fr.MissingSegments)
+
+		report.TotalSegments += fr.TotalSegments
+		report.MissingSegments += len(fr.MissingSegments)
+		report.Files = append(report.Files, fr)
+	}
+
+	return report
+}
+
+// printVerifyReport writes a human-readable verification report.
+func printVerifyReport(w io.Writer, report verifyReport) {
+	for _, f := range report.Files {
+		name := f.Filename
+		if name == "" {
+			name = f.Subject
+		}
+		if len(f.MissingSegments) == 0 {
+			_, _ = fmt.Fprintf(w, "OK       %s (%d segments)\n", name, f.TotalSegments)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "MISSING  %s (%d/%d segments missing): %v\n", name, len(f.MissingSegments), f.TotalSegments, f.MissingSegments)
+	}
+	_, _ = fmt.Fprintf(w, "\n%d/%d segments missing across %d files\n", report.MissingSegments, report.TotalSegments, len(report.Files))
+}
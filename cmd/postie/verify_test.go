@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/javi11/nzbparser"
+)
+
+func TestBuildVerifyReport(t *testing.T) {
+	// Segments are listed out of order to check the missing numbers are sorted.
+	nzbFile := &nzbparser.Nzb{Files: nzbparser.NzbFiles{
+		{Filename: "present.bin", Segments: nzbparser.NzbSegments{
+			{Number: 1, ID: "p1@test"}, {Number: 2, ID: "p2@test"},
+		}},
+		{Filename: "missing.bin", Segments: nzbparser.NzbSegments{
+			{Number: 2, ID: "m2@test"}, {Number: 1, ID: "m1@test"},
+		}},
+		{Subject: "partial", Segments: nzbparser.NzbSegments{
+			{Number: 3, ID: "x3@test"}, {Number: 1, ID: "x1@test"}, {Number: 2, ID: "x2@test"},
+		}},
+	}}
+
+	tests := []struct {
+		name        string
+		missing     []string
+		wantMissing [][]int
+		wantTotal   int
+	}{
+		{"all present", nil, [][]int{{}, {}, {}}, 0},
+		{"one file missing", []string{"m1@test", "m2@test"}, [][]int{{}, {1, 2}, {}}, 2},
+		{"partial file", []string{"x3@test", "x1@test"}, [][]int{{}, {}, {1, 3}}, 2},
+		{"unknown ids are ignored", []string{"other@test"}, [][]int{{}, {}, {}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing := make(map[string]struct{}, len(tt.missing))
+			for _, id := range tt.missing {
+				missing[id] = struct{}{}
+			}
+
+			report := buildVerifyReport("job.nzb", nzbFile, missing)
+			if report.NzbPath != "job.nzb" || report.TotalSegments != 7 || report.MissingSegments != tt.wantTotal {
+				t.Errorf("report = %s %d/%d, want job.nzb %d/7", report.NzbPath, report.MissingSegments, report.TotalSegments, tt.wantTotal)
+			}
+			if len(report.Files) != len(tt.wantMissing) {
+				t.Fatalf("got %d files, want %d", len(report.Files), len(tt.wantMissing))
+			}
+			for i, f := range report.Files {
+				if !reflect.DeepEqual(f.MissingSegments, tt.wantMissing[i]) {
+					t.Errorf("file %d missing = %v, want %v", i, f.MissingSegments, tt.wantMissing[i])
+				}
+			}
+			if report.Files[2].Subject != "partial" || report.Files[2].TotalSegments != 3 {
+				t.Errorf("file 2 = %+v", report.Files[2])
+			}
+		})
+	}
+}
@@ -132,12 +132,16 @@ func (ws *WebServer) handleArrWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	meta := arr.ExtractNzbMeta(payload, *instance)
 	for _, path := range paths {
 		req := backend.APIQueueUploadRequest{
 			File:              path,
 			RelativePath:      filepath.Dir(path),
 			Priority:          0,
 			DeleteAfterUpload: instance.DeleteAfterUpload,
+			Title:             meta.Title,
+			Category:          meta.Category,
+			Tags:              meta.Tags,
 		}
 		if _, err := ws.app.EnqueueAPIUpload(r.Context(), req); err != nil {
 			http.Error(w, fmt.Sprintf("queuing %s: %v", path, err), http.StatusInternalServerError)
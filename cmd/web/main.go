@@ -257,6 +257,7 @@ func (ws *WebServer) setupRoutes() {
 	api.HandleFunc("/queue/{id}/retry", ws.handleRetryJob).Methods("POST")
 	api.HandleFunc("/queue/{id}/cancel", ws.handleCancelJob).Methods("DELETE")
 	api.HandleFunc("/queue/{id}/priority", ws.handleSetQueueItemPriority).Methods("POST")
+	api.HandleFunc("/queue/{id}/verify-local", ws.handleVerifyLocalFiles).Methods("POST")
 	api.HandleFunc("/queue/stats", ws.handleGetQueueStats).Methods("GET")
 	api.HandleFunc("/logs", ws.handleGetLogs).Methods("GET")
 	api.HandleFunc("/logs/download", ws.handleDownloadLogs).Methods("GET")
@@ -264,6 +265,7 @@ func (ws *WebServer) setupRoutes() {
 	api.HandleFunc("/upload-folder", ws.handleUploadFolder).Methods("POST")
 	api.HandleFunc("/upload/cancel", ws.handleCancelUpload).Methods("POST")
 	api.HandleFunc("/nzb/{id}/download", ws.handleDownloadNZB).Methods("GET")
+	api.HandleFunc("/nzb/{id}/rebuild", ws.handleRebuildNZB).Methods("POST")
 	api.HandleFunc("/processor/status", ws.handleGetProcessorStatus).Methods("GET")
 	api.HandleFunc("/processor/pause", ws.handlePauseProcessing).Methods("POST")
 	api.HandleFunc("/processor/resume", ws.handleResumeProcessing).Methods("POST")
@@ -911,6 +913,34 @@ func (ws *WebServer) handleDownloadNZB(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte(nzbContent))
 }
 
+func (ws *WebServer) handleRebuildNZB(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	nzbPath, err := ws.app.RebuildNZB(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"nzbPath": nzbPath})
+}
+
+func (ws *WebServer) handleVerifyLocalFiles(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	checks, err := ws.app.VerifyLocalFiles(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(checks)
+}
+
 func (ws *WebServer) handleGetQueueStats(w http.ResponseWriter, r *http.Request) {
 	stats, err := ws.app.GetQueueStats()
 	if err != nil {
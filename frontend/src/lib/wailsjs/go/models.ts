@@ -174,6 +174,7 @@ export namespace backend {
 	    verificationStatus?: string;
 	    verifiedArticles?: number;
 	    totalArticles?: number;
+	    propagation?: queue.ProviderPropagation[];
 	
 	    static createFrom(source: any = {}) {
 	        return new QueueItem(source);
@@ -196,6 +197,7 @@ export namespace backend {
 	        this.verificationStatus = source["verificationStatus"];
 	        this.verifiedArticles = source["verifiedArticles"];
 	        this.totalArticles = source["totalArticles"];
+	        this.propagation = this.convertValues(source["propagation"], queue.ProviderPropagation);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -328,6 +330,12 @@ export namespace backend {
 	    ssl: boolean;
 	    maxConnections: number;
 	    role: string;
+	    insecureSsl?: boolean;
+	    proxyUrl?: string;
+	    caFile?: string;
+	    clientCert?: string;
+	    clientKey?: string;
+	    pinnedSha256?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new ServerData(source);
@@ -343,6 +351,12 @@ export namespace backend {
 	        this.ssl = source["ssl"];
 	        this.maxConnections = source["maxConnections"];
 	        this.role = source["role"];
+	        this.insecureSsl = source["insecureSsl"];
+	        this.proxyUrl = source["proxyUrl"];
+	        this.caFile = source["caFile"];
+	        this.clientCert = source["clientCert"];
+	        this.clientKey = source["clientKey"];
+	        this.pinnedSha256 = source["pinnedSha256"];
 	    }
 	}
 	export class SetupWizardData {
@@ -1033,6 +1047,29 @@ export namespace progress {
 
 }
 
+export namespace queue {
+	
+	export class ProviderPropagation {
+	    provider: string;
+	    presentArticles: number;
+	    totalArticles: number;
+	    percent: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProviderPropagation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.provider = source["provider"];
+	        this.presentArticles = source["presentArticles"];
+	        this.totalArticles = source["totalArticles"];
+	        this.percent = source["percent"];
+	    }
+	}
+
+}
+
 export namespace watcher {
 	
 	export class WatcherScheduleInfo {
@@ -1,5 +1,11 @@
 package arr
 
+import (
+	"fmt"
+
+	"github.com/javi11/postie/internal/config"
+)
+
 // WebhookPayload is a unified struct covering the import event payloads from
 // Radarr, Sonarr, Lidarr, and Readarr. Fields unused by a given app are nil/empty.
 type WebhookPayload struct {
@@ -8,14 +14,29 @@ type WebhookPayload struct {
 	EpisodeFile *EpisodeFilePayload `json:"episodeFile,omitempty"`
 	TrackFiles  []TrackFilePayload  `json:"trackFiles,omitempty"`
 	BookFiles   []BookFilePayload   `json:"bookFiles,omitempty"`
+	Movie       *MoviePayload       `json:"movie,omitempty"`
+	Series      *SeriesPayload      `json:"series,omitempty"`
+}
+
+type MoviePayload struct {
+	Title string   `json:"title"`
+	Year  int      `json:"year"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+type SeriesPayload struct {
+	Title string   `json:"title"`
+	Tags  []string `json:"tags,omitempty"`
 }
 
 type MovieFilePayload struct {
-	Path string `json:"path"`
+	Path      string `json:"path"`
+	SceneName string `json:"sceneName,omitempty"`
 }
 
 type EpisodeFilePayload struct {
-	Path string `json:"path"`
+	Path      string `json:"path"`
+	SceneName string `json:"sceneName,omitempty"`
 }
 
 type TrackFilePayload struct {
@@ -55,3 +76,31 @@ func ExtractFilePaths(payload WebhookPayload) []string {
 
 	return paths
 }
+
+// ExtractNzbMeta returns the NZB metadata of an import: the release's scene
+// name as title, falling back to the movie or series title, the movie or
+// series tags, and the instance's category.
+func ExtractNzbMeta(payload WebhookPayload, instance config.ArrInstance) config.NzbMeta {
+	meta := config.NzbMeta{Category: instance.NzbCategory()}
+
+	switch {
+	case payload.MovieFile != nil && payload.MovieFile.SceneName != "":
+		meta.Title = payload.MovieFile.SceneName
+	case payload.EpisodeFile != nil && payload.EpisodeFile.SceneName != "":
+		meta.Title = payload.EpisodeFile.SceneName
+	case payload.Movie != nil && payload.Movie.Year > 0:
+		meta.Title = fmt.Sprintf("%s (%d)", payload.Movie.Title, payload.Movie.Year)
+	case payload.Movie != nil:
+		meta.Title = payload.Movie.Title
+	case payload.Series != nil:
+		meta.Title = payload.Series.Title
+	}
+
+	if payload.Movie != nil {
+		meta.Tags = payload.Movie.Tags
+	} else if payload.Series != nil {
+		meta.Tags = payload.Series.Tags
+	}
+
+	return meta
+}
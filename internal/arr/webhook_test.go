@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/javi11/postie/internal/arr"
+	"github.com/javi11/postie/internal/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -56,3 +57,23 @@ func TestExtractFilePaths_TestEventIgnored(t *testing.T) {
 	got := arr.ExtractFilePaths(payload)
 	assert.Empty(t, got)
 }
+
+func TestExtractNzbMeta_Radarr(t *testing.T) {
+	payload := arr.WebhookPayload{
+		EventType: "Download",
+		Movie:     &arr.MoviePayload{Title: "The Matrix", Year: 1999, Tags: []string{"4k"}},
+		MovieFile: &arr.MovieFilePayload{Path: "/movies/The.Matrix.1999.mkv"},
+	}
+	got := arr.ExtractNzbMeta(payload, config.ArrInstance{Type: config.ArrTypeRadarr})
+	assert.Equal(t, config.NzbMeta{Title: "The Matrix (1999)", Category: "movies", Tags: []string{"4k"}}, got)
+}
+
+func TestExtractNzbMeta_SonarrSceneName(t *testing.T) {
+	payload := arr.WebhookPayload{
+		EventType:   "Download",
+		Series:      &arr.SeriesPayload{Title: "Breaking Bad"},
+		EpisodeFile: &arr.EpisodeFilePayload{Path: "/tv/Breaking.Bad.S01E01.mkv", SceneName: "Breaking.Bad.S01E01.720p"},
+	}
+	got := arr.ExtractNzbMeta(payload, config.ArrInstance{Type: config.ArrTypeSonarr, Category: "series"})
+	assert.Equal(t, config.NzbMeta{Title: "Breaking.Bad.S01E01.720p", Category: "series"}, got)
+}
@@ -2,7 +2,11 @@ package article
 
 import (
 	"bytes"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"maps"
@@ -177,6 +181,34 @@ func GenerateMessageID() (string, error) {
 	return fmt.Sprintf("%s@%s.%s", rand32, rand8, rand3), nil
 }
 
+// FileContentHash returns the SHA-256 of the content read from r. It is the
+// per-file input of DeriveMessageID.
+func FileContentHash(r io.Reader) ([]byte, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+var messageIDEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// DeriveMessageID returns the Message-ID of one part of a file as an
+// HMAC-SHA256, keyed with secret, of the file's content hash and the part's
+// number, offset and size. The same file split the same way, with the same
+// secret and domain, always maps to the same IDs; a different article size
+// gives different IDs, so a part is never mistaken for one cut differently.
+// Format: {hmac(32)}@{domain}
+func DeriveMessageID(secret string, contentHash []byte, partNumber int, offset, size int64, domain string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(contentHash)
+	_ = binary.Write(mac, binary.BigEndian, uint64(partNumber))
+	_ = binary.Write(mac, binary.BigEndian, uint64(offset))
+	_ = binary.Write(mac, binary.BigEndian, uint64(size))
+	local := strings.ToLower(messageIDEncoding.EncodeToString(mac.Sum(nil)[:20]))
+	return local + "@" + domain
+}
+
 // GenerateFrom generates a From header following the obfuscation pattern
 func GenerateFrom() (string, error) {
 	// Format: {rand(14)} <{rand(14)}@{rand(5)}.{rand(3)}>
@@ -255,6 +255,67 @@ func TestGenerateMessageID(t *testing.T) {
 	}
 }
 
+func TestDeriveMessageID(t *testing.T) {
+	hash, err := FileContentHash(strings.NewReader("file content"))
+	if err != nil {
+		t.Fatalf("FileContentHash failed: %v", err)
+	}
+
+	id := DeriveMessageID("secret", hash, 1, 0, 100, "example.net")
+	pattern := `^[a-z2-7]{32}@example\.net$`
+	if match, _ := regexp.MatchString(pattern, id); !match {
+		t.Errorf("Derived message ID %s doesn't match expected pattern %s", id, pattern)
+	}
+
+	if again := DeriveMessageID("secret", hash, 1, 0, 100, "example.net"); again != id {
+		t.Errorf("DeriveMessageID is not deterministic: %s != %s", again, id)
+	}
+	if other := DeriveMessageID("secret", hash, 2, 100, 100, "example.net"); other == id {
+		t.Errorf("parts 1 and 2 derived the same message ID %s", id)
+	}
+	if other := DeriveMessageID("other secret", hash, 1, 0, 100, "example.net"); other == id {
+		t.Errorf("different secrets derived the same message ID %s", id)
+	}
+	if other := DeriveMessageID("secret", hash, 1, 0, 80, "example.net"); other == id {
+		t.Errorf("different article sizes derived the same message ID %s", id)
+	}
+	otherHash, _ := FileContentHash(strings.NewReader("other content"))
+	if other := DeriveMessageID("secret", otherHash, 1, 0, 100, "example.net"); other == id {
+		t.Errorf("different files derived the same message ID %s", id)
+	}
+}
+
+func TestGenerateIdentities(t *testing.T) {
+	ids := GenerateIdentities("seed", 5, false)
+	if len(ids) != 5 {
+		t.Fatalf("GenerateIdentities returned %d identities, want 5", len(ids))
+	}
+	pattern := `^[a-zA-Z0-9]{14} <[a-zA-Z0-9]{14}@[a-zA-Z0-9]{5}\.[a-zA-Z0-9]{3}>$`
+	for _, id := range ids {
+		if match, _ := regexp.MatchString(pattern, id); !match {
+			t.Errorf("Generated identity %s doesn't match expected pattern %s", id, pattern)
+		}
+	}
+	if again := GenerateIdentities("seed", 5, false); strings.Join(again, ",") != strings.Join(ids, ",") {
+		t.Errorf("GenerateIdentities is not deterministic for a seed: %v != %v", again, ids)
+	}
+	if other := GenerateIdentities("other", 5, false); other[0] == ids[0] {
+		t.Errorf("different seeds generated the same identity %s", ids[0])
+	}
+	// Unseeded pools are built per job; every job of a run gets the same set.
+	if a, b := GenerateIdentities("", 5, false), GenerateIdentities("", 5, false); strings.Join(a, ",") != strings.Join(b, ",") {
+		t.Errorf("unseeded identities changed within the process: %v != %v", a, b)
+	}
+
+	named := GenerateIdentities("seed", 3, true)
+	namedPattern := `^[A-Z][a-z]+ [A-Z][a-z]+ <[a-z]+\.[a-z]+[0-9]*@[a-z.]+>$`
+	for _, id := range named {
+		if match, _ := regexp.MatchString(namedPattern, id); !match {
+			t.Errorf("Generated identity %s doesn't match expected pattern %s", id, namedPattern)
+		}
+	}
+}
+
 func TestGenerateFrom(t *testing.T) {
 	from, err := GenerateFrom()
 	if err != nil {
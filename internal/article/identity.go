@@ -0,0 +1,77 @@
+package article
+
+import (
+	"fmt"
+	"hash/fnv"
+	mrand "math/rand"
+	"strings"
+	"time"
+)
+
+var (
+	identityFirstNames = []string{
+		"Alex", "Anna", "Ben", "Chris", "Daniel", "David", "Emma", "Eric", "Jack", "James",
+		"Julia", "Kevin", "Laura", "Lucas", "Maria", "Mark", "Martin", "Nina", "Paul", "Peter",
+		"Rachel", "Sam", "Sarah", "Simon", "Sophie", "Thomas", "Tom", "Victor",
+	}
+	identityLastNames = []string{
+		"Baker", "Brown", "Carter", "Clark", "Cooper", "Davis", "Evans", "Fischer", "Garcia", "Green",
+		"Hall", "Harris", "Hughes", "King", "Lewis", "Martin", "Miller", "Moore", "Nelson", "Parker",
+		"Reed", "Scott", "Taylor", "Turner", "Walker", "White", "Wilson", "Young",
+	}
+	identityDomains = []string{
+		"gmail.com", "outlook.com", "yahoo.com", "hotmail.com", "gmx.net", "web.de",
+		"proton.me", "mail.com", "aol.com", "icloud.com",
+	}
+)
+
+const identityCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// processSeed is the source of unseeded identities. It is drawn once per
+// process, so every job of a run generates the same set.
+var processSeed = time.Now().UnixNano()
+
+// GenerateIdentities returns n From identities generated from seed. The same
+// seed always generates the same identities; an empty seed generates the same
+// set for the life of the process and a new one on every start. Identities are either in the GenerateFrom format or, when
+// named is set, a first and last name with a matching mailbox
+// ("Anna Reed <anna.reed84@gmx.net>").
+func GenerateIdentities(seed string, n int, named bool) []string {
+	var src int64
+	if seed == "" {
+		src = processSeed
+	} else {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(seed))
+		src = int64(h.Sum64())
+	}
+	r := mrand.New(mrand.NewSource(src))
+
+	identities := make([]string, 0, n)
+	seen := make(map[string]struct{}, n)
+	for len(identities) < n {
+		var id string
+		if named {
+			first := identityFirstNames[r.Intn(len(identityFirstNames))]
+			last := identityLastNames[r.Intn(len(identityLastNames))]
+			domain := identityDomains[r.Intn(len(identityDomains))]
+			id = fmt.Sprintf("%s %s <%s.%s%d@%s>", first, last, strings.ToLower(first), strings.ToLower(last), r.Intn(100), domain)
+		} else {
+			id = fmt.Sprintf("%s <%s@%s.%s>", seededString(r, 14), seededString(r, 14), seededString(r, 5), seededString(r, 3))
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		identities = append(identities, id)
+	}
+	return identities
+}
+
+func seededString(r *mrand.Rand, length int) string {
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = identityCharset[r.Intn(len(identityCharset))]
+	}
+	return string(b)
+}
@@ -0,0 +1,121 @@
+package article
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/javi11/postie/internal/tokentemplate"
+)
+
+// DefaultSubjectTemplate is the subject used when no subject template is
+// configured. It renders the same subject as GenerateSubject.
+const DefaultSubjectTemplate = `[{filenum}/{files}] "{relpath}" - yEnc ({part}/{parts})`
+
+// TemplateVars are the values substituted into subject, From and custom
+// header templates.
+type TemplateVars struct {
+	// FileName is the base name of the file ({filename}).
+	FileName string
+	// RelPath is the file's path relative to the posted folder, or the file
+	// name when it was posted on its own ({relpath}).
+	RelPath string
+	// Folder is the posted folder name ({folder}): the first element of
+	// RelPath, or the name of the file's parent directory.
+	Folder  string
+	Part    int
+	Parts   int
+	FileNum int
+	Files   int
+	Size    int64
+	Date    time.Time
+}
+
+// NewTemplateVars returns the per-file template values for filePath. relPath
+// may be empty, in which case the file name is used. Part and Parts are left
+// for the caller to set per article.
+func NewTemplateVars(filePath, relPath string, fileNum, files int, size int64, date time.Time) TemplateVars {
+	fileName := filepath.Base(filePath)
+	if relPath == "" {
+		relPath = fileName
+	}
+	folder := filepath.Base(filepath.Dir(filePath))
+	if dir, _, ok := strings.Cut(filepath.ToSlash(relPath), "/"); ok {
+		folder = dir
+	}
+	return TemplateVars{
+		FileName: fileName,
+		RelPath:  relPath,
+		Folder:   folder,
+		FileNum:  fileNum,
+		Files:    files,
+		Size:     size,
+		Date:     date,
+	}
+}
+
+// templateTokens lists the supported tokens. The 0-prefixed variants are
+// zero-padded to the width of their total, as in Nyuu ({0part} is "007" of
+// 120 parts).
+var templateTokens = map[string]func(v TemplateVars) string{
+	"filename": func(v TemplateVars) string { return v.FileName },
+	"relpath":  func(v TemplateVars) string { return v.RelPath },
+	"folder":   func(v TemplateVars) string { return v.Folder },
+	"part":     func(v TemplateVars) string { return strconv.Itoa(v.Part) },
+	"0part":    func(v TemplateVars) string { return zeroPad(v.Part, v.Parts) },
+	"parts":    func(v TemplateVars) string { return strconv.Itoa(v.Parts) },
+	"filenum":  func(v TemplateVars) string { return strconv.Itoa(v.FileNum) },
+	"0filenum": func(v TemplateVars) string { return zeroPad(v.FileNum, v.Files) },
+	"files":    func(v TemplateVars) string { return strconv.Itoa(v.Files) },
+	"size":     func(v TemplateVars) string { return strconv.FormatInt(v.Size, 10) },
+	"date":     func(v TemplateVars) string { return v.Date.UTC().Format(time.DateOnly) },
+}
+
+func zeroPad(n, total int) string {
+	return fmt.Sprintf("%0*d", len(strconv.Itoa(total)), n)
+}
+
+// Template is a parsed subject, From or header template. Tokens are written
+// as {name}; a literal brace is written as {{ or }}.
+type Template struct {
+	// parts alternates literal text (even indexes) and token names (odd).
+	parts      []string
+	perArticle bool
+}
+
+// ParseTemplate parses s, rejecting unknown tokens and unbalanced braces.
+func ParseTemplate(s string) (*Template, error) {
+	parts, err := tokentemplate.Parse(s, templateTokens)
+	if err != nil {
+		return nil, err
+	}
+	t := &Template{parts: parts}
+	for i := 1; i < len(parts); i += 2 {
+		if parts[i] == "part" || parts[i] == "0part" {
+			t.perArticle = true
+		}
+	}
+	return t, nil
+}
+
+// PerArticle reports whether the template renders differently for each
+// article of a file (it uses {part} or {0part}).
+func (t *Template) PerArticle() bool { return t.perArticle }
+
+// Expand renders the template with v.
+func (t *Template) Expand(v TemplateVars) string {
+	if len(t.parts) == 1 {
+		return t.parts[0]
+	}
+	var sb strings.Builder
+	for i, p := range t.parts {
+		if i%2 == 0 {
+			sb.WriteString(p)
+		} else {
+			sb.WriteString(templateTokens[p](v))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,77 @@
+package article
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTemplate(t *testing.T) {
+	for _, tmpl := range []string{
+		"",
+		"plain text",
+		DefaultSubjectTemplate,
+		"{{literal}} {filename}",
+	} {
+		if _, err := ParseTemplate(tmpl); err != nil {
+			t.Errorf("ParseTemplate(%q): %v", tmpl, err)
+		}
+	}
+
+	for _, tmpl := range []string{
+		"{unknown}",
+		"{filename",
+		"closing } only",
+	} {
+		if _, err := ParseTemplate(tmpl); err == nil {
+			t.Errorf("ParseTemplate(%q): expected an error", tmpl)
+		}
+	}
+}
+
+func TestTemplateExpand(t *testing.T) {
+	date := time.Date(2026, 3, 4, 23, 0, 0, 0, time.UTC)
+	vars := NewTemplateVars("/data/Show/S01/ep.mkv", "Show/S01/ep.mkv", 7, 120, 1234, date)
+	vars.Part, vars.Parts = 5, 1000
+
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{DefaultSubjectTemplate, `[7/120] "Show/S01/ep.mkv" - yEnc (5/1000)`},
+		{"{0filenum}/{files} {0part}/{parts}", "007/120 0005/1000"},
+		{"{folder} {filename} {size} {date}", "Show ep.mkv 1234 2026-03-04"},
+		{"{{filename}}", "{filename}"},
+	}
+	for _, tt := range tests {
+		tmpl, err := ParseTemplate(tt.tmpl)
+		if err != nil {
+			t.Fatalf("ParseTemplate(%q): %v", tt.tmpl, err)
+		}
+		if got := tmpl.Expand(vars); got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+
+	// Without a relative path, {relpath} is the file name and {folder} the
+	// parent directory.
+	vars = NewTemplateVars("/data/Movies/film.mkv", "", 1, 1, 1, date)
+	if vars.RelPath != "film.mkv" || vars.Folder != "Movies" {
+		t.Errorf("got relpath %q folder %q", vars.RelPath, vars.Folder)
+	}
+}
+
+func TestTemplatePerArticle(t *testing.T) {
+	for tmpl, want := range map[string]bool{
+		"{filename}":       false,
+		"({part}/{parts})": true,
+		"{0part}":          true,
+	} {
+		parsed, err := ParseTemplate(tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.PerArticle() != want {
+			t.Errorf("PerArticle(%q) = %v, want %v", tmpl, parsed.PerArticle(), want)
+		}
+	}
+}
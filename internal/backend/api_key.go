@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/javi11/postie/internal/apikey"
+	"github.com/javi11/postie/internal/config"
 	"github.com/javi11/postie/internal/queue"
 )
 
@@ -59,6 +60,11 @@ type APIQueueUploadRequest struct {
 	RelativePath      string `json:"relative_path"`
 	Priority          int    `json:"priority,omitempty"`
 	DeleteAfterUpload bool   `json:"delete_after_upload,omitempty"`
+	// NZB <head> metadata; set fields override the configured nzb_meta.
+	Title    string   `json:"title,omitempty"`
+	Password string   `json:"password,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
 }
 
 // APIQueueUploadResult describes the side-effect of a successful enqueue call.
@@ -109,6 +115,10 @@ func (a *App) EnqueueAPIUpload(ctx context.Context, req APIQueueUploadRequest) (
 		InputFolder:    cleanRoot,
 		DeleteOriginal: &delete,
 	}
+	meta := config.NzbMeta{Title: req.Title, Password: req.Password, Category: req.Category, Tags: req.Tags}
+	if !meta.IsZero() {
+		opts.NzbMeta = &meta
+	}
 	if err := a.queue.AddFileWithOptions(ctx, cleanFile, info.Size(), opts); err != nil {
 		return nil, fmt.Errorf("enqueue file: %w", err)
 	}
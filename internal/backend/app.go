@@ -13,8 +13,6 @@ import (
 	"sync/atomic"
 	"time"
 
-	"crypto/tls"
-
 	"github.com/javi11/nntppool/v4"
 	"github.com/javi11/postie/internal/config"
 	"github.com/javi11/postie/internal/database"
@@ -37,6 +35,14 @@ type ServerData struct {
 	SSL            bool   `json:"ssl"`
 	MaxConnections int    `json:"maxConnections"`
 	Role           string `json:"role"` // "upload" | "verify" | "" (defaults to upload)
+	// TLS and proxy settings of the server, so a connectivity test connects
+	// the way posting does. See config.ServerConfig.
+	InsecureSSL  bool   `json:"insecureSsl,omitempty"`
+	ProxyURL     string `json:"proxyUrl,omitempty"`
+	CAFile       string `json:"caFile,omitempty"`
+	ClientCert   string `json:"clientCert,omitempty"`
+	ClientKey    string `json:"clientKey,omitempty"`
+	PinnedSHA256 string `json:"pinnedSha256,omitempty"`
 }
 
 // SetupWizardData represents the complete setup wizard data from the frontend
@@ -106,6 +112,13 @@ type NntpProviderMetrics struct {
 	QuotaUsed         int64   `json:"quotaUsed"`
 	QuotaResetAt      string  `json:"quotaResetAt"` // RFC3339, "" = no period
 	QuotaExceeded     bool    `json:"quotaExceeded"`
+	// Upload health, "" / 0 for verify-only providers.
+	HealthScore      float64 `json:"healthScore"` // 0-100, 0 while quarantined
+	ErrorRate        float64 `json:"errorRate"`   // share of failed posts in the recent window
+	AvgLatency       string  `json:"avgLatency"`  // mean post latency in the recent window
+	StaleConnErrors  int     `json:"staleConnErrors"`
+	CircuitState     string  `json:"circuitState"`     // closed, open or half_open
+	QuarantinedUntil string  `json:"quarantinedUntil"` // RFC3339, "" = not quarantined
 }
 
 // App struct for the Wails application
@@ -133,6 +146,7 @@ type App struct {
 	pendingConfigMux     sync.RWMutex
 	isApplyingConfig     atomic.Bool
 	postCheckWorker      *processor.PostCheckRetryWorker
+	nzbSinkWorker        *processor.NzbSinkRetryWorker
 	broadcaster          *eventBroadcaster
 
 	// queueStatsCache short-circuits GetQueueStats polling: under a heavy
@@ -404,6 +418,9 @@ func (a *App) Startup(ctx context.Context) {
 		// Initialize post check retry worker if post check is enabled
 		a.initializePostCheckWorker()
 
+		// Initialize NZB sink retry worker if NZB sinks are configured
+		a.initializeNzbSinkWorker()
+
 		// Initialize watchers if enabled and configuration is valid
 		if err := a.initializeWatchers(); err != nil {
 			slog.Error(fmt.Sprintf("Failed to initialize watchers: %v", err))
@@ -435,6 +452,12 @@ func (a *App) Shutdown() {
 		a.postCheckWorker = nil
 	}
 
+	// Stop NZB sink retry worker if running
+	if a.nzbSinkWorker != nil {
+		a.nzbSinkWorker.Stop()
+		a.nzbSinkWorker = nil
+	}
+
 	// Stop watchers if running
 	if a.watchCancel != nil {
 		a.watchCancel()
@@ -962,6 +985,12 @@ func (a *App) SetupWizardComplete(wizardData SetupWizardData) error {
 			MaxConnections: serverData.MaxConnections,
 			Enabled:        &enabled,
 			Role:           role,
+			InsecureSSL:    serverData.InsecureSSL,
+			ProxyURL:       serverData.ProxyURL,
+			CAFile:         serverData.CAFile,
+			ClientCert:     serverData.ClientCert,
+			ClientKey:      serverData.ClientKey,
+			PinnedSHA256:   serverData.PinnedSHA256,
 		}
 		cfg.Servers[i] = server
 		slog.Debug("Configured server", "index", i, "host", serverData.Host, "port", serverData.Port, "ssl", serverData.SSL, "role", role)
@@ -1014,32 +1043,39 @@ func (a *App) TestProviderConnectivity(serverData ServerData) ValidationResult {
 		}
 	}
 
-	// Convert to nntppool v4 Provider
-	addr := fmt.Sprintf("%s:%d", serverData.Host, serverData.Port)
-	provider := nntppool.Provider{
-		Host:        addr,
-		Auth:        nntppool.Auth{Username: serverData.Username, Password: serverData.Password},
-		Connections: 1, // Use single connection for testing
-	}
+	// Test with the full provider settings (TLS trust, client certificate,
+	// pin and proxy) so servers that need them validate like they connect.
+	provider := config.ServerConfigToProvider(config.ServerConfig{
+		Host:         serverData.Host,
+		Port:         serverData.Port,
+		Username:     serverData.Username,
+		Password:     serverData.Password,
+		SSL:          serverData.SSL,
+		InsecureSSL:  serverData.InsecureSSL,
+		ProxyURL:     serverData.ProxyURL,
+		CAFile:       serverData.CAFile,
+		ClientCert:   serverData.ClientCert,
+		ClientKey:    serverData.ClientKey,
+		PinnedSHA256: serverData.PinnedSHA256,
+	})
+	provider.Connections = 1 // Use single connection for testing
 
-	if serverData.SSL {
-		provider.TLSConfig = &tls.Config{
-			ServerName: serverData.Host,
-		}
-	}
+	return testProvider(provider, serverData.Host, serverData.Port)
+}
 
-	// Use TestProvider from nntppool v4
+// testProvider runs the nntppool v4 connectivity test against provider.
+func testProvider(provider nntppool.Provider, host string, port int) ValidationResult {
 	ctx := context.Background()
 	result := nntppool.TestProvider(ctx, provider)
 	if result.Err != nil {
-		slog.Warn("Provider connectivity test failed", "host", serverData.Host, "port", serverData.Port, "error", result.Err)
+		slog.Warn("Provider connectivity test failed", "host", host, "port", port, "error", result.Err)
 		return ValidationResult{
 			Valid: false,
 			Error: fmt.Sprintf("Connection test failed: %v", result.Err),
 		}
 	}
 
-	slog.Info("Provider connectivity test successful", "host", serverData.Host, "port", serverData.Port, "rtt", result.RTT)
+	slog.Info("Provider connectivity test successful", "host", host, "port", port, "rtt", result.RTT)
 	return ValidationResult{
 		Valid: true,
 		Error: "",
@@ -1213,6 +1249,11 @@ func (a *App) GetNntpPoolMetrics() (NntpPoolMetrics, error) {
 		}
 	}
 
+	healthByAddr := make(map[string]pool.ProviderHealth)
+	for _, h := range a.poolManager.ProviderHealth() {
+		healthByAddr[h.Name] = h
+	}
+
 	// Convert provider metrics from v4 ProviderStats
 	providers := make([]NntpProviderMetrics, 0, len(stats.Providers))
 	for _, provider := range stats.Providers {
@@ -1224,6 +1265,15 @@ func (a *App) GetNntpPoolMetrics() (NntpPoolMetrics, error) {
 		if !provider.QuotaResetAt.IsZero() {
 			quotaResetAt = provider.QuotaResetAt.Format(time.RFC3339)
 		}
+		health := healthByAddr[provider.Name]
+		avgLatency := ""
+		if health.AvgLatency > 0 {
+			avgLatency = health.AvgLatency.String()
+		}
+		quarantinedUntil := ""
+		if health.State == pool.CircuitOpen {
+			quarantinedUntil = health.QuarantinedUntil.Format(time.RFC3339)
+		}
 		providers = append(providers, NntpProviderMetrics{
 			Name:              nameByAddr[provider.Name],
 			Host:              provider.Name,
@@ -1242,6 +1292,12 @@ func (a *App) GetNntpPoolMetrics() (NntpPoolMetrics, error) {
 			QuotaUsed:         provider.QuotaUsed,
 			QuotaResetAt:      quotaResetAt,
 			QuotaExceeded:     provider.QuotaExceeded,
+			HealthScore:       health.Score,
+			ErrorRate:         health.ErrorRate,
+			AvgLatency:        avgLatency,
+			StaleConnErrors:   health.StaleConnErrors,
+			CircuitState:      string(health.State),
+			QuarantinedUntil:  quarantinedUntil,
 		})
 	}
 	metrics.Providers = providers
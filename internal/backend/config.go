@@ -206,6 +206,11 @@ func (a *App) processorConfigChanged(newConfig *config.ConfigData) bool {
 		return true
 	}
 
+	// Captured by the transfer cleaner when the runtime is built
+	if !equalBoolPtr(old.GetPostCheckConfig().MaintainManifests, newConfig.GetPostCheckConfig().MaintainManifests) {
+		return true
+	}
+
 	if a.par2ConfigChanged(newConfig) {
 		return true
 	}
@@ -366,6 +371,9 @@ func (a *App) applyConfigChanges(configData *config.ConfigData) error {
 		slog.Info("Watcher config unchanged, skipping watcher restart")
 	}
 
+	// Restart the NZB sink retry worker so it delivers with the new sinks
+	a.initializeNzbSinkWorker()
+
 	// Emit a config update event to the frontend for both desktop and web modes
 	if !a.isWebMode {
 		runtime.EventsEmit(a.ctx, "config-updated", configData)
@@ -465,15 +473,10 @@ func (a *App) validateServerConnections(configData *config.ConfigData) error {
 
 // validateIndividualServer tests a single server connection
 func (a *App) validateIndividualServer(ctx context.Context, server *config.ServerConfig, serverNum int) error {
-	// Convert to ServerData format for testing
-	serverData := ServerData{
-		Host:           server.Host,
-		Port:           server.Port,
-		Username:       server.Username,
-		Password:       server.Password,
-		SSL:            server.SSL,
-		MaxConnections: server.MaxConnections,
-	}
+	// Test with the full provider settings (TLS trust, client certificate,
+	// pin and proxy) so servers that need them validate like they connect.
+	provider := config.ServerConfigToProvider(*server)
+	provider.Connections = 1 // Use single connection for testing
 
 	// Create a channel to handle the validation result
 	resultChan := make(chan ValidationResult, 1)
@@ -487,8 +490,7 @@ func (a *App) validateIndividualServer(ctx context.Context, server *config.Serve
 			}
 		}()
 
-		result := a.TestProviderConnectivity(serverData)
-		resultChan <- result
+		resultChan <- testProvider(provider, server.Host, server.Port)
 	}()
 
 	// Wait for result or timeout
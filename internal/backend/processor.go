@@ -162,6 +162,29 @@ func (a *App) initializePostCheckWorker() {
 	slog.Info("Post check retry worker initialized")
 }
 
+// initializeNzbSinkWorker starts the background worker retrying failed NZB
+// sink deliveries
+func (a *App) initializeNzbSinkWorker() {
+	// Stop existing worker if running
+	if a.nzbSinkWorker != nil {
+		a.nzbSinkWorker.Stop()
+		a.nzbSinkWorker = nil
+	}
+
+	if a.config == nil || a.queue == nil {
+		return
+	}
+
+	sinksCfg := a.config.GetNzbSinksConfig()
+	if len(sinksCfg.Sinks) == 0 {
+		return
+	}
+
+	a.nzbSinkWorker = processor.NewNzbSinkRetryWorker(a.ctx, a.queue, sinksCfg)
+	a.nzbSinkWorker.Start()
+	slog.Info("NZB sink retry worker initialized", "sinks", len(sinksCfg.Sinks))
+}
+
 // CancelJob cancels a running job via processor
 func (a *App) CancelJob(id string) error {
 	defer a.recoverPanic("CancelJob")
@@ -10,6 +10,8 @@ import (
 
 	"github.com/javi11/postie/internal/apikey"
 	"github.com/javi11/postie/internal/database"
+	"github.com/javi11/postie/internal/filehash"
+	"github.com/javi11/postie/internal/nzbrebuild"
 	"github.com/javi11/postie/internal/queue"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -32,6 +34,8 @@ type QueueItem struct {
 	// Deferred verification progress (only set while pending_verification)
 	VerifiedArticles *int `json:"verifiedArticles,omitempty"`
 	TotalArticles    *int `json:"totalArticles,omitempty"`
+	// Per verify server propagation of completed items
+	Propagation []queue.ProviderPropagation `json:"propagation,omitempty"`
 }
 
 // QueueStats represents queue statistics
@@ -65,6 +69,16 @@ type PaginatedQueueResult struct {
 	HasPrev      bool        `json:"hasPrev"`
 }
 
+// LocalFileCheck is the result of re-hashing one local file of a completed
+// item and comparing it with the checksums recorded when it was posted.
+type LocalFileCheck struct {
+	Path     string `json:"path"`
+	Expected string `json:"expected"`         // recorded "crc32:... sha256:..."
+	Actual   string `json:"actual,omitempty"` // empty when the file could not be read
+	Match    bool   `json:"match"`
+	Error    string `json:"error,omitempty"`
+}
+
 func (a *App) initializeQueue() error {
 	if a.config == nil {
 		return fmt.Errorf("config not loaded")
@@ -228,6 +242,7 @@ func (a *App) GetQueueItems(params PaginationParams) (*PaginatedQueueResult, err
 			VerificationStatus: queueItem.VerificationStatus,
 			VerifiedArticles:   queueItem.VerifiedArticles,
 			TotalArticles:      queueItem.TotalArticles,
+			Propagation:        queueItem.Propagation,
 		}
 		items = append(items, item)
 	}
@@ -428,6 +443,69 @@ func (a *App) GetNZB(id string) (content string, fileName string, err error) {
 	return string(nzbContent), f, nil
 }
 
+// RebuildNZB regenerates the NZB of a completed item from its transfer manifests
+// and writes it back to the item's NZB path. It returns the written path.
+func (a *App) RebuildNZB(id string) (string, error) {
+	defer a.recoverPanic("RebuildNZB")
+
+	if a.queue == nil {
+		return "", fmt.Errorf("queue not initialized")
+	}
+	if a.config == nil {
+		return "", fmt.Errorf("config not loaded")
+	}
+
+	nzbPath, err := nzbrebuild.CompletedItem(a.ctx, a.queue.DB(), a.config, id, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to rebuild NZB: %w", err)
+	}
+
+	slog.Info("NZB rebuilt from transfer manifests", "id", id, "path", nzbPath)
+
+	if !a.isWebMode {
+		runtime.EventsEmit(a.ctx, "queue-updated")
+	} else if a.webEventEmitter != nil {
+		a.webEventEmitter("queue-updated", nil)
+	}
+	return nzbPath, nil
+}
+
+// VerifyLocalFiles re-hashes the local files of a completed item and compares
+// them with the checksums recorded when they were posted, without downloading
+// anything back.
+func (a *App) VerifyLocalFiles(id string) ([]LocalFileCheck, error) {
+	defer a.recoverPanic("VerifyLocalFiles")
+
+	if a.queue == nil {
+		return nil, fmt.Errorf("queue not initialized")
+	}
+
+	hashes, err := a.queue.GetCompletedItemFileHashes(a.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("no file hashes recorded for completed item: %s", id)
+	}
+
+	checks := make([]LocalFileCheck, 0, len(hashes))
+	for _, h := range hashes {
+		expected := fmt.Sprintf("crc32:%s sha256:%s", h.CRC32, h.SHA256)
+		check := LocalFileCheck{Path: h.Path, Expected: expected}
+
+		sums, err := filehash.HashFile(h.Path)
+		if err != nil {
+			check.Error = err.Error()
+		} else {
+			check.Actual = sums.String()
+			check.Match = sums.Size == h.Size && check.Actual == expected
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
 // SetQueueItemPriority updates the priority of a pending queue item by id and reorders the queue
 func (a *App) SetQueueItemPriority(id string, priority int) error {
 	if a.queue == nil {
@@ -0,0 +1,23 @@
+-- +goose Up
+-- transfer_propagation records, per transfer file and per verify server
+-- (backbone), how many of the file's articles that server had at the first
+-- verification check. The merged verify pool only says whether SOME server has
+-- an article, which hides a post that reached one backbone but not another.
+-- completed_item_id is kept here as well so the report outlives the
+-- transfer_files rows removed by post-verification cleanup.
+
+CREATE TABLE IF NOT EXISTS transfer_propagation (
+    transfer_id       TEXT NOT NULL,
+    file_id           TEXT NOT NULL,
+    provider          TEXT NOT NULL,
+    completed_item_id TEXT,
+    present_articles  INTEGER NOT NULL DEFAULT 0,
+    total_articles    INTEGER NOT NULL DEFAULT 0,
+    checked_at        TEXT NOT NULL,
+    PRIMARY KEY (transfer_id, file_id, provider)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_propagation_completed_item ON transfer_propagation(completed_item_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS transfer_propagation;
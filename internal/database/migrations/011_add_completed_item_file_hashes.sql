@@ -0,0 +1,10 @@
+-- +goose Up
+-- file_hashes holds the whole-file CRC32 and SHA-256 of every file posted for
+-- a completed item (a JSON array), computed while the articles were read for
+-- posting. They let a user check the local copy against what was uploaded
+-- without downloading it back.
+
+ALTER TABLE completed_items ADD COLUMN file_hashes TEXT DEFAULT NULL;
+
+-- +goose Down
+ALTER TABLE completed_items DROP COLUMN file_hashes;
@@ -0,0 +1,27 @@
+-- +goose Up
+-- nzb_sink_deliveries tracks, per completed item and per configured NZB sink,
+-- the delivery of the item's NZB to that sink (an HTTP endpoint, a download
+-- client or another directory). Failed deliveries are retried with the same
+-- backoff as the post-upload script, so the columns mirror the script_*
+-- columns of completed_items.
+
+CREATE TABLE IF NOT EXISTS nzb_sink_deliveries (
+    completed_item_id TEXT NOT NULL,
+    sink              TEXT NOT NULL,
+    nzb_path          TEXT NOT NULL,
+    status            TEXT NOT NULL,
+    retry_count       INTEGER NOT NULL DEFAULT 0,
+    last_error        TEXT DEFAULT NULL,
+    next_retry_at     TEXT DEFAULT NULL,
+    first_failure_at  TEXT DEFAULT NULL,
+    updated_at        TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%f', 'now')),
+    PRIMARY KEY (completed_item_id, sink)
+);
+
+CREATE INDEX IF NOT EXISTS idx_nzb_sink_deliveries_retry
+ON nzb_sink_deliveries (status, next_retry_at)
+WHERE status = 'pending_retry';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_nzb_sink_deliveries_retry;
+DROP TABLE IF EXISTS nzb_sink_deliveries;
@@ -0,0 +1,124 @@
+// Package filehash computes the whole-file CRC32 and SHA-256 recorded for
+// every posted file, in the NZB filehash attribute and on the completed item.
+package filehash
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+)
+
+// Sums are the checksums of a whole file.
+type Sums struct {
+	Size   int64
+	CRC32  uint32
+	SHA256 [sha256.Size]byte
+}
+
+// String returns the sums as written to the NZB filehash attribute:
+// "crc32:<8 hex digits> sha256:<64 hex digits>".
+func (s Sums) String() string {
+	return fmt.Sprintf("crc32:%08x sha256:%x", s.CRC32, s.SHA256)
+}
+
+// CRC32Hex returns the CRC32 as 8 lowercase hex digits.
+func (s Sums) CRC32Hex() string { return fmt.Sprintf("%08x", s.CRC32) }
+
+// SHA256Hex returns the SHA-256 as 64 lowercase hex digits.
+func (s Sums) SHA256Hex() string { return hex.EncodeToString(s.SHA256[:]) }
+
+// Parse parses a filehash attribute written by Sums.String. The size is not
+// part of the attribute and is left zero.
+func Parse(attr string) (Sums, error) {
+	var s Sums
+	var haveCRC, haveSHA bool
+	for _, field := range strings.Fields(attr) {
+		name, value, _ := strings.Cut(field, ":")
+		switch name {
+		case "crc32":
+			if _, err := fmt.Sscanf(value, "%08x", &s.CRC32); err != nil || len(value) != 8 {
+				return Sums{}, fmt.Errorf("invalid crc32 %q", value)
+			}
+			haveCRC = true
+		case "sha256":
+			b, err := hex.DecodeString(value)
+			if err != nil || len(b) != sha256.Size {
+				return Sums{}, fmt.Errorf("invalid sha256 %q", value)
+			}
+			copy(s.SHA256[:], b)
+			haveSHA = true
+		}
+	}
+	if !haveCRC || !haveSHA {
+		return Sums{}, fmt.Errorf("filehash %q has no crc32 and sha256", attr)
+	}
+	return s, nil
+}
+
+// Hasher hashes a file from its segments as they are read. Segments must
+// arrive in file order; once one does not, the Hasher stops and Sum reports
+// that the sums are unavailable.
+type Hasher struct {
+	size   int64
+	next   int64
+	broken bool
+	crc    hash.Hash32
+	sha    hash.Hash
+}
+
+// NewHasher returns a Hasher for a file of size bytes.
+func NewHasher(size int64) *Hasher {
+	return &Hasher{size: size, crc: crc32.NewIEEE(), sha: sha256.New()}
+}
+
+// Write hashes the segment at offset. A nil Hasher ignores it.
+func (h *Hasher) Write(offset int64, p []byte) {
+	if h == nil || h.broken {
+		return
+	}
+	if offset != h.next {
+		h.broken = true
+		return
+	}
+	_, _ = h.crc.Write(p)
+	_, _ = h.sha.Write(p)
+	h.next += int64(len(p))
+}
+
+// Sum returns the sums once every byte of the file was written in order.
+func (h *Hasher) Sum() (Sums, bool) {
+	if h == nil || h.broken || h.next != h.size {
+		return Sums{}, false
+	}
+	s := Sums{Size: h.size, CRC32: h.crc.Sum32()}
+	copy(s.SHA256[:], h.sha.Sum(nil))
+	return s, true
+}
+
+// HashReader hashes everything read from r.
+func HashReader(r io.Reader) (Sums, error) {
+	crc := crc32.NewIEEE()
+	sha := sha256.New()
+	n, err := io.Copy(io.MultiWriter(crc, sha), r)
+	if err != nil {
+		return Sums{}, err
+	}
+	s := Sums{Size: n, CRC32: crc.Sum32()}
+	copy(s.SHA256[:], sha.Sum(nil))
+	return s, nil
+}
+
+// HashFile hashes the file at path.
+func HashFile(path string) (Sums, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Sums{}, err
+	}
+	defer func() { _ = f.Close() }()
+	return HashReader(f)
+}
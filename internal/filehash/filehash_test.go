@@ -0,0 +1,78 @@
+package filehash
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHasher_MatchesHashReader(t *testing.T) {
+	data := bytes.Repeat([]byte("postie"), 1000)
+	want, err := HashReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("HashReader: %v", err)
+	}
+
+	h := NewHasher(int64(len(data)))
+	for off := 0; off < len(data); off += 700 {
+		h.Write(int64(off), data[off:min(off+700, len(data))])
+	}
+	got, ok := h.Sum()
+	if !ok {
+		t.Fatal("Sum reported incomplete after every segment was written in order")
+	}
+	if got != want {
+		t.Errorf("Sum = %s, want %s", got, want)
+	}
+}
+
+func TestHasher_OutOfOrderOrIncomplete(t *testing.T) {
+	h := NewHasher(10)
+	h.Write(5, make([]byte, 5))
+	h.Write(0, make([]byte, 5))
+	if _, ok := h.Sum(); ok {
+		t.Error("Sum reported sums for segments written out of order")
+	}
+
+	h = NewHasher(10)
+	h.Write(0, make([]byte, 5))
+	if _, ok := h.Sum(); ok {
+		t.Error("Sum reported sums for a partly written file")
+	}
+
+	var nilHasher *Hasher
+	nilHasher.Write(0, []byte("x"))
+	if _, ok := nilHasher.Sum(); ok {
+		t.Error("nil Hasher reported sums")
+	}
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.bin")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sums, err := HashFile(path)
+	if err != nil {
+		t.Fatalf("HashFile: %v", err)
+	}
+	if sums.Size != 5 || sums.CRC32Hex() != "3610a686" {
+		t.Errorf("HashFile = size %d crc32 %s, want 5 and 3610a686", sums.Size, sums.CRC32Hex())
+	}
+
+	parsed, err := Parse(sums.String())
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", sums.String(), err)
+	}
+	parsed.Size = sums.Size
+	if parsed != sums {
+		t.Errorf("Parse = %+v, want %+v", parsed, sums)
+	}
+
+	for _, bad := range []string{"", "crc32:zz sha256:00", "crc32:3610a686", "sha256:abc"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) = nil error, want an error", bad)
+		}
+	}
+}
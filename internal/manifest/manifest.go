@@ -43,6 +43,9 @@ const (
 	RoleOriginal      FileRole = "original"
 	RoleGeneratedPar2 FileRole = "generated_par2"
 	RoleExistingPar2  FileRole = "existing_par2"
+	// RolePackedVolume is a volume written by the packing stage; it stands in
+	// for the job's original files and is removed once verified.
+	RolePackedVolume FileRole = "packed_volume"
 )
 
 // header is the first line of every manifest.
@@ -71,6 +74,7 @@ type ArticleRecord struct {
 	PartNumber      int               `json:"part"`
 	TotalParts      int               `json:"parts"`
 	FileSize        int64             `json:"fsize"`
+	FileNumber      int               `json:"fnum,omitempty"`
 }
 
 // RecordFromArticle builds an ArticleRecord from a posted article, capturing
@@ -94,6 +98,31 @@ func RecordFromArticle(idx int, sourcePath string, role FileRole, a *article.Art
 		PartNumber:      a.PartNumber,
 		TotalParts:      a.TotalParts,
 		FileSize:        a.FileSize,
+		FileNumber:      a.FileNumber,
+	}
+}
+
+// ArticleFromRecord reconstructs an article from a record so it can be
+// re-posted or added to an NZB. The original file name is the base name of the
+// source path, matching how the poster names files in the NZB.
+func ArticleFromRecord(rec ArticleRecord) *article.Article {
+	return &article.Article{
+		MessageID:       rec.MessageID,
+		Subject:         rec.Subject,
+		OriginalSubject: rec.OriginalSubject,
+		From:            rec.From,
+		Groups:          rec.Groups,
+		PartNumber:      rec.PartNumber,
+		TotalParts:      rec.TotalParts,
+		FileName:        rec.FileName,
+		FileNumber:      rec.FileNumber,
+		OriginalName:    filepath.Base(rec.SourcePath),
+		Date:            rec.Date,
+		Offset:          rec.Offset,
+		Size:            rec.BodySize,
+		FileSize:        rec.FileSize,
+		CustomHeaders:   rec.CustomHeaders,
+		XNxgHeader:      rec.XNxgHeader,
 	}
 }
 
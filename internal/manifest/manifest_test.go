@@ -187,6 +187,24 @@ func TestRecordFromArticle(t *testing.T) {
 	}
 }
 
+func TestArticleFromRecord(t *testing.T) {
+	rec := sampleRecords(3)[1]
+	rec.FileNumber = 4
+
+	a := ArticleFromRecord(rec)
+
+	if a.MessageID != rec.MessageID || a.Offset != rec.Offset || a.Size != rec.BodySize ||
+		a.PartNumber != rec.PartNumber || a.TotalParts != rec.TotalParts || a.FileNumber != rec.FileNumber {
+		t.Errorf("record fields not copied: %+v", a)
+	}
+	if a.OriginalName != "ep1.mkv" {
+		t.Errorf("OriginalName = %q, want %q", a.OriginalName, "ep1.mkv")
+	}
+	if got := RecordFromArticle(rec.Index, rec.SourcePath, rec.FileRole, a); got.FileNumber != rec.FileNumber || got.MessageID != rec.MessageID {
+		t.Errorf("round trip lost fields: %+v", got)
+	}
+}
+
 func TestFilePath(t *testing.T) {
 	got := FilePath("/base", "tid-123", "fid-9")
 	want := filepath.Join("/base", "tid-123", "fid-9.jsonl.zst")
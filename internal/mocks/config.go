@@ -99,6 +99,48 @@ func (mr *MockConfigMockRecorder) GetNzbCompressionConfig() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNzbCompressionConfig", reflect.TypeOf((*MockConfig)(nil).GetNzbCompressionConfig))
 }
 
+// GetNzbMeta mocks base method.
+func (m *MockConfig) GetNzbMeta() config.NzbMeta {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNzbMeta")
+	ret0, _ := ret[0].(config.NzbMeta)
+	return ret0
+}
+
+// GetNzbMeta indicates an expected call of GetNzbMeta.
+func (mr *MockConfigMockRecorder) GetNzbMeta() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNzbMeta", reflect.TypeOf((*MockConfig)(nil).GetNzbMeta))
+}
+
+// GetNzbOutputConfig mocks base method.
+func (m *MockConfig) GetNzbOutputConfig() config.NzbOutputConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNzbOutputConfig")
+	ret0, _ := ret[0].(config.NzbOutputConfig)
+	return ret0
+}
+
+// GetNzbOutputConfig indicates an expected call of GetNzbOutputConfig.
+func (mr *MockConfigMockRecorder) GetNzbOutputConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNzbOutputConfig", reflect.TypeOf((*MockConfig)(nil).GetNzbOutputConfig))
+}
+
+// GetNzbSinksConfig mocks base method.
+func (m *MockConfig) GetNzbSinksConfig() config.NzbSinksConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNzbSinksConfig")
+	ret0, _ := ret[0].(config.NzbSinksConfig)
+	return ret0
+}
+
+// GetNzbSinksConfig indicates an expected call of GetNzbSinksConfig.
+func (mr *MockConfigMockRecorder) GetNzbSinksConfig() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNzbSinksConfig", reflect.TypeOf((*MockConfig)(nil).GetNzbSinksConfig))
+}
+
 // GetPar2Config mocks base method.
 func (m *MockConfig) GetPar2Config(ctx context.Context) (*config.Par2Config, error) {
 	m.ctrl.T.Helper()
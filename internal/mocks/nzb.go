@@ -13,6 +13,8 @@ import (
 	reflect "reflect"
 
 	article "github.com/javi11/postie/internal/article"
+	config "github.com/javi11/postie/internal/config"
+	nzb "github.com/javi11/postie/internal/nzb"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -64,6 +66,18 @@ func (mr *MockNZBGeneratorMockRecorder) AddFileHash(filename, hash any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFileHash", reflect.TypeOf((*MockNZBGenerator)(nil).AddFileHash), filename, hash)
 }
 
+// Close mocks base method.
+func (m *MockNZBGenerator) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockNZBGeneratorMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockNZBGenerator)(nil).Close))
+}
+
 // Generate mocks base method.
 func (m *MockNZBGenerator) Generate(outputPath string) (string, error) {
 	m.ctrl.T.Helper()
@@ -78,3 +92,39 @@ func (mr *MockNZBGeneratorMockRecorder) Generate(outputPath any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*MockNZBGenerator)(nil).Generate), outputPath)
 }
+
+// SetHeadMeta mocks base method.
+func (m *MockNZBGenerator) SetHeadMeta(meta config.NzbMeta) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetHeadMeta", meta)
+}
+
+// SetHeadMeta indicates an expected call of SetHeadMeta.
+func (mr *MockNZBGeneratorMockRecorder) SetHeadMeta(meta any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHeadMeta", reflect.TypeOf((*MockNZBGenerator)(nil).SetHeadMeta), meta)
+}
+
+// SetMeta mocks base method.
+func (m *MockNZBGenerator) SetMeta(key, value string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetMeta", key, value)
+}
+
+// SetMeta indicates an expected call of SetMeta.
+func (mr *MockNZBGeneratorMockRecorder) SetMeta(key, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMeta", reflect.TypeOf((*MockNZBGenerator)(nil).SetMeta), key, value)
+}
+
+// SetOutputLayout mocks base method.
+func (m *MockNZBGenerator) SetOutputLayout(layout nzb.OutputLayout) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetOutputLayout", layout)
+}
+
+// SetOutputLayout indicates an expected call of SetOutputLayout.
+func (mr *MockNZBGeneratorMockRecorder) SetOutputLayout(layout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOutputLayout", reflect.TypeOf((*MockNZBGenerator)(nil).SetOutputLayout), layout)
+}
@@ -2,13 +2,19 @@ package nzb
 
 import (
 	"archive/zip"
+	"bufio"
 	"compress/flate"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,323 +23,495 @@ import (
 	"github.com/javi11/nzbparser"
 	"github.com/javi11/postie/internal/article"
 	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/manifest"
+	"github.com/javi11/postie/internal/nzbpath"
 	"github.com/klauspost/compress/zstd"
 )
 
+// ErrNzbExists is returned by Generate when the NZB already exists and the
+// skip collision policy keeps it. The new NZB is not written.
+var ErrNzbExists = errors.New("NZB already exists")
+
+// collisionMu serializes resolving and writing NZBs under the suffix and skip
+// collision policies, so concurrent jobs of the same name cannot pick the same
+// free path.
+var collisionMu sync.Mutex
+
 // NZBGenerator defines the interface for generating NZB files
 type NZBGenerator interface {
 	// AddArticle adds an article to the generator
 	AddArticle(article *article.Article)
 	// AddFileHash adds a hash for a file
 	AddFileHash(filename string, hash string)
+	// SetMeta sets a <head> meta entry of the NZB, such as its password
+	SetMeta(key string, value string)
+	// SetHeadMeta sets the title, password, category and tag meta entries
+	SetHeadMeta(meta config.NzbMeta)
+	// SetOutputLayout sets where Generate writes the NZB
+	SetOutputLayout(layout OutputLayout)
 	// Generate creates an NZB file
 	Generate(outputPath string) (string, error)
+	// Close releases the temporary spool holding the segments
+	Close()
 }
 
-// Generator creates NZB files
+// Generator creates NZB files. Only per-file details are kept in memory:
+// segments go to a spool that spills sorted runs to a temporary file, and
+// Generate merges them while streaming the XML to the output.
 type Generator struct {
-	articles                  map[string][]*article.Article // filename -> articles
-	articleIdx                map[string]map[string]int     // filename -> messageID -> index (for O(1) lookup)
-	filesHash                 map[string]string             // filename -> checksums
-	segmentSize               uint64                        // size of each segment in bytes
-	compressionConfig         config.NzbCompressionConfig   // compression configuration
-	maintainOriginalExtension bool                          // whether to maintain original file extension
-	mx                        sync.RWMutex                  // mutex for concurrent access
+	files                     map[string]*nzbFileEntry    // filename -> file details
+	fileList                  []*nzbFileEntry             // files by spool index
+	segments                  spool                       // segments of every file
+	seq                       uint64                      // order of the last added article
+	chunkSize                 uint64                      // largest article size
+	filesHash                 map[string]string           // filename -> checksums
+	meta                      map[string]string           // head meta type -> value
+	segmentSize               uint64                      // size of each segment in bytes
+	compressionConfig         config.NzbCompressionConfig // compression configuration
+	maintainOriginalExtension bool                        // whether to maintain original file extension
+	layout                    OutputLayout                // path template and collision policy
+	mx                        sync.Mutex                  // mutex for concurrent access
+}
+
+// nzbFileEntry holds the details of a file taken from its articles: the
+// subject, poster and number of its first part and the groups it was posted
+// to.
+type nzbFileEntry struct {
+	index     uint32
+	name      string
+	firstPart int
+	subject   string
+	poster    string
+	number    int
+	groups    map[string]groupRank
+}
+
+// groupRank is where a group was first used: the lowest part posted to it and
+// its position among that article's groups.
+type groupRank struct {
+	part int
+	pos  int
+}
+
+// OutputLayout places the NZB written by Generate below Dir with a path
+// template instead of at the path given to Generate, and says what happens
+// when an NZB already exists at the final path.
+type OutputLayout struct {
+	Dir string
+	// Template is nil to keep the path given to Generate.
+	Template *nzbpath.Template
+	// Vars are the template values. Generate fills in an empty Name from the
+	// path it is given, Category from the category meta and Date with the
+	// current time.
+	Vars        nzbpath.Vars
+	OnCollision config.NzbCollisionPolicy
 }
 
 // NewGenerator creates a new NZB generator
 func NewGenerator(segmentSize uint64, compressionConfig config.NzbCompressionConfig, maintainOriginalExtension bool) NZBGenerator {
 	return &Generator{
-		articles:                  make(map[string][]*article.Article),
-		articleIdx:                make(map[string]map[string]int),
+		files:                     make(map[string]*nzbFileEntry),
 		filesHash:                 make(map[string]string),
+		meta:                      make(map[string]string),
 		segmentSize:               segmentSize,
 		compressionConfig:         compressionConfig,
 		maintainOriginalExtension: maintainOriginalExtension,
 	}
 }
 
-// AddArticle adds an article to the generator
+// AddArticle adds an article to the generator. An article for a part that
+// was already added replaces it.
 func (g *Generator) AddArticle(art *article.Article) {
 	g.mx.Lock()
 	defer g.mx.Unlock()
-	filename := art.OriginalName
-
-	// O(1) lookup for existing article by message ID
-	if idx, ok := g.articleIdx[filename]; ok {
-		if i, exists := idx[art.MessageID]; exists {
-			// Replace the existing article
-			g.articles[filename][i] = art
-			return
+
+	file, ok := g.files[art.OriginalName]
+	if !ok {
+		file = &nzbFileEntry{
+			index:     uint32(len(g.fileList)),
+			name:      art.OriginalName,
+			firstPart: art.PartNumber,
+			groups:    make(map[string]groupRank, len(art.Groups)),
 		}
+		g.files[art.OriginalName] = file
+		g.fileList = append(g.fileList, file)
 	}
-
-	// Initialize index map for this filename if needed
-	if g.articleIdx[filename] == nil {
-		g.articleIdx[filename] = make(map[string]int)
+	if art.PartNumber <= file.firstPart {
+		file.firstPart = art.PartNumber
+		file.subject = art.OriginalSubject
+		file.poster = art.From
+		file.number = art.FileNumber
+	}
+	for pos, group := range art.Groups {
+		rank := groupRank{part: art.PartNumber, pos: pos}
+		if cur, seen := file.groups[group]; !seen || rank.part < cur.part || (rank.part == cur.part && rank.pos < cur.pos) {
+			file.groups[group] = rank
+		}
+	}
+	g.chunkSize = max(g.chunkSize, art.Size)
+
+	g.seq++
+	err := g.segments.add(segmentRecord{
+		file: file.index,
+		part: uint64(art.PartNumber),
+		seq:  g.seq,
+		size: art.Size,
+		id:   art.MessageID,
+	})
+	if err != nil {
+		// The spill failed: keep the segments in memory instead.
+		slog.Error("Error spooling NZB segments, keeping them in memory", "error", err)
 	}
+}
 
-	// Add to index and append to articles
-	g.articleIdx[filename][art.MessageID] = len(g.articles[filename])
-	g.articles[filename] = append(g.articles[filename], art)
+// fileGroups returns every group a file's articles were posted to, in order
+// of first use. NZB lists groups per file, so with the each_article group
+// policy the file lists all of them; the per-article group is kept in the
+// manifest.
+func fileGroups(file *nzbFileEntry) []string {
+	groups := slices.Collect(maps.Keys(file.groups))
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := file.groups[groups[i]], file.groups[groups[j]]
+		if a.part != b.part {
+			return a.part < b.part
+		}
+		return a.pos < b.pos
+	})
+	return groups
 }
 
-// Generate creates an NZB file for all files
+// Generate creates an NZB file for all files. With the skip collision policy
+// it returns ErrNzbExists when the NZB already exists.
 func (g *Generator) Generate(outputPath string) (string, error) {
-	g.mx.RLock()
-	defer g.mx.RUnlock()
+	g.mx.Lock()
+	defer g.mx.Unlock()
 
-	if len(g.articles) == 0 {
+	if len(g.fileList) == 0 {
 		return "", fmt.Errorf("no articles found")
 	}
 
 	// Generate the final NZB filename based on maintainOriginalExtension setting
+	// and the path template
 	finalNzbPath := g.generateFinalNzbPath(outputPath)
 
-	// Create NZB file
-	nzbFile := &nzbparser.Nzb{
-		Meta: map[string]string{
-			"date":       time.Now().Format(time.RFC3339),
-			"chunk_size": fmt.Sprintf("%d", g.segmentSize),
-		},
+	if g.layout.OnCollision == config.NzbCollisionSuffix || g.layout.OnCollision == config.NzbCollisionSkip {
+		collisionMu.Lock()
+		defer collisionMu.Unlock()
+	}
+	finalNzbPath, skip := g.resolveCollision(finalNzbPath)
+	if skip {
+		return "", fmt.Errorf("%w, keeping it: %s", ErrNzbExists, finalNzbPath)
 	}
 
-	// Add all files to NZB
-	fileNumber := 0
-	for filename, articles := range g.articles {
-		if len(articles) == 0 {
-			continue
+	// Create output directory if it doesn't exist
+	// Use filepath.Dir to get the parent directory, not the full path which includes the filename
+	outputDir := filepath.Dir(finalNzbPath)
+	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create output directory: %w, %s", err, outputDir)
 		}
+	} else if err != nil {
+		return "", fmt.Errorf("failed to check output directory: %w, %s", err, outputDir)
+	}
 
-		// Sort articles by part number
-		sort.Slice(articles, func(i, j int) bool {
-			return articles[i].PartNumber < articles[j].PartNumber
-		})
+	return g.createNzb(finalNzbPath, g.writeNzb)
+}
 
-		// Calculate file size from all segments
-		var fileSize int64
-		for _, a := range articles {
-			fileSize += int64(a.Size)
-		}
+// writeNzb writes the NZB XML, in the layout nzbparser.Write produces, one
+// segment at a time.
+func (g *Generator) writeNzb(w io.Writer) error {
+	bw := bufio.NewWriterSize(w, 64<<10)
+
+	// chunk_size is the largest article size: the configured size, or more
+	// when article sizes vary
+	meta := maps.Clone(g.meta)
+	meta["date"] = time.Now().Format(time.RFC3339)
+	meta["chunk_size"] = fmt.Sprintf("%d", max(g.segmentSize, g.chunkSize))
+
+	bw.WriteString(nzbparser.Header)
+	bw.WriteString("<nzb xmlns=\"" + nzbparser.Xmlns + "\">\n  <head>\n")
+	for _, key := range slices.Sorted(maps.Keys(meta)) {
+		bw.WriteString("    <meta type=\"")
+		escape(bw, key)
+		bw.WriteString("\">")
+		escape(bw, meta[key])
+		bw.WriteString("</meta>\n")
+	}
+	bw.WriteString("  </head>\n")
 
-		// Create file entry
-		file := nzbparser.NzbFile{
-			Subject:       articles[0].OriginalSubject,
-			Groups:        articles[0].Groups,
-			Poster:        articles[0].From,
-			Date:          int(time.Now().Unix()),
-			Bytes:         fileSize,
-			Number:        articles[0].FileNumber,
-			TotalSegments: len(articles),
-			Filename:      articles[0].OriginalName,
-		}
+	// Sort files
+	files := slices.Clone(g.fileList)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].number < files[j].number
+	})
 
-		// Add checksum if available
-		if hash, ok := g.filesHash[filename]; ok {
-			file.FileHash = hash
+	date := strconv.FormatInt(time.Now().Unix(), 10)
+	for _, file := range files {
+		// The file size comes before its segments, so they are read twice
+		var fileSize uint64
+		if err := g.segments.each(file.index, func(s segmentRecord) error {
+			fileSize += s.size
+			return nil
+		}); err != nil {
+			return err
 		}
 
-		// Add segments
-		for i, a := range articles {
-			// Use configured segment size for all segments except the last one
-			segmentSize := g.segmentSize
-			if i == len(articles)-1 {
-				segmentSize = a.Size
-			}
-
-			segment := nzbparser.NzbSegment{
-				Bytes:  int(segmentSize),
-				Number: a.PartNumber,
-				ID:     a.MessageID,
-			}
-			file.Segments = append(file.Segments, segment)
+		bw.WriteString("  <file poster=\"")
+		escape(bw, file.poster)
+		bw.WriteString("\" date=\"" + date + "\" subject=\"")
+		escape(bw, file.subject)
+		bw.WriteString("\" bytes=\"" + strconv.FormatUint(fileSize, 10) + "\" filehash=\"")
+		escape(bw, g.filesHash[file.name])
+		bw.WriteString("\">\n    <groups>\n")
+		for _, group := range fileGroups(file) {
+			bw.WriteString("      <group>")
+			escape(bw, group)
+			bw.WriteString("</group>\n")
 		}
-
-		// Add file to NZB
-		nzbFile.Files = append(nzbFile.Files, file)
-		fileNumber++
+		bw.WriteString("    </groups>\n    <segments>\n")
+
+		// Each segment lists its own size: sizes vary between files and
+		// articles, and the last segment holds the remainder.
+		if err := g.segments.each(file.index, func(s segmentRecord) error {
+			bw.WriteString("      <segment bytes=\"" + strconv.FormatUint(s.size, 10) + "\" number=\"" + strconv.FormatUint(s.part, 10) + "\">")
+			escape(bw, s.id)
+			_, err := bw.WriteString("</segment>\n")
+			return err
+		}); err != nil {
+			return err
+		}
+		bw.WriteString("    </segments>\n  </file>\n")
 	}
+	bw.WriteString("</nzb>")
 
-	// Sort files
-	sort.Slice(nzbFile.Files, func(i, j int) bool {
-		return nzbFile.Files[i].Number < nzbFile.Files[j].Number
-	})
+	return bw.Flush()
+}
 
-	// Create output directory if it doesn't exist
-	// Use filepath.Dir to get the parent directory, not the full path which includes the filename
-	outputDir := filepath.Dir(outputPath)
-	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(outputDir, 0755); err != nil {
-			return "", fmt.Errorf("failed to create output directory: %w, %s", err, outputDir)
-		}
-	} else if err != nil {
-		return "", fmt.Errorf("failed to check output directory: %w, %s", err, outputDir)
+// escape writes s escaped for XML text and attribute values.
+func escape(w *bufio.Writer, s string) {
+	_ = xml.EscapeText(w, []byte(s))
+}
+
+// createNzb writes the NZB for finalNzbPath with write, through the
+// configured compression, and returns the path of the file written. The file
+// is written to a temp file of its own next to that path, so jobs writing the
+// same NZB don't share it, and renamed into place once complete.
+func (g *Generator) createNzb(finalNzbPath string, write func(io.Writer) error) (string, error) {
+	path, compression := g.compressedPath(finalNzbPath)
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("error creating NZB file: %w", err)
+	}
+	tmpPath := f.Name()
+	if err := f.Chmod(0644); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+		return "", fmt.Errorf("error creating NZB file: %w", err)
 	}
 
-	// Write NZB file
-	data, err := nzbparser.Write(nzbFile)
+	err = g.writeCompressed(f, compression, filepath.Base(finalNzbPath), write)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("error writing NZB file: %w", closeErr)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
 	if err != nil {
+		_ = os.Remove(tmpPath)
+		if compression != "" {
+			return "", fmt.Errorf("error compressing NZB file with %s: %w", compression, err)
+		}
 		return "", fmt.Errorf("error writing NZB file: %w", err)
 	}
 
-	// Apply compression if enabled and write to final path
+	return path, nil
+}
+
+// compressedPath returns the path of the file written for finalNzbPath with
+// the configured compression, and the compression used.
+func (g *Generator) compressedPath(finalNzbPath string) (string, string) {
 	if g.compressionConfig.Enabled {
 		switch g.compressionConfig.Type {
 		case config.CompressionTypeZstd:
-			compressionPath := finalNzbPath + ".zst"
-			if err := g.compressWithZstd(data, compressionPath); err != nil {
-				return "", fmt.Errorf("error compressing NZB file with zstd: %w", err)
-			}
-			return compressionPath, nil
+			return finalNzbPath + ".zst", "zstd"
 		case config.CompressionTypeBrotli:
-			compressionPath := finalNzbPath + ".br"
-			if err := g.compressWithBrotli(data, compressionPath); err != nil {
-				return "", fmt.Errorf("error compressing NZB file with brotli: %w", err)
-			}
-			return compressionPath, nil
+			return finalNzbPath + ".br", "brotli"
 		case config.CompressionTypeZip:
-			compressionPath := finalNzbPath + ".zip"
-			if err := g.compressWithZip(data, compressionPath, finalNzbPath); err != nil {
-				return "", fmt.Errorf("error compressing NZB file with zip: %w", err)
-			}
-			return compressionPath, nil
-		default:
-			// No compression or unknown type, write the file as is
-			if err := os.WriteFile(finalNzbPath, data, 0644); err != nil {
-				return "", fmt.Errorf("error writing NZB file: %w", err)
-			}
-			return finalNzbPath, nil
-		}
-	} else {
-		// No compression, write the file as is
-		if err := os.WriteFile(finalNzbPath, data, 0644); err != nil {
-			return "", fmt.Errorf("error writing NZB file: %w", err)
+			return finalNzbPath + ".zip", "zip"
 		}
-		return finalNzbPath, nil
 	}
+	return finalNzbPath, ""
 }
 
-// compressWithZstd compresses data with zstd and writes it to the given path
-func (g *Generator) compressWithZstd(data []byte, outputPath string) error {
-	// Create the file
-	f, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("error creating compressed file: %w", err)
+// resolveCollision applies the collision policy when a file already exists
+// for finalNzbPath. It returns the NZB path to write, or the existing file
+// and true when the new NZB is to be skipped.
+func (g *Generator) resolveCollision(finalNzbPath string) (string, bool) {
+	path, _ := g.compressedPath(finalNzbPath)
+	if !fileExists(path) {
+		return finalNzbPath, false
 	}
-	defer func() {
-		if err := f.Close(); err != nil {
-			slog.Error("error closing file", "error", err)
-		}
-	}()
 
-	// Create zstd encoder with the configured level
-	level := zstd.EncoderLevel(g.compressionConfig.Level)
-	enc, err := zstd.NewWriter(f, zstd.WithEncoderLevel(level))
-	if err != nil {
-		return fmt.Errorf("error creating zstd encoder: %w", err)
-	}
-	defer func() {
-		if err := enc.Close(); err != nil {
-			slog.Error("error closing zstd encoder", "error", err)
+	switch g.layout.OnCollision {
+	case config.NzbCollisionSkip:
+		return path, true
+	case config.NzbCollisionSuffix:
+		// name.nzb becomes name (1).nzb, name (2).nzb, ...
+		ext := filepath.Ext(finalNzbPath)
+		base := strings.TrimSuffix(finalNzbPath, ext)
+		for i := 1; ; i++ {
+			candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+			if path, _ := g.compressedPath(candidate); !fileExists(path) {
+				return candidate, false
+			}
 		}
-	}()
-
-	// Write compressed data
-	if _, err := enc.Write(data); err != nil {
-		return fmt.Errorf("error writing compressed data: %w", err)
+	default:
+		slog.Warn("Overwriting existing NZB", "path", path)
+		return finalNzbPath, false
 	}
+}
 
-	return nil
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
 
-// compressWithBrotli compresses data with brotli and writes it to the given path
-func (g *Generator) compressWithBrotli(data []byte, outputPath string) error {
-	// Create the file
-	f, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("error creating compressed file: %w", err)
-	}
-	defer func() {
-		if err := f.Close(); err != nil {
-			slog.Error("error closing file", "error", err)
+// writeCompressed calls write with a writer that compresses into f. The zip
+// entry is named nzbFilename.
+func (g *Generator) writeCompressed(f io.Writer, compression, nzbFilename string, write func(io.Writer) error) error {
+	switch compression {
+	case "zstd":
+		// Create zstd encoder with the configured level
+		level := zstd.EncoderLevel(g.compressionConfig.Level)
+		enc, err := zstd.NewWriter(f, zstd.WithEncoderLevel(level))
+		if err != nil {
+			return fmt.Errorf("error creating zstd encoder: %w", err)
 		}
-	}()
-
-	// Create brotli writer with the configured level
-	w := brotli.NewWriterLevel(f, g.compressionConfig.Level)
-	defer func() {
-		if err := w.Close(); err != nil {
-			slog.Error("error closing brotli writer", "error", err)
+		if err := write(enc); err != nil {
+			_ = enc.Close()
+			return err
 		}
-	}()
+		return enc.Close()
+	case "brotli":
+		// Create brotli writer with the configured level
+		w := brotli.NewWriterLevel(f, g.compressionConfig.Level)
+		if err := write(w); err != nil {
+			_ = w.Close()
+			return err
+		}
+		return w.Close()
+	case "zip":
+		w := zip.NewWriter(f)
+
+		// Set compression level by manipulating the extra field
+		// This is a workaround since Go's zip package doesn't expose compression level directly
+		w.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(out, g.compressionConfig.Level)
+		})
 
-	// Write compressed data
-	if _, err := w.Write(data); err != nil {
-		return fmt.Errorf("error writing compressed data: %w", err)
+		zipFile, err := w.CreateHeader(&zip.FileHeader{
+			Name:   nzbFilename,
+			Method: zip.Deflate,
+		})
+		if err != nil {
+			_ = w.Close()
+			return fmt.Errorf("error creating zip entry: %w", err)
+		}
+		if err := write(zipFile); err != nil {
+			_ = w.Close()
+			return err
+		}
+		return w.Close()
+	default:
+		// No compression or unknown type, write the file as is
+		return write(f)
 	}
-
-	return nil
 }
 
-// compressWithZip compresses data with zip and writes it to the given path
-func (g *Generator) compressWithZip(data []byte, outputPath string, originalNzbPath string) error {
-	// Create the file
-	f, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("error creating compressed file: %w", err)
-	}
-	defer func() {
-		if err := f.Close(); err != nil {
-			slog.Error("error closing file", "error", err)
-		}
-	}()
+// Close removes the spool file of the generator.
+func (g *Generator) Close() {
+	g.mx.Lock()
+	defer g.mx.Unlock()
 
-	// Create zip writer
-	w := zip.NewWriter(f)
-	defer func() {
-		if err := w.Close(); err != nil {
-			slog.Error("error closing zip writer", "error", err)
-		}
-	}()
+	if err := g.segments.close(); err != nil {
+		slog.Warn("Error removing NZB spool", "error", err)
+	}
+}
 
-	// Get the base filename for the entry in the zip
-	nzbFilename := filepath.Base(originalNzbPath)
+// AddFileHash adds a hash for a file
+func (g *Generator) AddFileHash(filename string, hash string) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
 
-	// Create a file header with compression settings
-	header := &zip.FileHeader{
-		Name:   nzbFilename,
-		Method: zip.Deflate,
-	}
+	g.filesHash[filename] = hash
+}
 
-	// Set compression level by manipulating the extra field
-	// This is a workaround since Go's zip package doesn't expose compression level directly
-	w.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
-		return flate.NewWriter(out, g.compressionConfig.Level)
-	})
+// SetMeta sets a <head> meta entry of the NZB
+func (g *Generator) SetMeta(key string, value string) {
+	g.mx.Lock()
+	defer g.mx.Unlock()
 
-	zipFile, err := w.CreateHeader(header)
-	if err != nil {
-		return fmt.Errorf("error creating zip entry: %w", err)
-	}
+	g.meta[key] = value
+}
 
-	// Write the NZB data to the zip file
-	if _, err := zipFile.Write(data); err != nil {
-		return fmt.Errorf("error writing data to zip: %w", err)
+// SetHeadMeta sets the title, password, category and tag meta entries from
+// the set fields of meta. Tags are written as one comma-separated tag entry.
+func (g *Generator) SetHeadMeta(meta config.NzbMeta) {
+	for key, value := range map[string]string{
+		"title":    meta.Title,
+		"password": meta.Password,
+		"category": meta.Category,
+		"tag":      strings.Join(meta.Tags, ", "),
+	} {
+		if value != "" {
+			g.SetMeta(key, value)
+		}
 	}
-
-	return nil
 }
 
-// AddFileHash adds a hash for a file
-func (g *Generator) AddFileHash(filename string, hash string) {
+// SetOutputLayout sets the path template and collision policy used by
+// Generate.
+func (g *Generator) SetOutputLayout(layout OutputLayout) {
 	g.mx.Lock()
 	defer g.mx.Unlock()
 
-	g.filesHash[filename] = hash
+	g.layout = layout
 }
 
-// generateFinalNzbPath creates the final NZB path based on the configuration
+// generateFinalNzbPath creates the final NZB path based on the configuration.
+// With a path template, the name of that path is the {name} of the template.
 func (g *Generator) generateFinalNzbPath(originalFilePath string) string {
+	nzbPath := g.nzbPathFor(originalFilePath)
+	if g.layout.Template == nil {
+		return nzbPath
+	}
+
+	vars := g.layout.Vars
+	if vars.Name == "" {
+		name := filepath.Base(nzbPath)
+		vars.Name = name[:len(name)-len(".nzb")]
+	}
+	if vars.Category == "" {
+		vars.Category = g.meta["category"]
+	}
+	if vars.Date.IsZero() {
+		vars.Date = time.Now()
+	}
+
+	rel := g.layout.Template.Expand(vars)
+	if rel == "" {
+		rel = vars.Name
+	}
+	if !strings.HasSuffix(strings.ToLower(rel), ".nzb") {
+		rel += ".nzb"
+	}
+	return filepath.Join(g.layout.Dir, rel)
+}
+
+// nzbPathFor returns the NZB path next to originalFilePath, keeping or
+// dropping its extension.
+func (g *Generator) nzbPathFor(originalFilePath string) string {
 	if strings.HasSuffix(strings.ToLower(originalFilePath), ".nzb") {
 		return originalFilePath
 	}
@@ -364,6 +542,59 @@ func Parse(path string) (*nzbparser.Nzb, error) {
 	return nzbparser.ParseString(string(data))
 }
 
+// ArticleRecords rebuilds manifest records for an NZB file whose source lives
+// at sourcePath and is fileSize bytes long. It is used to re-post segments of
+// uploads that no longer have a manifest: the Message-IDs, groups and poster
+// come from the NZB, while offsets are derived by accumulating the sizes of the
+// preceding segments in part order. The NZB only carries the unobfuscated
+// subject and filename, so those are used for both the posted and original
+// headers.
+func ArticleRecords(file nzbparser.NzbFile, sourcePath string, fileSize int64, role manifest.FileRole) []manifest.ArticleRecord {
+	segments := make([]nzbparser.NzbSegment, len(file.Segments))
+	copy(segments, file.Segments)
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].Number < segments[j].Number
+	})
+
+	fileName := file.Filename
+	if fileName == "" {
+		fileName = filepath.Base(sourcePath)
+	}
+
+	recs := make([]manifest.ArticleRecord, 0, len(segments))
+	var offset int64
+	for i, s := range segments {
+		size := int64(s.Bytes)
+		if offset+size > fileSize {
+			size = fileSize - offset
+		}
+		if size < 0 {
+			size = 0
+		}
+
+		recs = append(recs, manifest.ArticleRecord{
+			Index:           i,
+			SourcePath:      sourcePath,
+			FileRole:        role,
+			Offset:          offset,
+			BodySize:        uint64(size),
+			MessageID:       s.ID,
+			Subject:         file.Subject,
+			OriginalSubject: file.Subject,
+			From:            file.Poster,
+			Groups:          file.Groups,
+			Date:            time.Unix(int64(file.Date), 0),
+			FileName:        fileName,
+			PartNumber:      s.Number,
+			TotalParts:      len(segments),
+			FileSize:        fileSize,
+		})
+		offset += int64(s.Bytes)
+	}
+
+	return recs
+}
+
 // Validate checks if an NZB file is valid
 func Validate(path string) error {
 	nzbFile, err := Parse(path)
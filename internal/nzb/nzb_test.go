@@ -1,12 +1,21 @@
 package nzb
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/andybalholm/brotli"
+	"github.com/javi11/nzbparser"
 	"github.com/javi11/postie/internal/article"
 	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/manifest"
+	"github.com/javi11/postie/internal/nzbpath"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -28,7 +37,7 @@ func TestNewGenerator(t *testing.T) {
 	require.True(t, ok, "Generator should be of type *Generator")
 	assert.Equal(t, segmentSize, g.segmentSize, "Segment size should match")
 	assert.Equal(t, compressionConfig, g.compressionConfig, "Compression config should match")
-	assert.NotNil(t, g.articles, "Articles map should be initialized")
+	assert.NotNil(t, g.files, "Files map should be initialized")
 	assert.NotNil(t, g.filesHash, "Files hash map should be initialized")
 }
 
@@ -59,9 +68,11 @@ func TestAddArticle(t *testing.T) {
 	generator.AddArticle(testArticle)
 
 	// Check that the article was added correctly
-	assert.Len(t, generator.articles, 1, "Generator should have one file")
-	assert.Len(t, generator.articles["test-file.txt"], 1, "File should have one article")
-	assert.Equal(t, testArticle, generator.articles["test-file.txt"][0], "Article should match")
+	nzbFile := generateAndParse(t, generator)
+	require.Len(t, nzbFile.Files, 1, "Generator should have one file")
+	require.Len(t, nzbFile.Files[0].Segments, 1, "File should have one article")
+	assert.Equal(t, "test-message-id-1", nzbFile.Files[0].Segments[0].ID, "Article should match")
+	assert.Equal(t, "Test Subject", nzbFile.Files[0].Subject, "Article should match")
 
 	// Create a second article with the same message ID but different data
 	updatedArticle := &article.Article{
@@ -80,9 +91,12 @@ func TestAddArticle(t *testing.T) {
 	generator.AddArticle(updatedArticle)
 
 	// Check that the article was updated rather than added
-	assert.Len(t, generator.articles, 1, "Generator should still have one file")
-	assert.Len(t, generator.articles["test-file.txt"], 1, "File should still have one article")
-	assert.Equal(t, updatedArticle, generator.articles["test-file.txt"][0], "Article should be updated")
+	nzbFile = generateAndParse(t, generator)
+	require.Len(t, nzbFile.Files, 1, "Generator should still have one file")
+	require.Len(t, nzbFile.Files[0].Segments, 1, "File should still have one article")
+	assert.Equal(t, 600, nzbFile.Files[0].Segments[0].Bytes, "Article should be updated")
+	assert.Equal(t, "Updated Subject", nzbFile.Files[0].Subject, "Article should be updated")
+	assert.Equal(t, "updated@example.com", nzbFile.Files[0].Poster, "Article should be updated")
 
 	// Add another article with a different message ID
 	secondArticle := &article.Article{
@@ -101,8 +115,19 @@ func TestAddArticle(t *testing.T) {
 	generator.AddArticle(secondArticle)
 
 	// Check that both articles are now in the generator
-	assert.Len(t, generator.articles, 1, "Generator should have one file")
-	assert.Len(t, generator.articles["test-file.txt"], 2, "File should have two articles")
+	nzbFile = generateAndParse(t, generator)
+	require.Len(t, nzbFile.Files, 1, "Generator should have one file")
+	assert.Len(t, nzbFile.Files[0].Segments, 2, "File should have two articles")
+}
+
+// generateAndParse generates the NZB of g in a temp dir and parses it.
+func generateAndParse(t *testing.T, g NZBGenerator) *nzbparser.Nzb {
+	t.Helper()
+	path, err := g.Generate(filepath.Join(t.TempDir(), "test.nzb"))
+	require.NoError(t, err)
+	nzbFile, err := Parse(path)
+	require.NoError(t, err)
+	return nzbFile
 }
 
 func TestAddFileHash(t *testing.T) {
@@ -365,6 +390,259 @@ func TestGenerate(t *testing.T) {
 	})
 }
 
+func TestGenerate_FileGroupsFromEveryArticle(t *testing.T) {
+	generator := NewGenerator(1000, config.NzbCompressionConfig{}, true).(*Generator)
+
+	// Articles spread over groups one by one (each_article), added out of order.
+	for i, group := range []string{"alt.b", "alt.a", "alt.b", "alt.c"} {
+		generator.AddArticle(&article.Article{
+			MessageID:       fmt.Sprintf("id-%d", 4-i),
+			OriginalName:    "spread.bin",
+			OriginalSubject: "spread",
+			Groups:          []string{group},
+			PartNumber:      4 - i,
+			TotalParts:      4,
+			Size:            250,
+			FileNumber:      1,
+			FileName:        "spread.bin",
+		})
+	}
+
+	finalPath, err := generator.Generate(filepath.Join(t.TempDir(), "spread.nzb"))
+	require.NoError(t, err)
+
+	nzbFile, err := Parse(finalPath)
+	require.NoError(t, err)
+	require.Len(t, nzbFile.Files, 1)
+	// Groups in order of first use by part number: part 1 is alt.c.
+	assert.Equal(t, []string{"alt.c", "alt.b", "alt.a"}, nzbFile.Files[0].Groups)
+}
+
+func TestGenerate_VariedArticleSizes(t *testing.T) {
+	generator := NewGenerator(1000, config.NzbCompressionConfig{}, true).(*Generator)
+
+	// Jittered article sizes, one of them above the configured size.
+	sizes := []uint64{950, 1080, 990, 400}
+	for i, size := range sizes {
+		generator.AddArticle(&article.Article{
+			MessageID:       fmt.Sprintf("id-%d", i+1),
+			OriginalName:    "varied.bin",
+			OriginalSubject: "varied",
+			Groups:          []string{"alt.test"},
+			PartNumber:      i + 1,
+			TotalParts:      len(sizes),
+			Size:            size,
+			FileNumber:      1,
+			FileName:        "varied.bin",
+		})
+	}
+
+	finalPath, err := generator.Generate(filepath.Join(t.TempDir(), "varied.nzb"))
+	require.NoError(t, err)
+
+	nzbFile, err := Parse(finalPath)
+	require.NoError(t, err)
+	require.Len(t, nzbFile.Files, 1)
+	assert.Equal(t, "1080", nzbFile.Meta["chunk_size"])
+	assert.Equal(t, int64(3420), nzbFile.Files[0].Bytes)
+	for i, seg := range nzbFile.Files[0].Segments {
+		assert.Equal(t, int(sizes[i]), seg.Bytes, "segment %d", i+1)
+	}
+}
+
+func TestGenerate_HeadMeta(t *testing.T) {
+	generator := NewGenerator(1000, config.NzbCompressionConfig{}, true)
+	generator.AddArticle(&article.Article{
+		MessageID:       "id-1",
+		OriginalName:    "packed.zip.001",
+		OriginalSubject: "packed",
+		Groups:          []string{"alt.test"},
+		PartNumber:      1,
+		TotalParts:      1,
+		Size:            1000,
+		FileNumber:      1,
+		FileName:        "packed.zip.001",
+	})
+	generator.SetMeta("password", "s3cret")
+
+	finalPath, err := generator.Generate(filepath.Join(t.TempDir(), "packed.nzb"))
+	require.NoError(t, err)
+
+	nzbFile, err := Parse(finalPath)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", nzbFile.Meta["password"])
+	assert.Equal(t, "1000", nzbFile.Meta["chunk_size"])
+}
+
+func TestGenerate_SetHeadMeta(t *testing.T) {
+	generator := NewGenerator(1000, config.NzbCompressionConfig{}, true)
+	generator.AddArticle(&article.Article{
+		MessageID:       "id-1",
+		OriginalName:    "movie.mkv",
+		OriginalSubject: "movie",
+		Groups:          []string{"alt.test"},
+		PartNumber:      1,
+		TotalParts:      1,
+		Size:            1000,
+		FileNumber:      1,
+		FileName:        "movie.mkv",
+	})
+	generator.SetHeadMeta(config.NzbMeta{
+		Title:    "Movie (2024)",
+		Category: "movies",
+		Tags:     []string{"hd", "remux"},
+	})
+
+	finalPath, err := generator.Generate(filepath.Join(t.TempDir(), "movie.nzb"))
+	require.NoError(t, err)
+
+	nzbFile, err := Parse(finalPath)
+	require.NoError(t, err)
+	assert.Equal(t, "Movie (2024)", nzbFile.Meta["title"])
+	assert.Equal(t, "movies", nzbFile.Meta["category"])
+	assert.Equal(t, "hd, remux", nzbFile.Meta["tag"])
+	_, hasPassword := nzbFile.Meta["password"]
+	assert.False(t, hasPassword, "unset fields must not be written")
+}
+
+// newSingleArticleGenerator returns a generator holding one article of name.
+func newSingleArticleGenerator(name string) NZBGenerator {
+	generator := NewGenerator(1000, config.NzbCompressionConfig{}, false)
+	generator.AddArticle(&article.Article{
+		MessageID:       "id-1",
+		OriginalName:    name,
+		OriginalSubject: name,
+		Groups:          []string{"alt.test"},
+		PartNumber:      1,
+		TotalParts:      1,
+		Size:            1000,
+		FileNumber:      1,
+		FileName:        name,
+	})
+	return generator
+}
+
+func TestGenerate_PathTemplate(t *testing.T) {
+	tmpl, err := nzbpath.Parse("{category}/{yyyy}/{folder}/{name}.nzb")
+	require.NoError(t, err)
+
+	outputDir := t.TempDir()
+	generator := newSingleArticleGenerator("movie.mkv")
+	generator.SetHeadMeta(config.NzbMeta{Category: "movies"})
+	generator.SetOutputLayout(OutputLayout{
+		Dir:      outputDir,
+		Template: tmpl,
+		Vars:     nzbpath.Vars{Folder: "Films/HD", Date: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+	})
+
+	// The name comes from the path given to Generate, without its extension
+	finalPath, err := generator.Generate(filepath.Join(outputDir, "ignored", "movie.mkv"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(outputDir, "movies", "2026", "Films", "HD", "movie.nzb"), finalPath)
+	assert.FileExists(t, finalPath)
+}
+
+func TestGenerate_Collision(t *testing.T) {
+	outputDir := t.TempDir()
+	existing := filepath.Join(outputDir, "movie.nzb")
+
+	generateErr := func(policy config.NzbCollisionPolicy) (string, error) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(existing, []byte("existing"), 0644))
+		generator := newSingleArticleGenerator("movie.mkv")
+		defer generator.Close()
+		generator.SetOutputLayout(OutputLayout{OnCollision: policy})
+		return generator.Generate(filepath.Join(outputDir, "movie.mkv"))
+	}
+	generate := func(policy config.NzbCollisionPolicy) string {
+		t.Helper()
+		finalPath, err := generateErr(policy)
+		require.NoError(t, err)
+		return finalPath
+	}
+
+	// skip keeps the existing NZB and reports that the new one was not
+	// written, rather than the path of another upload's NZB
+	finalPath, err := generateErr(config.NzbCollisionSkip)
+	assert.ErrorIs(t, err, ErrNzbExists)
+	assert.Empty(t, finalPath)
+	data, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, "existing", string(data))
+
+	// suffix numbers the new NZB after the taken ones
+	assert.Equal(t, filepath.Join(outputDir, "movie (1).nzb"), generate(config.NzbCollisionSuffix))
+	assert.Equal(t, filepath.Join(outputDir, "movie (2).nzb"), generate(config.NzbCollisionSuffix))
+	data, err = os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, "existing", string(data))
+
+	// overwrite is the default
+	assert.Equal(t, existing, generate(""))
+	_, err = Parse(existing)
+	assert.NoError(t, err)
+}
+
+func TestGenerate_ConcurrentSamePath(t *testing.T) {
+	outputDir := t.TempDir()
+
+	// Jobs writing the same NZB each use a temp file of their own.
+	const jobs = 8
+	errs := make([]error, jobs)
+	var wg sync.WaitGroup
+	for i := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			generator := newSingleArticleGenerator("movie.mkv")
+			defer generator.Close()
+			_, errs[i] = generator.Generate(filepath.Join(outputDir, "movie.mkv"))
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	_, err := Parse(filepath.Join(outputDir, "movie.nzb"))
+	assert.NoError(t, err)
+	entries, err := os.ReadDir(outputDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temp files were left behind")
+}
+
+func TestGenerate_ConcurrentSuffix(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "movie.nzb"), []byte("existing"), 0644))
+
+	// Concurrent jobs of the same name each get a path of their own.
+	const jobs = 8
+	paths := make([]string, jobs)
+	var wg sync.WaitGroup
+	for i := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			generator := newSingleArticleGenerator("movie.mkv")
+			defer generator.Close()
+			generator.SetOutputLayout(OutputLayout{OnCollision: config.NzbCollisionSuffix})
+			var err error
+			paths[i], err = generator.Generate(filepath.Join(outputDir, "movie.mkv"))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		assert.False(t, seen[path], "two jobs wrote %s", path)
+		seen[path] = true
+	}
+	entries, err := os.ReadDir(outputDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, jobs+1)
+}
+
 func TestParse(t *testing.T) {
 	// Create a simple NZB file for testing
 	nzbContent := `<?xml version="1.0" encoding="UTF-8"?>
@@ -416,6 +694,40 @@ func TestParse(t *testing.T) {
 	assert.Error(t, err, "Parsing a non-existent file should fail")
 }
 
+func TestArticleRecords(t *testing.T) {
+	file := nzbparser.NzbFile{
+		Subject:  "[1/1] \"movie.mkv\" - yEnc (1/3)",
+		Poster:   "poster@example.com",
+		Groups:   []string{"alt.test"},
+		Date:     1672574400,
+		Filename: "movie.mkv",
+		// Out of order on purpose: offsets must follow part numbers.
+		Segments: []nzbparser.NzbSegment{
+			{Bytes: 1000, Number: 3, ID: "part3@test"},
+			{Bytes: 1000, Number: 1, ID: "part1@test"},
+			{Bytes: 1000, Number: 2, ID: "part2@test"},
+		},
+	}
+
+	recs := ArticleRecords(file, "/data/movie.mkv", 2500, manifest.RoleOriginal)
+	require.Len(t, recs, 3)
+
+	for i, rec := range recs {
+		assert.Equal(t, i+1, rec.PartNumber)
+		assert.Equal(t, int64(i*1000), rec.Offset)
+		assert.Equal(t, 3, rec.TotalParts)
+		assert.Equal(t, "/data/movie.mkv", rec.SourcePath)
+		assert.Equal(t, "movie.mkv", rec.FileName)
+		assert.Equal(t, int64(2500), rec.FileSize)
+		assert.Equal(t, "poster@example.com", rec.From)
+		assert.Equal(t, []string{"alt.test"}, rec.Groups)
+	}
+	assert.Equal(t, "part1@test", recs[0].MessageID)
+	assert.Equal(t, uint64(1000), recs[0].BodySize)
+	// The final segment is clamped to the bytes actually left in the file.
+	assert.Equal(t, uint64(500), recs[2].BodySize)
+}
+
 func TestValidate(t *testing.T) {
 	t.Run("valid nzb file", func(t *testing.T) {
 		// Create a valid NZB file for testing
@@ -545,24 +857,24 @@ func TestCompressWithZstd(t *testing.T) {
 	// Create test data
 	testData := []byte("This is test data for compression")
 
-	// Create a temporary file for the compressed output
-	tempFile, err := os.CreateTemp("", "test-zstd-*.zst")
-	require.NoError(t, err, "Failed to create temp file")
-	defer func() {
-		err := tempFile.Close()
-		assert.NoError(t, err, "Failed to close temp file")
-		err = os.Remove(tempFile.Name())
-		assert.NoError(t, err, "Failed to remove temp file")
-	}()
-
 	// Compress the data
-	err = generator.compressWithZstd(testData, tempFile.Name())
+	path, err := generator.createNzb(filepath.Join(t.TempDir(), "test.nzb"), func(w io.Writer) error {
+		_, err := w.Write(testData)
+		return err
+	})
 	require.NoError(t, err, "Failed to compress data with zstd")
+	assert.Equal(t, ".zst", filepath.Ext(path))
 
-	// Check that the compressed file exists and is not empty
-	info, err := os.Stat(tempFile.Name())
+	// Check that the compressed file decompresses to the data
+	f, err := os.Open(path)
 	require.NoError(t, err, "Compressed file should exist")
-	assert.Greater(t, info.Size(), int64(0), "Compressed file should not be empty")
+	defer func() { _ = f.Close() }()
+	dec, err := zstd.NewReader(f)
+	require.NoError(t, err)
+	defer dec.Close()
+	data, err := io.ReadAll(dec)
+	require.NoError(t, err)
+	assert.Equal(t, testData, data)
 }
 
 func TestCompressWithBrotli(t *testing.T) {
@@ -578,22 +890,19 @@ func TestCompressWithBrotli(t *testing.T) {
 	// Create test data
 	testData := []byte("This is test data for compression")
 
-	// Create a temporary file for the compressed output
-	tempFile, err := os.CreateTemp("", "test-brotli-*.br")
-	require.NoError(t, err, "Failed to create temp file")
-	defer func() {
-		err := tempFile.Close()
-		assert.NoError(t, err, "Failed to close temp file")
-		err = os.Remove(tempFile.Name())
-		assert.NoError(t, err, "Failed to remove temp file")
-	}()
-
 	// Compress the data
-	err = generator.compressWithBrotli(testData, tempFile.Name())
+	path, err := generator.createNzb(filepath.Join(t.TempDir(), "test.nzb"), func(w io.Writer) error {
+		_, err := w.Write(testData)
+		return err
+	})
 	require.NoError(t, err, "Failed to compress data with brotli")
+	assert.Equal(t, ".br", filepath.Ext(path))
 
-	// Check that the compressed file exists and is not empty
-	info, err := os.Stat(tempFile.Name())
+	// Check that the compressed file decompresses to the data
+	f, err := os.Open(path)
 	require.NoError(t, err, "Compressed file should exist")
-	assert.Greater(t, info.Size(), int64(0), "Compressed file should not be empty")
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(brotli.NewReader(f))
+	require.NoError(t, err)
+	assert.Equal(t, testData, data)
 }
@@ -0,0 +1,278 @@
+package nzb
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+)
+
+// spoolRunSize is how many segments are buffered in memory before they are
+// sorted and spilled to the spool file as one run.
+const spoolRunSize = 1 << 16
+
+// segmentRecord is the NZB entry of one article. seq orders records of the
+// same part: the last one added wins.
+type segmentRecord struct {
+	file uint32
+	part uint64
+	seq  uint64
+	size uint64
+	id   string
+}
+
+func compareRecords(a, b segmentRecord) int {
+	switch {
+	case a.file != b.file:
+		return cmpUint(uint64(a.file), uint64(b.file))
+	case a.part != b.part:
+		return cmpUint(a.part, b.part)
+	default:
+		return cmpUint(a.seq, b.seq)
+	}
+}
+
+func cmpUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// spoolRun is one sorted run in the spool file, with the byte range of each
+// file's records.
+type spoolRun struct {
+	spans []spoolSpan
+}
+
+type spoolSpan struct {
+	file   uint32
+	offset int64
+	length int64
+}
+
+// spool holds the segments of a generator: a buffer of recent records and
+// sorted runs of older ones in a temporary file, created on first spill.
+type spool struct {
+	pending  []segmentRecord
+	sorted   bool
+	file     *os.File
+	size     int64
+	runs     []spoolRun
+	runSize  int  // records buffered before a spill; spoolRunSize when zero
+	inMemory bool // a spill failed: keep every record in pending
+}
+
+// add buffers rec, spilling the buffer once it is full. When a spill fails
+// the records stay buffered and later ones are no longer spilled.
+func (s *spool) add(rec segmentRecord) error {
+	s.pending = append(s.pending, rec)
+	s.sorted = false
+
+	runSize := s.runSize
+	if runSize == 0 {
+		runSize = spoolRunSize
+	}
+	if s.inMemory || len(s.pending) < runSize {
+		return nil
+	}
+	if err := s.spill(); err != nil {
+		s.inMemory = true
+		return err
+	}
+	return nil
+}
+
+// spill sorts the buffered records and appends them to the spool file.
+func (s *spool) spill() error {
+	if s.file == nil {
+		f, err := os.CreateTemp("", "postie-nzb-*.spool")
+		if err != nil {
+			return fmt.Errorf("error creating NZB spool: %w", err)
+		}
+		s.file = f
+	}
+	s.sort()
+
+	w := bufio.NewWriter(io.NewOffsetWriter(s.file, s.size))
+	var run spoolRun
+	var buf []byte
+	for i, rec := range s.pending {
+		if i == 0 || rec.file != s.pending[i-1].file {
+			run.spans = append(run.spans, spoolSpan{file: rec.file, offset: s.size})
+		}
+		buf = binary.AppendUvarint(buf[:0], rec.part)
+		buf = binary.AppendUvarint(buf, rec.seq)
+		buf = binary.AppendUvarint(buf, rec.size)
+		buf = binary.AppendUvarint(buf, uint64(len(rec.id)))
+		buf = append(buf, rec.id...)
+		if _, err := w.Write(buf); err != nil {
+			return fmt.Errorf("error writing NZB spool: %w", err)
+		}
+		s.size += int64(len(buf))
+		run.spans[len(run.spans)-1].length += int64(len(buf))
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("error writing NZB spool: %w", err)
+	}
+
+	s.runs = append(s.runs, run)
+	s.pending = s.pending[:0]
+	return nil
+}
+
+func (s *spool) sort() {
+	if !s.sorted {
+		slices.SortFunc(s.pending, compareRecords)
+		s.sorted = true
+	}
+}
+
+// each calls fn with the segments of file in part order, one per part.
+func (s *spool) each(file uint32, fn func(segmentRecord) error) error {
+	s.sort()
+
+	var sources mergeHeap
+	start := sort.Search(len(s.pending), func(i int) bool { return s.pending[i].file >= file })
+	end := sort.Search(len(s.pending), func(i int) bool { return s.pending[i].file > file })
+	if start < end {
+		sources = append(sources, &memorySource{records: s.pending[start:end]})
+	}
+	for _, run := range s.runs {
+		for _, span := range run.spans {
+			if span.file == file {
+				r := bufio.NewReaderSize(io.NewSectionReader(s.file, span.offset, span.length), 16<<10)
+				sources = append(sources, &runSource{r: r, file: file})
+			}
+		}
+	}
+
+	for i := 0; i < len(sources); {
+		ok, err := sources[i].next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			i++
+			continue
+		}
+		sources = slices.Delete(sources, i, i+1)
+	}
+	heap.Init(&sources)
+
+	var (
+		last    segmentRecord
+		hasLast bool
+	)
+	for sources.Len() > 0 {
+		src := sources[0]
+		rec := src.current()
+		if hasLast && rec.part != last.part {
+			if err := fn(last); err != nil {
+				return err
+			}
+		}
+		last, hasLast = rec, true
+
+		ok, err := src.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&sources, 0)
+		} else {
+			heap.Pop(&sources)
+		}
+	}
+	if hasLast {
+		return fn(last)
+	}
+	return nil
+}
+
+// close removes the spool file.
+func (s *spool) close() error {
+	s.pending, s.runs, s.size, s.inMemory = nil, nil, 0, false
+	if s.file == nil {
+		return nil
+	}
+	name := s.file.Name()
+	err := s.file.Close()
+	s.file = nil
+	if rmErr := os.Remove(name); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// segmentSource yields the records of one file in (part, seq) order.
+type segmentSource interface {
+	// next advances to the next record, reporting false at the end.
+	next() (bool, error)
+	current() segmentRecord
+}
+
+type memorySource struct {
+	records []segmentRecord
+	pos     int
+}
+
+func (m *memorySource) next() (bool, error) {
+	m.pos++
+	return m.pos <= len(m.records), nil
+}
+
+func (m *memorySource) current() segmentRecord { return m.records[m.pos-1] }
+
+type runSource struct {
+	r    *bufio.Reader
+	file uint32
+	rec  segmentRecord
+}
+
+func (s *runSource) next() (bool, error) {
+	part, err := binary.ReadUvarint(s.r)
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error reading NZB spool: %w", err)
+	}
+	var fields [3]uint64
+	for i := range fields {
+		if fields[i], err = binary.ReadUvarint(s.r); err != nil {
+			return false, fmt.Errorf("error reading NZB spool: %w", err)
+		}
+	}
+	id := make([]byte, fields[2])
+	if _, err := io.ReadFull(s.r, id); err != nil {
+		return false, fmt.Errorf("error reading NZB spool: %w", err)
+	}
+	s.rec = segmentRecord{file: s.file, part: part, seq: fields[0], size: fields[1], id: string(id)}
+	return true, nil
+}
+
+func (s *runSource) current() segmentRecord { return s.rec }
+
+// mergeHeap orders sources by their current record.
+type mergeHeap []segmentSource
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	return compareRecords(h[i].current(), h[j].current()) < 0
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(segmentSource)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
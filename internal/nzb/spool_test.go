@@ -0,0 +1,86 @@
+package nzb
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/javi11/postie/internal/article"
+	"github.com/javi11/postie/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_SpilledSegments(t *testing.T) {
+	generator := NewGenerator(100, config.NzbCompressionConfig{}, true).(*Generator)
+	generator.segments.runSize = 7
+	defer generator.Close()
+
+	// Parts of two files are added out of order, across several runs.
+	const parts = 50
+	for i := parts; i >= 1; i-- {
+		for _, name := range []string{"a.bin", "b.bin"} {
+			generator.AddArticle(&article.Article{
+				MessageID:       fmt.Sprintf("%s-%d@test", name, i),
+				OriginalName:    name,
+				OriginalSubject: name,
+				From:            "poster@example.com",
+				Groups:          []string{"alt.test"},
+				PartNumber:      i,
+				TotalParts:      parts,
+				Size:            100,
+				FileNumber:      map[string]int{"a.bin": 1, "b.bin": 2}[name],
+			})
+		}
+	}
+	// A repost of part 3 replaces the spilled one.
+	generator.AddArticle(&article.Article{
+		MessageID:    "a.bin-3-repost@test",
+		OriginalName: "a.bin",
+		Groups:       []string{"alt.test"},
+		PartNumber:   3,
+		Size:         90,
+		FileNumber:   1,
+	})
+
+	require.NotEmpty(t, generator.segments.runs, "Segments should have been spilled")
+	spoolPath := generator.segments.file.Name()
+
+	nzbFile := generateAndParse(t, generator)
+	require.Len(t, nzbFile.Files, 2)
+	for n, file := range nzbFile.Files {
+		require.Len(t, file.Segments, parts, "file %d", n)
+		for i, s := range file.Segments {
+			assert.Equal(t, i+1, s.Number, "segments should be in part order")
+		}
+	}
+
+	a := nzbFile.Files[0]
+	assert.Equal(t, "a.bin", a.Subject)
+	assert.Equal(t, "a.bin-3-repost@test", a.Segments[2].ID)
+	assert.Equal(t, int64(parts*100-10), a.Bytes)
+	assert.Equal(t, "b.bin-1@test", nzbFile.Files[1].Segments[0].ID)
+
+	generator.Close()
+	_, err := os.Stat(spoolPath)
+	assert.True(t, os.IsNotExist(err), "Close should remove the spool file")
+}
+
+func TestGenerate_EscapesMeta(t *testing.T) {
+	generator := NewGenerator(100, config.NzbCompressionConfig{}, true)
+	defer generator.Close()
+	generator.AddArticle(&article.Article{
+		MessageID:       "id@test",
+		OriginalName:    "a.bin",
+		OriginalSubject: `"a&b.bin"`,
+		Groups:          []string{"alt.test"},
+		PartNumber:      1,
+		Size:            100,
+	})
+	generator.SetMeta("password", "p<&>")
+
+	// The password would make the XML invalid if written as is.
+	nzbFile := generateAndParse(t, generator)
+	assert.Equal(t, `"a&b.bin"`, nzbFile.Files[0].Subject)
+	assert.Contains(t, nzbFile.Meta, "password")
+}
@@ -0,0 +1,94 @@
+// Package nzbpath resolves nzb_output.path_template, the layout of the NZBs
+// below output_dir.
+package nzbpath
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/javi11/postie/internal/tokentemplate"
+)
+
+// Vars are the values substituted into an NZB path template.
+type Vars struct {
+	// Name is the NZB name without the .nzb extension ({name}): the source
+	// file name, or the folder name of a folder posted as a single NZB.
+	Name string
+	// Folder is the directory of the source below the input folder, or the
+	// folder name of a folder post ({folder}). It may span several
+	// directories.
+	Folder string
+	// Root is the base name of the input folder: the watch folder, or the
+	// directory the source was added from ({root}).
+	Root       string
+	Category   string
+	TransferID string
+	Date       time.Time
+}
+
+// tokens lists the supported tokens. Only {folder} may add directories;
+// path separators in the other values are replaced.
+var tokens = map[string]func(v Vars) string{
+	"name":        func(v Vars) string { return segment(v.Name) },
+	"folder":      func(v Vars) string { return filepath.ToSlash(v.Folder) },
+	"root":        func(v Vars) string { return segment(v.Root) },
+	"category":    func(v Vars) string { return segment(v.Category) },
+	"transfer_id": func(v Vars) string { return segment(v.TransferID) },
+	"yyyy":        func(v Vars) string { return v.Date.Format("2006") },
+	"mm":          func(v Vars) string { return v.Date.Format("01") },
+	"dd":          func(v Vars) string { return v.Date.Format("02") },
+}
+
+var separatorReplacer = strings.NewReplacer("/", "_", `\`, "_")
+
+func segment(s string) string {
+	return separatorReplacer.Replace(s)
+}
+
+// Template is a parsed NZB path template such as
+// {category}/{folder}/{name}.nzb. Tokens are written as {name}; a literal
+// brace is written as {{ or }}.
+type Template struct {
+	// parts alternates literal text (even indexes) and token names (odd).
+	parts []string
+}
+
+// Parse parses s, rejecting unknown tokens, unbalanced braces and absolute
+// paths.
+func Parse(s string) (*Template, error) {
+	if filepath.IsAbs(s) || strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("template %q must be relative to output_dir", s)
+	}
+	parts, err := tokentemplate.Parse(s, tokens)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{parts: parts}, nil
+}
+
+// Expand renders the template with v as a path relative to the output
+// directory. Directories left empty by an empty value are dropped, as are
+// "." and ".." elements, so the path never leaves the output directory. The
+// result is empty only if every element is.
+func (t *Template) Expand(v Vars) string {
+	var sb strings.Builder
+	for i, p := range t.parts {
+		if i%2 == 0 {
+			sb.WriteString(filepath.ToSlash(p))
+		} else {
+			sb.WriteString(tokens[p](v))
+		}
+	}
+
+	var elems []string
+	for _, elem := range strings.Split(sb.String(), "/") {
+		elem = strings.TrimSpace(elem)
+		if elem == "" || elem == "." || elem == ".." {
+			continue
+		}
+		elems = append(elems, elem)
+	}
+	return filepath.Join(elems...)
+}
@@ -0,0 +1,65 @@
+package nzbpath
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, tmpl := range []string{
+		"{name}.nzb",
+		"{category}/{folder}/{name}.nzb",
+		"{yyyy}/{mm}/{dd}/{transfer_id}",
+		"{{literal}}/{root}/{name}",
+	} {
+		if _, err := Parse(tmpl); err != nil {
+			t.Errorf("Parse(%q): %v", tmpl, err)
+		}
+	}
+
+	for _, tmpl := range []string{
+		"{unknown}.nzb",
+		"{name",
+		"closing } only",
+		"/nzbs/{name}.nzb",
+	} {
+		if _, err := Parse(tmpl); err == nil {
+			t.Errorf("Parse(%q): expected an error", tmpl)
+		}
+	}
+}
+
+func TestTemplateExpand(t *testing.T) {
+	vars := Vars{
+		Name:       "ep.mkv",
+		Folder:     "Show/S01",
+		Root:       "tv",
+		Category:   "tv/hd",
+		TransferID: "t-1",
+		Date:       time.Date(2026, 3, 4, 23, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		tmpl string
+		vars Vars
+		want string
+	}{
+		{"{category}/{folder}/{name}.nzb", vars, "tv_hd/Show/S01/ep.mkv.nzb"},
+		{"{yyyy}/{mm}/{dd}/{transfer_id}.nzb", vars, "2026/03/04/t-1.nzb"},
+		{"{root}/{{x}}/{name}", vars, "tv/{x}/ep.mkv"},
+		// Empty values drop their directory.
+		{"{category}/{folder}/{name}.nzb", Vars{Name: "film"}, "film.nzb"},
+		// The path cannot leave the output directory.
+		{"../{folder}/{name}", Vars{Name: "film", Folder: "../.."}, "film"},
+	}
+	for _, tt := range tests {
+		tmpl, err := Parse(tt.tmpl)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.tmpl, err)
+		}
+		if got := tmpl.Expand(tt.vars); got != filepath.FromSlash(tt.want) {
+			t.Errorf("Expand(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
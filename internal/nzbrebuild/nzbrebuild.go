@@ -0,0 +1,170 @@
+// Package nzbrebuild regenerates the NZB of a completed upload from the
+// durable transfer manifests recorded while it was posted. Manifests hold the
+// exact Message-ID, subject and groups of every article, so a deleted or
+// corrupted NZB can be rebuilt without touching the servers.
+//
+// Manifests are removed once a transfer is verified unless
+// post_check.maintain_manifests is enabled, so a rebuild only works for
+// transfers that are still pending verification, failed verification, or
+// whose manifests were maintained.
+package nzbrebuild
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/manifest"
+	"github.com/javi11/postie/internal/nzb"
+	"github.com/javi11/postie/internal/queue"
+	"github.com/javi11/postie/internal/transferstore"
+)
+
+// compressedNzbExts are the suffixes nzb.Generator appends to a compressed NZB.
+var compressedNzbExts = []string{".zst", ".br", ".zip"}
+
+// CompletedItem rebuilds the NZB of the completed queue item id. When
+// outputPath is empty the NZB is written back to the item's original location
+// and the item is updated if the final path changed (e.g. the compression
+// setting changed since the upload). It returns the path that was written.
+//
+// The item is read from db directly rather than through a queue.Queue, so a
+// rebuild does not recover or re-queue the items of a running instance.
+func CompletedItem(ctx context.Context, db *sql.DB, cfg *config.ConfigData, id string, outputPath string) (string, error) {
+	var itemNzbPath string
+	var jobData []byte
+	err := db.QueryRowContext(ctx, "SELECT nzb_path, job_data FROM completed_items WHERE id = ?", id).Scan(&itemNzbPath, &jobData)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("completed item not found: %s", id)
+		}
+		return "", fmt.Errorf("failed to get completed item: %w", err)
+	}
+
+	var job queue.FileJob
+	if err := json.Unmarshal(jobData, &job); err != nil {
+		return "", fmt.Errorf("failed to decode job data: %w", err)
+	}
+	if job.TransferID == "" {
+		return "", fmt.Errorf("completed item %s has no transfer id, it was uploaded before durable transfers", id)
+	}
+
+	paths, err := ManifestPaths(ctx, transferstore.New(db), cfg.GetDatabaseConfig().ManifestDir(), job.TransferID)
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return "", fmt.Errorf("no manifests found for transfer %s; enable post_check.maintain_manifests to keep them after verification", job.TransferID)
+	}
+
+	updateItem := outputPath == ""
+	if updateItem {
+		outputPath = stripCompressionExt(itemNzbPath)
+	}
+
+	written, err := Generate(paths, cfg.GetNzbCompressionConfig(), cfg.GetMaintainOriginalExtension(), outputPath)
+	if err != nil {
+		return "", err
+	}
+
+	if updateItem && written != itemNzbPath {
+		if _, err := db.ExecContext(ctx, "UPDATE completed_items SET nzb_path = ? WHERE id = ?", written, id); err != nil {
+			return "", fmt.Errorf("failed to update NZB path: %w", err)
+		}
+	}
+
+	return written, nil
+}
+
+// ManifestPaths returns the manifests recorded for a transfer. The
+// transfer_files rows are preferred since they hold the exact manifest path;
+// once a verified transfer has been cleaned up its rows are gone, so the
+// transfer's manifest directory is scanned instead.
+func ManifestPaths(ctx context.Context, store *transferstore.Store, manifestDir, transferID string) ([]string, error) {
+	files, err := store.ListFilesByTransfer(ctx, transferID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfer files: %w", err)
+	}
+
+	var paths []string
+	for _, f := range files {
+		if f.ManifestPath == "" {
+			continue
+		}
+		if _, err := os.Stat(f.ManifestPath); err != nil {
+			continue
+		}
+		paths = append(paths, f.ManifestPath)
+	}
+	if len(paths) > 0 {
+		return paths, nil
+	}
+
+	paths, err = filepath.Glob(filepath.Join(manifestDir, transferID, "*.jsonl.zst"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Generate replays every article of the given manifests through a new
+// nzb.Generator and writes the NZB to outputPath. The chunk_size is that of
+// the largest recorded article, so it matches the upload even if the
+// configured article size changed since.
+func Generate(manifestPaths []string, compression config.NzbCompressionConfig, maintainOriginalExtension bool, outputPath string) (string, error) {
+	gen := nzb.NewGenerator(0, compression, maintainOriginalExtension)
+	defer gen.Close()
+
+	for _, path := range manifestPaths {
+		recs, err := readManifest(path)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		for _, rec := range recs {
+			gen.AddArticle(manifest.ArticleFromRecord(rec))
+		}
+	}
+
+	return gen.Generate(outputPath)
+}
+
+// readManifest returns every article record of a manifest.
+func readManifest(path string) ([]manifest.ArticleRecord, error) {
+	r, err := manifest.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	var recs []manifest.ArticleRecord
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return recs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+}
+
+// stripCompressionExt turns a compressed NZB path (name.nzb.zst) back into the
+// plain path the generator expects, since it appends the suffix itself.
+func stripCompressionExt(path string) string {
+	for _, ext := range compressedNzbExts {
+		if trimmed, ok := strings.CutSuffix(path, ext); ok && strings.HasSuffix(strings.ToLower(trimmed), ".nzb") {
+			return trimmed
+		}
+	}
+	return path
+}
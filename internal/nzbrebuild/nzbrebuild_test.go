@@ -0,0 +1,165 @@
+package nzbrebuild
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/database"
+	"github.com/javi11/postie/internal/manifest"
+	"github.com/javi11/postie/internal/nzb"
+	"github.com/javi11/postie/internal/queue"
+)
+
+func newTestQueue(t *testing.T, dbPath string) *queue.Queue {
+	t.Helper()
+	ctx := context.Background()
+	db, err := database.New(ctx, config.DatabaseConfig{
+		DatabaseType: "sqlite",
+		DatabasePath: dbPath,
+	})
+	if err != nil {
+		t.Fatalf("database.New: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := db.GetMigrationRunner().MigrateUp(); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	q, err := queue.New(ctx, db)
+	if err != nil {
+		t.Fatalf("queue.New: %v", err)
+	}
+	t.Cleanup(func() { _ = q.Close() })
+	return q
+}
+
+// writeManifest records parts articles of srcPath, sized as a 768000-byte
+// article split with the given final article size.
+func writeManifest(t *testing.T, path, srcPath string, fileNumber, parts int, lastSize uint64) {
+	t.Helper()
+	w, err := manifest.NewWriter(path)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	for i := range parts {
+		size := uint64(768000)
+		if i == parts-1 {
+			size = lastSize
+		}
+		if err := w.Write(manifest.ArticleRecord{
+			Index:           i,
+			SourcePath:      srcPath,
+			FileRole:        manifest.RoleOriginal,
+			Offset:          int64(i) * 768000,
+			BodySize:        size,
+			MessageID:       fmt.Sprintf("%s-%d@postie", filepath.Base(srcPath), i+1),
+			Subject:         "obfuscated",
+			OriginalSubject: fmt.Sprintf("[%d/2] - \"%s\" yEnc (%d/%d)", fileNumber, filepath.Base(srcPath), i+1, parts),
+			From:            "poster@example.com",
+			Groups:          []string{"alt.binaries.test"},
+			Date:            time.Unix(1700000000, 0).UTC(),
+			FileName:        "obfuscated.bin",
+			PartNumber:      i + 1,
+			TotalParts:      parts,
+			FileNumber:      fileNumber,
+		}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	m1 := filepath.Join(dir, "a.jsonl.zst")
+	m2 := filepath.Join(dir, "b.jsonl.zst")
+	writeManifest(t, m1, "/data/show/ep1.mkv", 1, 3, 1000)
+	writeManifest(t, m2, "/data/show/ep1.par2", 2, 1, 500)
+
+	out, err := Generate([]string{m2, m1}, config.NzbCompressionConfig{}, true, filepath.Join(dir, "show.nzb"))
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	n, err := nzb.Parse(out)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(n.Files) != 2 {
+		t.Fatalf("files = %d, want 2", len(n.Files))
+	}
+	if n.Meta["chunk_size"] != "768000" {
+		t.Errorf("chunk_size = %q, want 768000", n.Meta["chunk_size"])
+	}
+
+	f := n.Files[0]
+	if f.Filename != "ep1.mkv" || len(f.Segments) != 3 {
+		t.Fatalf("first file = %q with %d segments, want ep1.mkv with 3", f.Filename, len(f.Segments))
+	}
+	if f.Segments[0].ID != "ep1.mkv-1@postie" || f.Segments[2].Bytes != 1000 {
+		t.Errorf("unexpected segments: %+v", f.Segments)
+	}
+	if n.Files[1].Filename != "ep1.par2" {
+		t.Errorf("second file = %q, want ep1.par2", n.Files[1].Filename)
+	}
+}
+
+func TestCompletedItem(t *testing.T) {
+	dir := t.TempDir()
+	q := newTestQueue(t, filepath.Join(dir, "postie.db"))
+	ctx := context.Background()
+
+	if err := q.AddFile(ctx, "/data/show/ep1.mkv", 2*768000+1000); err != nil {
+		t.Fatalf("AddFile: %v", err)
+	}
+	msg, job, err := q.ReceiveFile(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("ReceiveFile: %v", err)
+	}
+	nzbPath := filepath.Join(dir, "out", "ep1.mkv.nzb")
+	if err := q.CompleteFile(ctx, msg.ID, nzbPath, job); err != nil {
+		t.Fatalf("CompleteFile: %v", err)
+	}
+
+	cfg := &config.ConfigData{Database: config.DatabaseConfig{DatabaseType: "sqlite", DatabasePath: filepath.Join(dir, "postie.db")}}
+
+	if _, err := CompletedItem(ctx, q.DB(), cfg, string(msg.ID), ""); err == nil {
+		t.Fatal("expected an error when no manifests exist")
+	}
+
+	// Verified transfers have no transfer_files rows left; the manifest
+	// directory is scanned instead.
+	writeManifest(t, manifest.FilePath(cfg.Database.ManifestDir(), job.TransferID, "f1"), "/data/show/ep1.mkv", 1, 3, 1000)
+
+	got, err := CompletedItem(ctx, q.DB(), cfg, string(msg.ID), "")
+	if err != nil {
+		t.Fatalf("CompletedItem: %v", err)
+	}
+	if got != nzbPath {
+		t.Errorf("rebuilt path = %q, want %q", got, nzbPath)
+	}
+	if _, err := os.Stat(nzbPath); err != nil {
+		t.Errorf("rebuilt NZB missing: %v", err)
+	}
+}
+
+func TestStripCompressionExt(t *testing.T) {
+	cases := map[string]string{
+		"/out/a.nzb.zst": "/out/a.nzb",
+		"/out/a.nzb.br":  "/out/a.nzb",
+		"/out/a.NZB.zip": "/out/a.NZB",
+		"/out/a.nzb":     "/out/a.nzb",
+		"/out/a.zip":     "/out/a.zip",
+	}
+	for in, want := range cases {
+		if got := stripCompressionExt(in); got != want {
+			t.Errorf("stripCompressionExt(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
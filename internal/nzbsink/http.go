@@ -0,0 +1,276 @@
+package nzbsink
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/javi11/postie/internal/config"
+)
+
+// httpSink POSTs NZBs as a multipart upload to a URL.
+type httpSink struct {
+	cfg    config.NzbSinkConfig
+	client *http.Client
+}
+
+func (s *httpSink) Name() string { return s.cfg.SinkName() }
+
+func (s *httpSink) Deliver(ctx context.Context, nzbPath string) error {
+	field := s.cfg.FormField
+	if field == "" {
+		field = "nzb"
+	}
+	f, err := os.Open(nzbPath)
+	if err != nil {
+		return fmt.Errorf("error opening NZB: %w", err)
+	}
+	req, err := uploadRequest(ctx, s.cfg.URL, field, filepath.Base(nzbPath), f)
+	if err != nil {
+		return err
+	}
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if s.cfg.Username != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+
+	_, err = do(s.client, req)
+	return err
+}
+
+// sabnzbdSink adds NZBs to SABnzbd with the addfile API mode.
+type sabnzbdSink struct {
+	cfg    config.NzbSinkConfig
+	client *http.Client
+}
+
+func (s *sabnzbdSink) Name() string { return s.cfg.SinkName() }
+
+func (s *sabnzbdSink) Deliver(ctx context.Context, nzbPath string) error {
+	query := url.Values{
+		"mode":   {"addfile"},
+		"apikey": {s.cfg.APIKey},
+		"output": {"json"},
+	}
+	if s.cfg.Category != "" {
+		query.Set("cat", s.cfg.Category)
+	}
+	r, name, err := openNZB(nzbPath)
+	if err != nil {
+		return err
+	}
+	req, err := uploadRequest(ctx, apiURL(s.cfg.URL, "api")+"?"+query.Encode(), "name", name, r)
+	if err != nil {
+		return err
+	}
+
+	body, err := do(s.client, req)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Status bool   `json:"status"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("invalid SABnzbd response: %w", err)
+	}
+	if !resp.Status {
+		return fmt.Errorf("SABnzbd rejected the NZB: %s", resp.Error)
+	}
+	return nil
+}
+
+// nzbgetSink adds NZBs to NZBGet with the append JSON-RPC method.
+type nzbgetSink struct {
+	cfg    config.NzbSinkConfig
+	client *http.Client
+}
+
+func (s *nzbgetSink) Name() string { return s.cfg.SinkName() }
+
+func (s *nzbgetSink) Deliver(ctx context.Context, nzbPath string) error {
+	r, name, err := openNZB(nzbPath)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	_ = r.Close()
+	if err != nil {
+		return fmt.Errorf("error reading NZB: %w", err)
+	}
+
+	// append(NZBFilename, NZBContent, Category, Priority, AddToTop,
+	// AddPaused, DupeKey, DupeScore, DupeMode, PPParameters)
+	payload, err := json.Marshal(map[string]any{
+		"method": "append",
+		"params": []any{
+			name, base64.StdEncoding.EncodeToString(data), s.cfg.Category,
+			0, false, false, "", 0, "SCORE", []any{},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL(s.cfg.URL, "jsonrpc"), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.Username != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+
+	body, err := do(s.client, req)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Result int `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("invalid NZBGet response: %w", err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("NZBGet rejected the NZB: %s", resp.Error.Message)
+	}
+	if resp.Result <= 0 {
+		return fmt.Errorf("NZBGet rejected the NZB")
+	}
+	return nil
+}
+
+// apiURL joins base, the download client's web interface URL, and its API
+// path, unless base already ends with it.
+func apiURL(base, path string) string {
+	base = strings.TrimRight(base, "/")
+	if strings.HasSuffix(base, "/"+path) {
+		return base
+	}
+	return base + "/" + path
+}
+
+// openNZB opens the NZB at nzbPath for a download client and returns it with
+// its file name. Download clients cannot read the zstd, brotli and zip NZBs
+// of nzb_compression, so those are decompressed and lose their suffix.
+func openNZB(nzbPath string) (io.ReadCloser, string, error) {
+	f, err := os.Open(nzbPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("error opening NZB: %w", err)
+	}
+	name := filepath.Base(nzbPath)
+	switch ext := filepath.Ext(name); strings.ToLower(ext) {
+	case ".zst":
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, "", fmt.Errorf("error decompressing NZB: %w", err)
+		}
+		return &decompressedNZB{Reader: zr, close: func() error {
+			zr.Close()
+			return f.Close()
+		}}, strings.TrimSuffix(name, ext), nil
+	case ".br":
+		return &decompressedNZB{Reader: brotli.NewReader(f), close: f.Close}, strings.TrimSuffix(name, ext), nil
+	case ".zip":
+		r, err := openZippedNZB(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, "", fmt.Errorf("error decompressing NZB: %w", err)
+		}
+		return &decompressedNZB{Reader: r, close: func() error {
+			_ = r.Close()
+			return f.Close()
+		}}, strings.TrimSuffix(name, ext), nil
+	}
+	return f, name, nil
+}
+
+// openZippedNZB opens the NZB inside f, a zip written by nzb_compression
+// with the NZB as its only entry.
+func openZippedNZB(f *os.File) (io.ReadCloser, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	if len(zr.File) != 1 {
+		return nil, fmt.Errorf("zip holds %d entries, want 1", len(zr.File))
+	}
+	return zr.File[0].Open()
+}
+
+// decompressedNZB reads a decompressed NZB; close releases the decoder and
+// the file.
+type decompressedNZB struct {
+	io.Reader
+	close func() error
+}
+
+func (d *decompressedNZB) Close() error { return d.close() }
+
+// uploadRequest builds a POST request to target with the NZB read from r in
+// the multipart field, as a file called name. The NZB is streamed rather
+// than read into memory, and r is closed once sent.
+func uploadRequest(ctx context.Context, target, field, name string, r io.ReadCloser) (*http.Request, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		defer func() { _ = r.Close() }()
+		part, err := mw.CreateFormFile(field, name)
+		if err == nil {
+			_, err = io.Copy(part, r)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, pr)
+	if err != nil {
+		_ = pr.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req, nil
+}
+
+// do sends req and returns the response body, failing on a non-2xx status.
+func do(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(body[:min(len(body), 512)])))
+	}
+	return body, nil
+}
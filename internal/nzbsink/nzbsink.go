@@ -0,0 +1,90 @@
+// Package nzbsink delivers generated NZBs to destinations besides the output
+// directory: HTTP endpoints, download clients and other directories.
+package nzbsink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/javi11/postie/internal/config"
+)
+
+// Sink delivers NZB files to one destination.
+type Sink interface {
+	// Name identifies the sink, and its delivery state, in the queue.
+	Name() string
+	// Deliver sends the NZB at nzbPath. It is retried on error, so it must be
+	// safe to call again for the same file.
+	Deliver(ctx context.Context, nzbPath string) error
+}
+
+// New returns the sink configured by cfg.
+func New(cfg config.NzbSinkConfig) (Sink, error) {
+	switch cfg.Type {
+	case config.NzbSinkTypeHTTP:
+		return &httpSink{cfg: cfg, client: http.DefaultClient}, nil
+	case config.NzbSinkTypeSABnzbd:
+		return &sabnzbdSink{cfg: cfg, client: http.DefaultClient}, nil
+	case config.NzbSinkTypeNZBGet:
+		return &nzbgetSink{cfg: cfg, client: http.DefaultClient}, nil
+	case config.NzbSinkTypeDirectory:
+		return &directorySink{cfg: cfg}, nil
+	default:
+		return nil, fmt.Errorf("unsupported NZB sink type: %q", cfg.Type)
+	}
+}
+
+// directorySink copies NZBs into each of its directories.
+type directorySink struct {
+	cfg config.NzbSinkConfig
+}
+
+func (s *directorySink) Name() string { return s.cfg.SinkName() }
+
+func (s *directorySink) Deliver(ctx context.Context, nzbPath string) error {
+	for _, dir := range s.cfg.Directories {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := copyFile(nzbPath, filepath.Join(dir, filepath.Base(nzbPath))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile copies src to dst through a temp file and a rename, so a consumer
+// watching the directory never picks up a partial NZB.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("error opening NZB: %w", err)
+	}
+	defer func() { _ = in.Close() }()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("error creating directory: %w", err)
+	}
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("error creating %s: %w", dst, err)
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("error copying NZB to %s: %w", dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package nzbsink
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/javi11/postie/internal/config"
+)
+
+const testNzb = `<?xml version="1.0" encoding="utf-8" ?><nzb></nzb>`
+
+func writeTestNzb(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "job.nzb")
+	if err := os.WriteFile(path, []byte(testNzb), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readUpload returns the name and content of the file in a multipart field.
+func readUpload(t *testing.T, r *http.Request, field string) (string, string) {
+	t.Helper()
+	f, header, err := r.FormFile(field)
+	if err != nil {
+		t.Errorf("FormFile(%q): %v", field, err)
+		return "", ""
+	}
+	defer func() { _ = f.Close() }()
+	data, _ := io.ReadAll(f)
+	return header.Filename, string(data)
+}
+
+func deliver(t *testing.T, cfg config.NzbSinkConfig, nzbPath string) error {
+	t.Helper()
+	sink, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if sink.Name() != cfg.SinkName() {
+		t.Errorf("Name() = %q, want %q", sink.Name(), cfg.SinkName())
+	}
+	return sink.Deliver(context.Background(), nzbPath)
+}
+
+func TestHTTPSink(t *testing.T) {
+	nzbPath := writeTestNzb(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Token"); got != "secret" {
+			t.Errorf("X-Token = %q, want secret", got)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "u" || pass != "p" {
+			t.Errorf("basic auth = %q %q %v", user, pass, ok)
+		}
+		name, data := readUpload(t, r, "file")
+		if name != "job.nzb" || data != testNzb {
+			t.Errorf("upload = %q %q", name, data)
+		}
+	}))
+	defer srv.Close()
+
+	err := deliver(t, config.NzbSinkConfig{
+		Type:      config.NzbSinkTypeHTTP,
+		URL:       srv.URL,
+		Headers:   map[string]string{"X-Token": "secret"},
+		Username:  "u",
+		Password:  "p",
+		FormField: "file",
+	}, nzbPath)
+	if err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+}
+
+func TestHTTPSink_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "indexer down", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := deliver(t, config.NzbSinkConfig{Type: config.NzbSinkTypeHTTP, URL: srv.URL}, writeTestNzb(t))
+	if err == nil {
+		t.Fatal("expected an error for a 502 response")
+	}
+}
+
+func TestSABnzbdSink(t *testing.T) {
+	nzbPath := writeTestNzb(t)
+	status := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/sabnzbd/api" || q.Get("mode") != "addfile" || q.Get("apikey") != "key" || q.Get("cat") != "movies" {
+			t.Errorf("request = %s", r.URL)
+		}
+		if name, data := readUpload(t, r, "name"); name != "job.nzb" || data != testNzb {
+			t.Errorf("upload = %q %q", name, data)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": status, "error": "bad nzb"})
+	}))
+	defer srv.Close()
+
+	cfg := config.NzbSinkConfig{Type: config.NzbSinkTypeSABnzbd, URL: srv.URL + "/sabnzbd/", APIKey: "key", Category: "movies"}
+	if err := deliver(t, cfg, nzbPath); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	status = false
+	if err := deliver(t, cfg, nzbPath); err == nil {
+		t.Error("expected an error when SABnzbd rejects the NZB")
+	}
+}
+
+func TestNZBGetSink(t *testing.T) {
+	nzbPath := writeTestNzb(t)
+	result := 42
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jsonrpc" {
+			t.Errorf("path = %s, want /jsonrpc", r.URL.Path)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "nzbget" || pass != "tegbzn" {
+			t.Errorf("basic auth = %q %q %v", user, pass, ok)
+		}
+		var req struct {
+			Method string `json:"method"`
+			Params []any  `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		content, _ := base64.StdEncoding.DecodeString(req.Params[1].(string))
+		if req.Method != "append" || req.Params[0] != "job.nzb" || string(content) != testNzb || req.Params[2] != "tv" {
+			t.Errorf("request = %+v", req)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer srv.Close()
+
+	cfg := config.NzbSinkConfig{Type: config.NzbSinkTypeNZBGet, URL: srv.URL, Username: "nzbget", Password: "tegbzn", Category: "tv"}
+	if err := deliver(t, cfg, nzbPath); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	result = 0
+	if err := deliver(t, cfg, nzbPath); err == nil {
+		t.Error("expected an error when NZBGet rejects the NZB")
+	}
+}
+
+func TestDownloadClientSinks_DecompressNZB(t *testing.T) {
+	dir := t.TempDir()
+	writeCompressed := func(name string, newWriter func(io.Writer) io.WriteCloser) string {
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := newWriter(f)
+		if _, err := io.WriteString(w, testNzb); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	paths := []string{
+		writeCompressed("job.nzb.zst", func(w io.Writer) io.WriteCloser {
+			zw, _ := zstd.NewWriter(w)
+			return zw
+		}),
+		writeCompressed("job.nzb.br", func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) }),
+		writeCompressed("job.nzb.zip", func(w io.Writer) io.WriteCloser {
+			zw := zip.NewWriter(w)
+			entry, _ := zw.Create("job.nzb")
+			return zipEntry{Writer: entry, zw: zw}
+		}),
+	}
+
+	sab := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name, data := readUpload(t, r, "name"); name != "job.nzb" || data != testNzb {
+			t.Errorf("SABnzbd upload = %q %q", name, data)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": true})
+	}))
+	defer sab.Close()
+
+	nzbget := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []any `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		content, _ := base64.StdEncoding.DecodeString(req.Params[1].(string))
+		if req.Params[0] != "job.nzb" || string(content) != testNzb {
+			t.Errorf("NZBGet append = %v %q", req.Params[0], content)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": 1})
+	}))
+	defer nzbget.Close()
+
+	for _, path := range paths {
+		if err := deliver(t, config.NzbSinkConfig{Type: config.NzbSinkTypeSABnzbd, URL: sab.URL, APIKey: "key"}, path); err != nil {
+			t.Errorf("SABnzbd Deliver(%s): %v", filepath.Base(path), err)
+		}
+		if err := deliver(t, config.NzbSinkConfig{Type: config.NzbSinkTypeNZBGet, URL: nzbget.URL}, path); err != nil {
+			t.Errorf("NZBGet Deliver(%s): %v", filepath.Base(path), err)
+		}
+	}
+}
+
+// zipEntry writes a zip entry and closes its archive on Close.
+type zipEntry struct {
+	io.Writer
+	zw *zip.Writer
+}
+
+func (z zipEntry) Close() error { return z.zw.Close() }
+
+func TestDirectorySink(t *testing.T) {
+	nzbPath := writeTestNzb(t)
+	root := t.TempDir()
+	dirs := []string{filepath.Join(root, "a"), filepath.Join(root, "b", "c")}
+
+	cfg := config.NzbSinkConfig{Name: "mirrors", Type: config.NzbSinkTypeDirectory, Directories: dirs}
+	for range 2 {
+		if err := deliver(t, cfg, nzbPath); err != nil {
+			t.Fatalf("Deliver: %v", err)
+		}
+	}
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, "job.nzb"))
+		if err != nil || string(data) != testNzb {
+			t.Errorf("%s: data = %q, err = %v", dir, data, err)
+		}
+	}
+}
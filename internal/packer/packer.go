@@ -0,0 +1,255 @@
+// Package packer bundles a job's files into fixed-size volumes before they are
+// posted: a plain split (.001, .002, ...) or an AES-256 encrypted ZIP cut into
+// the same volumes. Posting a folder of many small files as a few volumes cuts
+// the article count, and encryption keeps the contents opaque on the server.
+package packer
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/javi11/postie/internal/config"
+)
+
+// markerName is the file, next to the volumes, recording a finished pack so a
+// retried job posts the same volumes (and password) instead of packing again.
+const markerName = "pack.json"
+
+// Entry is a file to pack.
+type Entry struct {
+	// Path is the file on disk.
+	Path string
+	// Name is the slash-separated path of the file inside the archive.
+	Name string
+}
+
+// Result describes the volumes written by Pack.
+type Result struct {
+	Volumes  []string `json:"volumes"`
+	Password string   `json:"password,omitempty"`
+}
+
+// Pack writes entries into volumes of volumeSize bytes under dir, named after
+// name:
+//   - split with a single entry cuts the file itself: name.001, name.002, ...
+//   - split with several entries cuts an uncompressed tar: name.tar.001, ...
+//   - zip cuts an AES-256 encrypted ZIP: name.zip.001, ...
+//
+// password is only used by zip. When dir already holds a finished pack, its
+// volumes and password are returned without packing again.
+func Pack(ctx context.Context, entries []Entry, dir, name string, mode config.PackingMode, volumeSize int64, password string) (Result, error) {
+	if len(entries) == 0 {
+		return Result{}, fmt.Errorf("packer: no files to pack")
+	}
+	if volumeSize <= 0 {
+		return Result{}, fmt.Errorf("packer: invalid volume size %d", volumeSize)
+	}
+	if mode == config.PackingModeZip && password == "" {
+		return Result{}, fmt.Errorf("packer: zip packing needs a password")
+	}
+
+	if res, ok := load(dir); ok {
+		return res, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Result{}, fmt.Errorf("packer: create %s: %w", dir, err)
+	}
+
+	var (
+		base  string
+		write func(w io.Writer) error
+	)
+	switch {
+	case mode == config.PackingModeSplit && len(entries) == 1:
+		base = name
+		write = func(w io.Writer) error { return copyFile(ctx, w, entries[0].Path) }
+	case mode == config.PackingModeSplit:
+		base = name + ".tar"
+		write = func(w io.Writer) error { return writeTar(ctx, w, entries) }
+	case mode == config.PackingModeZip:
+		base = name + ".zip"
+		write = func(w io.Writer) error { return writeZip(ctx, w, entries, password) }
+	default:
+		return Result{}, fmt.Errorf("packer: unsupported mode %q", mode)
+	}
+
+	vw := &volumeWriter{base: filepath.Join(dir, base), size: volumeSize}
+	err := write(vw)
+	if cerr := vw.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	if len(vw.paths) == 0 {
+		return Result{}, fmt.Errorf("packer: nothing to pack in %s", name)
+	}
+
+	res := Result{Volumes: vw.paths}
+	if mode == config.PackingModeZip {
+		res.Password = password
+	}
+	if err := save(dir, res); err != nil {
+		return Result{}, err
+	}
+	return res, nil
+}
+
+// Forget removes the record of a finished pack from dir, keeping the volumes.
+func Forget(dir string) error {
+	if err := os.Remove(filepath.Join(dir, markerName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// NewPassword returns a random password with 128 bits of entropy.
+func NewPassword() string {
+	return rand.Text()
+}
+
+// load returns the pack recorded in dir when all of its volumes still exist.
+func load(dir string) (Result, bool) {
+	data, err := os.ReadFile(filepath.Join(dir, markerName))
+	if err != nil {
+		return Result{}, false
+	}
+	var res Result
+	if err := json.Unmarshal(data, &res); err != nil || len(res.Volumes) == 0 {
+		return Result{}, false
+	}
+	for _, v := range res.Volumes {
+		if _, err := os.Stat(v); err != nil {
+			return Result{}, false
+		}
+	}
+	return res, true
+}
+
+// save records a finished pack in dir.
+func save(dir string, res Result) error {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, markerName), data, 0600); err != nil {
+		return fmt.Errorf("packer: record pack: %w", err)
+	}
+	return nil
+}
+
+// copyFile copies the file at path to w.
+func copyFile(ctx context.Context, w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("packer: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(w, &ctxReader{ctx: ctx, r: f}); err != nil {
+		return fmt.Errorf("packer: copy %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeTar writes entries to w as an uncompressed tar archive.
+func writeTar(ctx context.Context, w io.Writer, entries []Entry) error {
+	tw := tar.NewWriter(w)
+	for _, e := range entries {
+		info, err := os.Stat(e.Path)
+		if err != nil {
+			return fmt.Errorf("packer: %w", err)
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return fmt.Errorf("packer: tar header for %s: %w", e.Path, err)
+		}
+		hdr.Name = e.Name
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("packer: tar header for %s: %w", e.Path, err)
+		}
+		if err := copyFile(ctx, tw, e.Path); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// ctxReader stops a copy once its context is cancelled.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// volumeWriter writes a stream into base.001, base.002, ... of size bytes
+// each; the last volume holds the remainder.
+type volumeWriter struct {
+	base    string
+	size    int64
+	f       *os.File
+	written int64
+	paths   []string
+}
+
+func (w *volumeWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if w.f == nil || w.written == w.size {
+			if err := w.next(); err != nil {
+				return n, err
+			}
+		}
+		chunk := int(min(int64(len(p)), w.size-w.written))
+		m, err := w.f.Write(p[:chunk])
+		n += m
+		w.written += int64(m)
+		if err != nil {
+			return n, fmt.Errorf("packer: write volume: %w", err)
+		}
+		p = p[chunk:]
+	}
+	return n, nil
+}
+
+// next closes the current volume and opens the following one.
+func (w *volumeWriter) next() error {
+	if err := w.Close(); err != nil {
+		return err
+	}
+	path := fmt.Sprintf("%s.%03d", w.base, len(w.paths)+1)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("packer: create volume: %w", err)
+	}
+	w.f = f
+	w.written = 0
+	w.paths = append(w.paths, path)
+	return nil
+}
+
+// Close closes the current volume.
+func (w *volumeWriter) Close() error {
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	if err != nil {
+		return fmt.Errorf("packer: close volume: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package packer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/javi11/postie/internal/config"
+)
+
+func writeTestFile(t *testing.T, dir, name string, size int) (string, []byte) {
+	t.Helper()
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+// joinVolumes concatenates volumes, checking every one but the last is full.
+func joinVolumes(t *testing.T, volumes []string, size int64) []byte {
+	t.Helper()
+	var all []byte
+	for i, v := range volumes {
+		data, err := os.ReadFile(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i < len(volumes)-1 && int64(len(data)) != size {
+			t.Errorf("volume %s is %d bytes, want %d", v, len(data), size)
+		}
+		all = append(all, data...)
+	}
+	return all
+}
+
+func TestPack_SplitSingleFile(t *testing.T) {
+	src := t.TempDir()
+	path, data := writeTestFile(t, src, "movie.mkv", 2500)
+	dir := filepath.Join(t.TempDir(), "pack")
+
+	res, err := Pack(context.Background(), []Entry{{Path: path, Name: "movie.mkv"}}, dir, "movie.mkv", config.PackingModeSplit, 1000, "")
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	want := []string{filepath.Join(dir, "movie.mkv.001"), filepath.Join(dir, "movie.mkv.002"), filepath.Join(dir, "movie.mkv.003")}
+	if len(res.Volumes) != len(want) {
+		t.Fatalf("volumes = %v, want %v", res.Volumes, want)
+	}
+	for i := range want {
+		if res.Volumes[i] != want[i] {
+			t.Errorf("volume %d = %s, want %s", i, res.Volumes[i], want[i])
+		}
+	}
+	if res.Password != "" {
+		t.Errorf("split pack has password %q", res.Password)
+	}
+	if got := joinVolumes(t, res.Volumes, 1000); !bytes.Equal(got, data) {
+		t.Error("joined volumes differ from the source file")
+	}
+}
+
+func TestPack_SplitTar(t *testing.T) {
+	src := t.TempDir()
+	a, aData := writeTestFile(t, src, "a.bin", 700)
+	b, bData := writeTestFile(t, src, "b.bin", 1300)
+	dir := filepath.Join(t.TempDir(), "pack")
+
+	res, err := Pack(context.Background(), []Entry{{Path: a, Name: "set/a.bin"}, {Path: b, Name: "set/sub/b.bin"}}, dir, "set", config.PackingModeSplit, 1024, "")
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if filepath.Base(res.Volumes[0]) != "set.tar.001" {
+		t.Errorf("first volume = %s, want set.tar.001", res.Volumes[0])
+	}
+
+	tr := tar.NewReader(bytes.NewReader(joinVolumes(t, res.Volumes, 1024)))
+	want := map[string][]byte{"set/a.bin": aData, "set/sub/b.bin": bData}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		got, _ := io.ReadAll(tr)
+		if !bytes.Equal(got, want[hdr.Name]) {
+			t.Errorf("tar entry %s differs", hdr.Name)
+		}
+		delete(want, hdr.Name)
+	}
+	if len(want) != 0 {
+		t.Errorf("missing tar entries: %v", want)
+	}
+}
+
+func TestPack_ZipAES(t *testing.T) {
+	src := t.TempDir()
+	a, aData := writeTestFile(t, src, "a.bin", 100)
+	b, bData := writeTestFile(t, src, "b.bin", 5000)
+	dir := filepath.Join(t.TempDir(), "pack")
+	const password = "correct horse"
+
+	res, err := Pack(context.Background(), []Entry{{Path: a, Name: "set/a.bin"}, {Path: b, Name: "set/b.bin"}}, dir, "set", config.PackingModeZip, 2048, password)
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	if res.Password != password {
+		t.Errorf("password = %q, want %q", res.Password, password)
+	}
+	if filepath.Base(res.Volumes[0]) != "set.zip.001" {
+		t.Errorf("first volume = %s, want set.zip.001", res.Volumes[0])
+	}
+
+	archive := joinVolumes(t, res.Volumes, 2048)
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("zip: %v", err)
+	}
+	want := map[string][]byte{"set/a.bin": aData, "set/b.bin": bData}
+	if len(zr.File) != len(want) {
+		t.Fatalf("zip has %d entries, want %d", len(zr.File), len(want))
+	}
+	for _, f := range zr.File {
+		if f.Method != methodWinZipAES || f.Flags&0x1 == 0 {
+			t.Errorf("%s: method %d flags %#x, want an AES encrypted entry", f.Name, f.Method, f.Flags)
+		}
+		raw, err := f.OpenRaw()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(raw)
+		if got := decryptAES(t, data, password); !bytes.Equal(got, want[f.Name]) {
+			t.Errorf("%s: decrypted content differs", f.Name)
+		}
+	}
+}
+
+// decryptAES reverses writeAESEntry, checking the password verifier and MAC.
+func decryptAES(t *testing.T, data []byte, password string) []byte {
+	t.Helper()
+	salt, pwv := data[:aesSaltLen], data[aesSaltLen:aesSaltLen+aesPwvLen]
+	body, mac := data[aesSaltLen+aesPwvLen:len(data)-aesMACLen], data[len(data)-aesMACLen:]
+
+	keys, err := pbkdf2.Key(sha1.New, password, salt, aesIterations, 2*aesKeyLen+aesPwvLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(keys[2*aesKeyLen:], pwv) {
+		t.Fatal("password verifier mismatch")
+	}
+	h := hmac.New(sha1.New, keys[aesKeyLen:2*aesKeyLen])
+	h.Write(body)
+	if !bytes.Equal(h.Sum(nil)[:aesMACLen], mac) {
+		t.Fatal("authentication code mismatch")
+	}
+
+	aw, err := newAESWriter(io.Discard, keys[:aesKeyLen], keys[aesKeyLen:2*aesKeyLen])
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := make([]byte, len(body))
+	aw.xorKeyStream(plain, body)
+	return plain
+}
+
+func TestPack_ReusesFinishedPack(t *testing.T) {
+	src := t.TempDir()
+	path, _ := writeTestFile(t, src, "file.bin", 3000)
+	dir := filepath.Join(t.TempDir(), "pack")
+	entries := []Entry{{Path: path, Name: "file.bin"}}
+
+	first, err := Pack(context.Background(), entries, dir, "file.bin", config.PackingModeZip, 1000, "first")
+	if err != nil {
+		t.Fatalf("Pack: %v", err)
+	}
+	second, err := Pack(context.Background(), entries, dir, "file.bin", config.PackingModeZip, 1000, "second")
+	if err != nil {
+		t.Fatalf("Pack again: %v", err)
+	}
+	if second.Password != "first" || len(second.Volumes) != len(first.Volumes) {
+		t.Errorf("retried pack = %+v, want the finished pack %+v", second, first)
+	}
+
+	if err := Forget(dir); err != nil {
+		t.Fatalf("Forget: %v", err)
+	}
+	third, err := Pack(context.Background(), entries, dir, "file.bin", config.PackingModeZip, 1000, "third")
+	if err != nil {
+		t.Fatalf("Pack after Forget: %v", err)
+	}
+	if third.Password != "third" {
+		t.Errorf("password after Forget = %q, want a new pack", third.Password)
+	}
+}
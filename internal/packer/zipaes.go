@@ -0,0 +1,182 @@
+package packer
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+)
+
+// WinZip AES (AE-2) encryption, as read by 7-Zip, WinZip, WinRAR and
+// libarchive. Entries are stored uncompressed: they are mostly media, and the
+// point of packing is fewer articles, not fewer bytes.
+const (
+	methodWinZipAES = 99
+	aesExtraID      = 0x9901
+	aesKeyLen       = 32 // AES-256
+	aesSaltLen      = 16
+	aesPwvLen       = 2
+	aesMACLen       = 10
+	aesIterations   = 1000
+	zipVersionAES   = 51
+)
+
+// writeZip writes entries to w as a ZIP archive encrypted with password.
+func writeZip(ctx context.Context, w io.Writer, entries []Entry, password string) error {
+	zw := zip.NewWriter(w)
+	for _, e := range entries {
+		if err := writeAESEntry(ctx, zw, e, password); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("packer: close zip: %w", err)
+	}
+	return nil
+}
+
+// writeAESEntry adds one encrypted, stored entry to zw.
+func writeAESEntry(ctx context.Context, zw *zip.Writer, e Entry, password string) error {
+	f, err := os.Open(e.Path)
+	if err != nil {
+		return fmt.Errorf("packer: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("packer: %w", err)
+	}
+	size := uint64(info.Size())
+
+	salt := make([]byte, aesSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("packer: salt: %w", err)
+	}
+	keys, err := pbkdf2.Key(sha1.New, password, salt, aesIterations, 2*aesKeyLen+aesPwvLen)
+	if err != nil {
+		return fmt.Errorf("packer: derive key: %w", err)
+	}
+	encKey, macKey, pwv := keys[:aesKeyLen], keys[aesKeyLen:2*aesKeyLen], keys[2*aesKeyLen:]
+
+	// AES extra field: AE-2, vendor "AE", AES-256, actual method stored.
+	extra := make([]byte, 11)
+	binary.LittleEndian.PutUint16(extra[0:], aesExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 7)
+	binary.LittleEndian.PutUint16(extra[4:], 2)
+	copy(extra[6:], "AE")
+	extra[8] = 3
+	binary.LittleEndian.PutUint16(extra[9:], uint16(zip.Store))
+
+	fh := &zip.FileHeader{
+		Name:               e.Name,
+		Method:             methodWinZipAES,
+		Flags:              0x1 | 0x800, // encrypted, UTF-8 name
+		CreatorVersion:     3<<8 | zipVersionAES,
+		ReaderVersion:      zipVersionAES,
+		UncompressedSize64: size,
+		CompressedSize64:   aesSaltLen + aesPwvLen + size + aesMACLen,
+		Extra:              extra,
+	}
+	fh.ModifiedTime, fh.ModifiedDate = msDosTime(info.ModTime())
+	fh.SetMode(info.Mode())
+
+	w, err := zw.CreateRaw(fh)
+	if err != nil {
+		return fmt.Errorf("packer: zip header for %s: %w", e.Path, err)
+	}
+	if _, err := w.Write(salt); err != nil {
+		return fmt.Errorf("packer: write zip: %w", err)
+	}
+	if _, err := w.Write(pwv); err != nil {
+		return fmt.Errorf("packer: write zip: %w", err)
+	}
+
+	ew, err := newAESWriter(w, encKey, macKey)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(ew, &ctxReader{ctx: ctx, r: f})
+	if err != nil {
+		return fmt.Errorf("packer: copy %s: %w", e.Path, err)
+	}
+	if uint64(n) != size {
+		return fmt.Errorf("packer: %s changed size while packing", e.Path)
+	}
+	if _, err := w.Write(ew.mac.Sum(nil)[:aesMACLen]); err != nil {
+		return fmt.Errorf("packer: write zip: %w", err)
+	}
+	return nil
+}
+
+// aesWriter encrypts with AES in WinZip's CTR mode (a little-endian counter
+// starting at 1) and authenticates the ciphertext with HMAC-SHA1.
+type aesWriter struct {
+	w       io.Writer
+	block   cipher.Block
+	mac     hash.Hash
+	counter [aes.BlockSize]byte
+	stream  [aes.BlockSize]byte
+	pos     int
+	buf     []byte
+}
+
+func newAESWriter(w io.Writer, encKey, macKey []byte) (*aesWriter, error) {
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, fmt.Errorf("packer: %w", err)
+	}
+	return &aesWriter{
+		w:     w,
+		block: block,
+		mac:   hmac.New(sha1.New, macKey),
+		pos:   aes.BlockSize,
+	}, nil
+}
+
+func (a *aesWriter) Write(p []byte) (int, error) {
+	if cap(a.buf) < len(p) {
+		a.buf = make([]byte, len(p))
+	}
+	out := a.buf[:len(p)]
+	a.xorKeyStream(out, p)
+	a.mac.Write(out)
+	return a.w.Write(out)
+}
+
+func (a *aesWriter) xorKeyStream(dst, src []byte) {
+	for i := range src {
+		if a.pos == aes.BlockSize {
+			for j := range a.counter {
+				a.counter[j]++
+				if a.counter[j] != 0 {
+					break
+				}
+			}
+			a.block.Encrypt(a.stream[:], a.counter[:])
+			a.pos = 0
+		}
+		dst[i] = src[i] ^ a.stream[a.pos]
+		a.pos++
+	}
+}
+
+// msDosTime converts t to the MS-DOS time and date of a ZIP header.
+func msDosTime(t time.Time) (uint16, uint16) {
+	if t.Year() < 1980 {
+		t = time.Date(1980, 1, 1, 0, 0, 0, 0, time.Local)
+	}
+	dosTime := uint16(t.Hour()<<11 | t.Minute()<<5 | t.Second()/2)
+	dosDate := uint16((t.Year()-1980)<<9 | int(t.Month())<<5 | t.Day())
+	return dosTime, dosDate
+}
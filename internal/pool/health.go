@@ -0,0 +1,290 @@
+package pool
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Health scoring and circuit breaker tuning.
+const (
+	// healthWindow is the number of recent post outcomes kept per provider.
+	healthWindow = 100
+	// healthMinSamples is the number of outcomes needed before a provider can
+	// be quarantined, so a single early failure does not open the breaker.
+	healthMinSamples = 10
+	// healthErrorRateLimit is the error rate within the window that opens the
+	// breaker.
+	healthErrorRateLimit = 0.5
+	// healthStaleLimit is the number of stale-connection errors within the
+	// window that opens the breaker regardless of the error rate.
+	healthStaleLimit = 10
+	// healthProbeSamples is the number of outcomes a half-open provider needs
+	// before its breaker closes or re-opens.
+	healthProbeSamples = 3
+
+	quarantineBase = time.Minute
+	quarantineMax  = 30 * time.Minute
+)
+
+// CircuitState is the circuit breaker state of a provider.
+type CircuitState string
+
+const (
+	// CircuitClosed means the provider is healthy and receives posts.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen means the provider is quarantined until QuarantinedUntil.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen means the quarantine expired and the provider is on
+	// probation: the next evaluation closes or re-opens the breaker based on
+	// the posts it received since.
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// ProviderHealth is a point-in-time health report of one upload provider.
+type ProviderHealth struct {
+	Name             string        `json:"name"`
+	Samples          int           `json:"samples"`
+	ErrorRate        float64       `json:"errorRate"`
+	AvgLatency       time.Duration `json:"avgLatency"`
+	StaleConnErrors  int           `json:"staleConnErrors"`
+	Score            float64       `json:"score"` // 0-100, 0 while quarantined
+	State            CircuitState  `json:"state"`
+	QuarantinedUntil time.Time     `json:"quarantinedUntil"`
+}
+
+type postOutcome struct {
+	latency time.Duration
+	failed  bool
+	stale   bool
+}
+
+type providerHealth struct {
+	outcomes []postOutcome // ring buffer of up to healthWindow outcomes
+	next     int
+	state    CircuitState
+	until    time.Time
+	backoff  time.Duration
+}
+
+func (h *providerHealth) record(o postOutcome) {
+	if len(h.outcomes) < healthWindow {
+		h.outcomes = append(h.outcomes, o)
+		return
+	}
+	h.outcomes[h.next] = o
+	h.next = (h.next + 1) % healthWindow
+}
+
+func (h *providerHealth) report(name string) ProviderHealth {
+	r := ProviderHealth{
+		Name:             name,
+		Samples:          len(h.outcomes),
+		State:            h.state,
+		QuarantinedUntil: h.until,
+	}
+
+	var failed int
+	var latency time.Duration
+	for _, o := range h.outcomes {
+		latency += o.latency
+		if o.failed {
+			failed++
+		}
+		if o.stale {
+			r.StaleConnErrors++
+		}
+	}
+	if r.Samples > 0 {
+		r.ErrorRate = float64(failed) / float64(r.Samples)
+		r.AvgLatency = latency / time.Duration(r.Samples)
+	}
+
+	if h.state != CircuitOpen {
+		r.Score = max(0, 100*(1-r.ErrorRate)-5*float64(r.StaleConnErrors))
+	}
+	return r
+}
+
+// unhealthy reports whether the outcomes warrant quarantine. minSamples lets
+// a half-open provider be judged on fewer posts.
+func (r ProviderHealth) unhealthy(minSamples int) bool {
+	if r.Samples < minSamples {
+		return false
+	}
+	return r.ErrorRate >= healthErrorRateLimit || r.StaleConnErrors >= healthStaleLimit
+}
+
+// Healthy reports whether the provider is out of quarantine and its recent
+// posts are mostly succeeding.
+func (r ProviderHealth) Healthy() bool {
+	return r.State != CircuitOpen && r.Samples > 0 && !r.unhealthy(1)
+}
+
+// HealthTracker keeps a rolling window of post outcomes per provider and runs
+// a circuit breaker on them. Record and Evaluate open the breaker for
+// providers with a high error rate or many stale connections, quarantining
+// them for an exponentially growing backoff, while healthy providers keep
+// receiving posts.
+type HealthTracker struct {
+	mu        sync.Mutex
+	providers map[string]*providerHealth
+	now       func() time.Time
+}
+
+// NewHealthTracker creates an empty tracker.
+func NewHealthTracker() *HealthTracker {
+	return &HealthTracker{
+		providers: make(map[string]*providerHealth),
+		now:       time.Now,
+	}
+}
+
+func (t *HealthTracker) get(name string) *providerHealth {
+	h, ok := t.providers[name]
+	if !ok {
+		h = &providerHealth{state: CircuitClosed}
+		t.providers[name] = h
+	}
+	return h
+}
+
+// Record adds the outcome of one post attempt to name's window and applies
+// the circuit breaker transitions to name, so the breaker trips on the posting
+// path itself and not only where a monitor calls Evaluate.
+func (t *HealthTracker) Record(name string, latency time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h := t.get(name)
+	now := t.now()
+	if h.state == CircuitOpen && !now.Before(h.until) {
+		// The quarantine is over: start probing so this post is judged
+		// as the first one of the probation.
+		t.evaluate(name, h, now)
+	}
+	h.record(postOutcome{
+		latency: latency,
+		failed:  err != nil,
+		stale:   IsStaleConnError(err),
+	})
+	t.evaluate(name, h, now)
+}
+
+// Allowed reports whether name may receive posts, i.e. it is not quarantined.
+func (t *HealthTracker) Allowed(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h, ok := t.providers[name]
+	return !ok || h.state != CircuitOpen || !t.now().Before(h.until)
+}
+
+// Evaluate applies the circuit breaker transitions to the named providers and
+// returns their health.
+func (t *HealthTracker) Evaluate(names []string) []ProviderHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	reports := make([]ProviderHealth, 0, len(names))
+	for _, name := range names {
+		h := t.get(name)
+		t.evaluate(name, h, now)
+		reports = append(reports, h.report(name))
+	}
+	return reports
+}
+
+// evaluate applies the circuit breaker transitions to one provider.
+func (t *HealthTracker) evaluate(name string, h *providerHealth, now time.Time) {
+	switch h.state {
+	case CircuitOpen:
+		if !now.Before(h.until) {
+			// Quarantine over: let posts through again and judge the
+			// provider only on what happens from now on.
+			h.state = CircuitHalfOpen
+			h.outcomes = h.outcomes[:0]
+			h.next = 0
+			slog.Info("Provider quarantine expired, probing", "provider", name)
+		}
+	case CircuitHalfOpen:
+		r := h.report(name)
+		switch {
+		case r.unhealthy(healthProbeSamples):
+			t.open(name, h, now, r)
+		case r.Samples >= healthProbeSamples:
+			h.state = CircuitClosed
+			h.until = time.Time{}
+			h.backoff = 0
+			slog.Info("Provider healthy again, closing circuit", "provider", name)
+		}
+	default:
+		if r := h.report(name); r.unhealthy(healthMinSamples) {
+			t.open(name, h, now, r)
+		}
+	}
+}
+
+// open quarantines a provider, doubling the backoff on every consecutive
+// quarantine up to quarantineMax.
+func (t *HealthTracker) open(name string, h *providerHealth, now time.Time, r ProviderHealth) {
+	if h.backoff == 0 {
+		h.backoff = quarantineBase
+	} else {
+		h.backoff = min(h.backoff*2, quarantineMax)
+	}
+	h.state = CircuitOpen
+	h.until = now.Add(h.backoff)
+
+	slog.Warn("Quarantining unhealthy provider",
+		"provider", name,
+		"errorRate", r.ErrorRate,
+		"staleConnErrors", r.StaleConnErrors,
+		"avgLatency", r.AvgLatency,
+		"until", h.until)
+}
+
+// Health returns the current health of the named providers without changing
+// breaker state.
+func (t *HealthTracker) Health(names []string) []ProviderHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	reports := make([]ProviderHealth, 0, len(names))
+	for _, name := range names {
+		reports = append(reports, t.get(name).report(name))
+	}
+	return reports
+}
+
+// IsStaleConnError reports whether err looks like a stale pooled connection:
+// the remote server silently closed the TCP socket (broken pipe / connection
+// reset) or the connection went half-open and the read deadline fired before
+// any response arrived (i/o timeout). In all of these cases the pool discards
+// the bad connection, so retrying picks a fresh one; many of them from one
+// provider point at a flaky link.
+//
+// Callers short-circuit true context cancellation / deadline exceeded before
+// calling this, so any net.Error timeout reaching here is a per-socket read
+// deadline rather than the per-article envelope.
+func IsStaleConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "broken pipe") ||
+		strings.Contains(msg, "connection reset by peer") ||
+		strings.Contains(msg, "i/o timeout")
+}
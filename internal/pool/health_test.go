@@ -0,0 +1,125 @@
+package pool
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHealthTracker_QuarantinesAndRecovers(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	ht := NewHealthTracker()
+	ht.now = func() time.Time { return now }
+	names := []string{"flaky", "good"}
+
+	for range healthMinSamples {
+		ht.Record("flaky", 50*time.Millisecond, errors.New("441 posting failed"))
+		ht.Record("good", 20*time.Millisecond, nil)
+	}
+
+	reports := ht.Evaluate(names)
+	if reports[0].State != CircuitOpen || reports[0].Score != 0 {
+		t.Fatalf("flaky provider not quarantined: %+v", reports[0])
+	}
+	if reports[1].State != CircuitClosed || !reports[1].Healthy() {
+		t.Fatalf("good provider affected: %+v", reports[1])
+	}
+	if ht.Allowed("flaky") || !ht.Allowed("good") {
+		t.Fatal("Allowed does not reflect the quarantine")
+	}
+
+	// Quarantine expires: half-open and allowed again.
+	now = reports[0].QuarantinedUntil
+	if r := ht.Evaluate(names)[0]; r.State != CircuitHalfOpen || r.Samples != 0 {
+		t.Fatalf("expected a fresh half-open window, got %+v", r)
+	}
+	if !ht.Allowed("flaky") {
+		t.Fatal("half-open provider must receive posts")
+	}
+
+	// Failing probes re-open with a doubled backoff.
+	for range healthProbeSamples {
+		ht.Record("flaky", time.Second, errors.New("441 posting failed"))
+	}
+	r := ht.Evaluate(names)[0]
+	if r.State != CircuitOpen || r.QuarantinedUntil.Sub(now) != 2*quarantineBase {
+		t.Fatalf("expected re-quarantine for %v, got %+v", 2*quarantineBase, r)
+	}
+
+	// Successful probes close the breaker.
+	now = r.QuarantinedUntil
+	ht.Evaluate(names)
+	for range healthProbeSamples {
+		ht.Record("flaky", 10*time.Millisecond, nil)
+	}
+	if r := ht.Evaluate(names)[0]; r.State != CircuitClosed || r.Score != 100 {
+		t.Fatalf("expected the breaker to close, got %+v", r)
+	}
+}
+
+func TestHealthTracker_RecordAppliesBreaker(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	ht := NewHealthTracker()
+	ht.now = func() time.Time { return now }
+	names := []string{"flaky"}
+
+	// Without Evaluate, as in CLI runs, recording opens the breaker...
+	for range healthMinSamples {
+		ht.Record("flaky", time.Second, errors.New("441 posting failed"))
+	}
+	r := ht.Health(names)[0]
+	if r.State != CircuitOpen || ht.Allowed("flaky") {
+		t.Fatalf("expected the breaker to open on Record, got %+v", r)
+	}
+
+	// ...and once the quarantine is over, the probes close it again.
+	now = r.QuarantinedUntil
+	for range healthProbeSamples {
+		ht.Record("flaky", 10*time.Millisecond, nil)
+	}
+	if r := ht.Health(names)[0]; r.State != CircuitClosed || r.Samples != healthProbeSamples {
+		t.Fatalf("expected the probes to close the breaker, got %+v", r)
+	}
+}
+
+func TestHealthTracker_StaleConnections(t *testing.T) {
+	ht := NewHealthTracker()
+
+	// Mostly successful, but enough stale connections to quarantine.
+	for range 30 {
+		ht.Record("p", 10*time.Millisecond, nil)
+	}
+	for range healthStaleLimit {
+		ht.Record("p", 10*time.Millisecond, syscall.ECONNRESET)
+	}
+
+	r := ht.Evaluate([]string{"p"})[0]
+	if r.StaleConnErrors != healthStaleLimit || r.State != CircuitOpen {
+		t.Fatalf("expected quarantine on stale connections, got %+v", r)
+	}
+}
+
+func TestHealthTracker_NeedsMinSamples(t *testing.T) {
+	ht := NewHealthTracker()
+	ht.Record("p", time.Millisecond, errors.New("boom"))
+
+	if r := ht.Evaluate([]string{"p"})[0]; r.State != CircuitClosed {
+		t.Fatalf("a single failure must not open the breaker, got %+v", r)
+	}
+}
+
+func TestHealthTracker_Window(t *testing.T) {
+	ht := NewHealthTracker()
+	for range healthWindow {
+		ht.Record("p", time.Millisecond, errors.New("boom"))
+	}
+	for range healthWindow {
+		ht.Record("p", time.Millisecond, nil)
+	}
+
+	r := ht.Health([]string{"p"})[0]
+	if r.Samples != healthWindow || r.ErrorRate != 0 {
+		t.Fatalf("old outcomes not rolled out of the window: %+v", r)
+	}
+}
@@ -38,31 +38,22 @@ func New(cfg *config.ConfigData) (*Manager, error) {
 
 	slog.Info("Creating NNTP connection pool manager")
 
-	// Create the upload pool
-	uploadPool, err := cfg.GetUploadPool()
+	uploadPool, verifyPool, err := newPools(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create upload pool: %w", err)
-	}
-
-	// Create the verify pool (verify-role servers, or falls back to upload servers)
-	verifyPool, err := cfg.GetVerifyPool()
-	if err != nil {
-		// If verify pool fails, fall back to upload pool
-		slog.Warn("Failed to create dedicated verify pool, will use upload pool for article verification", "error", err)
-		verifyPool = uploadPool
+		return nil, err
 	}
 
 	// Log pool configuration
 	uploadServers := cfg.GetUploadServers()
 	verifyServers := cfg.GetVerifyServers()
-	uploadHost := ""
-	if len(uploadServers) > 0 {
-		uploadHost = uploadServers[0].Host
+	uploadHosts := make([]string, 0, len(uploadServers))
+	for _, s := range uploadServers {
+		uploadHosts = append(uploadHosts, s.Host)
 	}
 	slog.Info("NNTP connection pools configured",
 		"upload_servers", len(uploadServers),
 		"verify_servers", len(verifyServers),
-		"upload_host", uploadHost,
+		"upload_hosts", uploadHosts,
 		"using_dedicated_verify_pool", len(verifyServers) > 0)
 
 	manager := &Manager{
@@ -76,6 +67,28 @@ func New(cfg *config.ConfigData) (*Manager, error) {
 	return manager, nil
 }
 
+// newPools creates the upload pool, with one connection pool per upload
+// server, and the verify pool, with one connection pool per verify server.
+// Without verify-role servers the upload pool is also used for verification.
+func newPools(cfg *config.ConfigData) (NNTPClient, NNTPClient, error) {
+	uploadPool, err := NewUploadClient(cfg.GetUploadServers(), cfg.GetDatabaseConfig().ProviderUsagePath())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create upload pool: %w", err)
+	}
+
+	if len(cfg.GetVerifyServers()) == 0 {
+		return uploadPool, uploadPool, nil
+	}
+
+	verifyPool, err := NewUploadClient(cfg.GetVerifyServers(), "")
+	if err != nil {
+		// If verify pool fails, fall back to upload pool
+		slog.Warn("Failed to create dedicated verify pool, will use upload pool for article verification", "error", err)
+		return uploadPool, uploadPool, nil
+	}
+	return uploadPool, verifyPool, nil
+}
+
 // GetUploadPool returns the NNTP connection pool for posting articles.
 func (m *Manager) GetUploadPool() NNTPClient {
 	m.mu.RLock()
@@ -135,14 +148,9 @@ func (m *Manager) UpdateConfig(newCfg *config.ConfigData) error {
 	// before overwriting to avoid leaks (defensive — should not normally
 	// happen when m.uploadPool == nil).
 	if m.uploadPool == nil {
-		uploadPool, err := newCfg.GetUploadPool()
+		uploadPool, verifyPool, err := newPools(newCfg)
 		if err != nil {
-			return fmt.Errorf("failed to create upload pool: %w", err)
-		}
-		verifyPool, err := newCfg.GetVerifyPool()
-		if err != nil {
-			slog.Warn("Failed to create dedicated verify pool, will use upload pool", "error", err)
-			verifyPool = uploadPool
+			return err
 		}
 
 		if m.verifyPool != nil && m.verifyPool != m.uploadPool {
@@ -182,7 +190,7 @@ func (m *Manager) UpdateConfig(newCfg *config.ConfigData) error {
 		}
 	case hasNewVerify && !hasOldVerify:
 		// New config introduces verify servers — create dedicated verify pool
-		verifyPool, err := newCfg.GetVerifyPool()
+		verifyPool, err := NewUploadClient(newVerifyServers, "")
 		if err != nil {
 			slog.Warn("Failed to create dedicated verify pool, will use upload pool", "error", err)
 			m.verifyPool = m.uploadPool
@@ -239,8 +247,13 @@ func (m *Manager) diffProviders(pool NNTPClient, oldServers, newServers []config
 	for key, newSrv := range newMap {
 		oldSrv, exists := oldMap[key]
 		if !exists || serverConfigChanged(oldSrv, newSrv) {
-			provider := config.ServerConfigToProvider(newSrv)
-			if err := pool.AddProvider(provider); err != nil {
+			var err error
+			if uc, ok := pool.(*UploadClient); ok {
+				err = uc.AddServer(newSrv)
+			} else {
+				err = pool.AddProvider(config.ServerConfigToProvider(newSrv))
+			}
+			if err != nil {
 				return fmt.Errorf("failed to add provider %q to %s pool: %w", key, poolName, err)
 			}
 			slog.Info("Added provider to pool", "pool", poolName, "provider", key)
@@ -286,6 +299,9 @@ func serverConfigChanged(a, b config.ServerConfig) bool {
 	if a.ProxyURL != b.ProxyURL {
 		return true
 	}
+	if a.CAFile != b.CAFile || a.ClientCert != b.ClientCert || a.ClientKey != b.ClientKey || a.PinnedSHA256 != b.PinnedSHA256 {
+		return true
+	}
 	aEnabled := a.Enabled == nil || *a.Enabled
 	bEnabled := b.Enabled == nil || *b.Enabled
 	if aEnabled != bEnabled {
@@ -294,6 +310,15 @@ func serverConfigChanged(a, b config.ServerConfig) bool {
 	if a.Role != b.Role {
 		return true
 	}
+	if a.UploadWeight != b.UploadWeight {
+		return true
+	}
+	if a.ThrottleRate != b.ThrottleRate || a.DailyQuotaBytes != b.DailyQuotaBytes || a.QuotaResetTime != b.QuotaResetTime {
+		return true
+	}
+	if a.Transport != b.Transport {
+		return true
+	}
 	return false
 }
 
@@ -313,6 +338,30 @@ func (m *Manager) GetMetrics() (nntppool.ClientStats, error) {
 	return m.uploadPool.Stats(), nil
 }
 
+// ProviderHealth returns the health of the upload providers, or nil when the
+// upload pool does not track health.
+func (m *Manager) ProviderHealth() []ProviderHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if uc, ok := m.uploadPool.(*UploadClient); ok {
+		return uc.Health()
+	}
+	return nil
+}
+
+// EvaluateProviderHealth runs the upload providers' circuit breakers and
+// returns their health, or nil when the upload pool does not track health.
+func (m *Manager) EvaluateProviderHealth() []ProviderHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if uc, ok := m.uploadPool.(*UploadClient); ok {
+		return uc.EvaluateHealth()
+	}
+	return nil
+}
+
 // Close gracefully shuts down the connection pool manager.
 // This should be called during application shutdown.
 func (m *Manager) Close() error {
@@ -0,0 +1,206 @@
+package pool
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExhausted is returned by UploadClient.PostYenc when every upload
+// provider has used up its daily quota. The error is a *QuotaExhaustedError,
+// which carries the time posting can resume.
+var ErrQuotaExhausted = errors.New("all upload providers have exhausted their daily quota")
+
+// QuotaExhaustedError is the ErrQuotaExhausted returned by
+// UploadClient.PostYenc. ResetAt is the earliest quota reset of the upload
+// providers.
+type QuotaExhaustedError struct {
+	ResetAt time.Time
+}
+
+func (e *QuotaExhaustedError) Error() string {
+	return fmt.Sprintf("%s (next reset at %s)", ErrQuotaExhausted, e.ResetAt.Format(time.RFC3339))
+}
+
+func (e *QuotaExhaustedError) Unwrap() error { return ErrQuotaExhausted }
+
+// quotaSaveInterval bounds how often usage is flushed to disk while posting.
+// Pauses, resets and Close always flush.
+const quotaSaveInterval = 30 * time.Second
+
+// QuotaUsage is the persisted daily quota usage of one provider.
+type QuotaUsage struct {
+	Used    int64     `json:"used"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// QuotaTracker counts the bytes posted to each provider against its daily
+// quota. Usage is kept per provider name, so it survives a provider being
+// re-added after a config change, and is persisted to a JSON file so it
+// survives restarts. An empty path keeps usage in memory only.
+type QuotaTracker struct {
+	mu       sync.Mutex
+	path     string
+	usage    map[string]*QuotaUsage
+	lastSave time.Time
+	now      func() time.Time
+}
+
+// NewQuotaTracker creates a tracker persisted at path, loading any usage
+// already recorded there.
+func NewQuotaTracker(path string) (*QuotaTracker, error) {
+	t := &QuotaTracker{
+		path:  path,
+		usage: make(map[string]*QuotaUsage),
+		now:   time.Now,
+	}
+	if path == "" {
+		return t, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading provider usage: %w", err)
+	}
+	if err := json.Unmarshal(data, &t.usage); err != nil {
+		return nil, fmt.Errorf("parsing provider usage %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// parseResetClock converts an "HH:MM" reset time to its offset from
+// midnight. An empty value resets at midnight.
+func parseResetClock(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quota reset time %q: %w", s, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// nextReset returns the first local time after now whose clock equals
+// resetClock.
+func nextReset(now time.Time, resetClock time.Duration) time.Time {
+	y, m, d := now.Date()
+	reset := time.Date(y, m, d, 0, 0, 0, 0, now.Location()).Add(resetClock)
+	if !reset.After(now) {
+		reset = time.Date(y, m, d+1, 0, 0, 0, 0, now.Location()).Add(resetClock)
+	}
+	return reset
+}
+
+// entry returns the usage of name, starting a new period when the previous
+// one has passed its reset time. The caller must hold t.mu.
+func (t *QuotaTracker) entry(name string, limit int64, resetClock time.Duration) *QuotaUsage {
+	now := t.now()
+	u, ok := t.usage[name]
+	if !ok {
+		u = &QuotaUsage{ResetAt: nextReset(now, resetClock)}
+		t.usage[name] = u
+		return u
+	}
+	if !now.Before(u.ResetAt) {
+		if limit > 0 && u.Used >= limit {
+			slog.Info("Upload provider daily quota reset, resuming", "provider", name)
+		}
+		u.Used = 0
+		u.ResetAt = nextReset(now, resetClock)
+		t.saveLocked()
+	}
+	return u
+}
+
+// Exhausted reports whether name has used up limit bytes in the current
+// period, and when that period ends. A limit <= 0 is never exhausted.
+func (t *QuotaTracker) Exhausted(name string, limit int64, resetClock time.Duration) (bool, time.Time) {
+	if limit <= 0 {
+		return false, time.Time{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.entry(name, limit, resetClock)
+	return u.Used >= limit, u.ResetAt
+}
+
+// Add records n bytes posted to name. The provider is paused once its usage
+// reaches limit.
+func (t *QuotaTracker) Add(name string, limit int64, resetClock time.Duration, n int64) {
+	if limit <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.entry(name, limit, resetClock)
+	before := u.Used
+	u.Used += n
+
+	if before < limit && u.Used >= limit {
+		slog.Warn("Upload provider reached its daily quota, pausing until reset",
+			"provider", name, "used", u.Used, "quota", limit, "resetAt", u.ResetAt)
+		t.saveLocked()
+		return
+	}
+	if t.now().Sub(t.lastSave) >= quotaSaveInterval {
+		t.saveLocked()
+	}
+}
+
+// Usage returns the recorded usage of name.
+func (t *QuotaTracker) Usage(name string) (QuotaUsage, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u, ok := t.usage[name]
+	if !ok {
+		return QuotaUsage{}, false
+	}
+	return *u, true
+}
+
+// Save writes the usage to disk.
+func (t *QuotaTracker) Save() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.save()
+}
+
+func (t *QuotaTracker) saveLocked() {
+	if err := t.save(); err != nil {
+		slog.Warn("Failed to persist provider usage", "path", t.path, "error", err)
+	}
+}
+
+// save writes the usage through a temp file so a crash can't leave a
+// truncated file behind. The caller must hold t.mu.
+func (t *QuotaTracker) save() error {
+	t.lastSave = t.now()
+	if t.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(t.usage, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
@@ -0,0 +1,103 @@
+package pool
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNextReset(t *testing.T) {
+	loc := time.UTC
+	tests := []struct {
+		name  string
+		now   time.Time
+		clock time.Duration
+		want  time.Time
+	}{
+		{"midnight rolls to next day", time.Date(2026, 3, 10, 15, 0, 0, 0, loc), 0, time.Date(2026, 3, 11, 0, 0, 0, 0, loc)},
+		{"later today", time.Date(2026, 3, 10, 1, 0, 0, 0, loc), 6 * time.Hour, time.Date(2026, 3, 10, 6, 0, 0, 0, loc)},
+		{"exactly at reset rolls over", time.Date(2026, 3, 10, 6, 0, 0, 0, loc), 6 * time.Hour, time.Date(2026, 3, 11, 6, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextReset(tt.now, tt.clock); !got.Equal(tt.want) {
+				t.Errorf("nextReset() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResetClock(t *testing.T) {
+	if d, err := parseResetClock("04:30"); err != nil || d != 4*time.Hour+30*time.Minute {
+		t.Errorf("parseResetClock(04:30) = %v, %v", d, err)
+	}
+	if d, err := parseResetClock(""); err != nil || d != 0 {
+		t.Errorf("parseResetClock(\"\") = %v, %v", d, err)
+	}
+	if _, err := parseResetClock("25:00"); err == nil {
+		t.Error("expected an error for an invalid clock")
+	}
+}
+
+func TestQuotaTracker_PausesAndResumes(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	qt, err := NewQuotaTracker("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qt.now = func() time.Time { return now }
+
+	qt.Add("p", 100, 0, 60)
+	if exhausted, _ := qt.Exhausted("p", 100, 0); exhausted {
+		t.Fatal("provider paused before reaching its quota")
+	}
+
+	qt.Add("p", 100, 0, 40)
+	exhausted, resetAt := qt.Exhausted("p", 100, 0)
+	if !exhausted {
+		t.Fatal("provider not paused after reaching its quota")
+	}
+	if want := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC); !resetAt.Equal(want) {
+		t.Errorf("resetAt = %v, want %v", resetAt, want)
+	}
+
+	now = resetAt
+	if exhausted, _ := qt.Exhausted("p", 100, 0); exhausted {
+		t.Error("provider still paused after the reset time")
+	}
+	if u, _ := qt.Usage("p"); u.Used != 0 {
+		t.Errorf("usage after reset = %d, want 0", u.Used)
+	}
+}
+
+func TestQuotaTracker_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "provider-usage.json")
+
+	qt, err := NewQuotaTracker(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qt.Add("p", 1000, 0, 250)
+	if err := qt.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewQuotaTracker(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, ok := reloaded.Usage("p"); !ok || u.Used != 250 {
+		t.Errorf("reloaded usage = %+v, %v; want 250", u, ok)
+	}
+}
+
+func TestQuotaTracker_UnlimitedNotTracked(t *testing.T) {
+	qt, _ := NewQuotaTracker("")
+	qt.Add("p", 0, 0, 1<<40)
+	if exhausted, _ := qt.Exhausted("p", 0, 0); exhausted {
+		t.Error("a provider without a quota must never pause")
+	}
+	if _, ok := qt.Usage("p"); ok {
+		t.Error("usage recorded for a provider without a quota")
+	}
+}
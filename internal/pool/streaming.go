@@ -0,0 +1,597 @@
+package pool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/javi11/nntppool/v4"
+	"github.com/mnightingale/rapidyenc"
+)
+
+// RFC 4644 streaming status codes.
+const (
+	codeStreamingPermitted = 203
+	codeCheckSend          = 238
+	codeTakeThisAccepted   = 239
+	codeCheckRetryLater    = 431
+	codeCheckNotWanted     = 438
+	codeTakeThisRejected   = 439
+)
+
+const (
+	streamHandshakeTimeout = 30 * time.Second
+	streamWriteTimeout     = time.Minute
+)
+
+// errStreamClosed is returned for requests on a closed StreamingClient.
+var errStreamClosed = errors.New("streaming client is closed")
+
+type streamResult struct {
+	code   int
+	status string
+	err    error
+}
+
+// streamConn is one MODE STREAM connection. Requests are pipelined: writers
+// send CHECK/TAKETHIS under wmu without waiting, and a reader goroutine routes
+// each response to its waiter by command and message-ID.
+type streamConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+	wmu  sync.Mutex
+
+	mu       sync.Mutex
+	pending  map[string][]chan streamResult
+	inflight int
+	lastUsed time.Time
+	err      error
+}
+
+func (sc *streamConn) load() int {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.inflight
+}
+
+func (sc *streamConn) dead() bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.err != nil
+}
+
+// fail closes the connection and fails every pending request with err.
+func (sc *streamConn) fail(err error) {
+	sc.mu.Lock()
+	if sc.err != nil {
+		sc.mu.Unlock()
+		return
+	}
+	sc.err = err
+	pending := sc.pending
+	sc.pending = nil
+	sc.mu.Unlock()
+
+	_ = sc.conn.Close()
+	for _, waiters := range pending {
+		for _, ch := range waiters {
+			ch <- streamResult{err: err}
+		}
+	}
+}
+
+// readLoop dispatches responses until the connection fails.
+func (sc *streamConn) readLoop() {
+	for {
+		code, rest, err := readStatus(sc.r)
+		if err != nil {
+			sc.fail(err)
+			return
+		}
+
+		var cmd string
+		switch code {
+		case codeCheckSend, codeCheckRetryLater, codeCheckNotWanted:
+			cmd = "CHECK"
+		case codeTakeThisAccepted, codeTakeThisRejected:
+			cmd = "TAKETHIS"
+		default:
+			sc.fail(fmt.Errorf("unexpected streaming response: %d %s", code, rest))
+			return
+		}
+
+		id, _, _ := strings.Cut(rest, " ")
+		key := cmd + " " + id
+
+		sc.mu.Lock()
+		var ch chan streamResult
+		if waiters := sc.pending[key]; len(waiters) > 0 {
+			ch = waiters[0]
+			if len(waiters) == 1 {
+				delete(sc.pending, key)
+			} else {
+				sc.pending[key] = waiters[1:]
+			}
+		}
+		sc.mu.Unlock()
+
+		if ch == nil {
+			slog.Debug("Dropping streaming response with no waiter", "code", code, "messageID", id)
+			continue
+		}
+		ch <- streamResult{code: code, status: rest}
+	}
+}
+
+// roundTrip sends "<cmd> <id>" followed by payload and waits for the response.
+func (sc *streamConn) roundTrip(ctx context.Context, cmd, id string, payload []byte) streamResult {
+	ch := make(chan streamResult, 1)
+	key := cmd + " " + id
+
+	sc.mu.Lock()
+	if sc.err != nil {
+		err := sc.err
+		sc.mu.Unlock()
+		return streamResult{err: err}
+	}
+	sc.pending[key] = append(sc.pending[key], ch)
+	sc.mu.Unlock()
+
+	sc.wmu.Lock()
+	_ = sc.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	_, err := sc.w.WriteString(key + "\r\n")
+	if err == nil && payload != nil {
+		_, err = sc.w.Write(payload)
+	}
+	if err == nil {
+		err = sc.w.Flush()
+	}
+	sc.wmu.Unlock()
+	if err != nil {
+		sc.fail(err)
+	}
+
+	select {
+	case res := <-ch:
+		return res
+	case <-ctx.Done():
+		// The reader delivers the late response into the buffered channel.
+		return streamResult{err: ctx.Err()}
+	}
+}
+
+// StreamingClient posts to a single provider with RFC 4644 streaming: MODE
+// STREAM, then TAKETHIS for every article, pipelined up to the provider's
+// inflight limit per connection instead of waiting for a POST round-trip per
+// article. Stat uses CHECK, so an article counts as present when the server
+// answers "not wanted"; use a verify server for a real retention check.
+//
+// It implements NNTPClient so UploadClient can use it in place of an
+// nntppool.Client for servers configured with transport: streaming.
+type StreamingClient struct {
+	provider nntppool.Provider
+	name     string
+	slots    chan struct{}
+	start    time.Time
+
+	mu     sync.Mutex
+	conns  []*streamConn
+	closed bool
+	dialMu sync.Mutex
+
+	bytesPosted atomic.Int64
+	errors      atomic.Int64
+}
+
+var _ NNTPClient = (*StreamingClient)(nil)
+
+// NewStreamingClient creates a streaming client for p. Connections are dialed
+// on demand, up to p.Connections.
+func NewStreamingClient(p nntppool.Provider) *StreamingClient {
+	p.Connections = max(p.Connections, 1)
+	p.Inflight = max(p.Inflight, 1)
+	return &StreamingClient{
+		provider: p,
+		name:     uploadProviderName(p),
+		slots:    make(chan struct{}, p.Connections*p.Inflight),
+		start:    time.Now(),
+	}
+}
+
+func (c *StreamingClient) dial(ctx context.Context) (net.Conn, error) {
+	if c.provider.Factory != nil {
+		return c.provider.Factory(ctx)
+	}
+
+	d := net.Dialer{Timeout: streamHandshakeTimeout, KeepAlive: 30 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", c.provider.Host)
+	if err != nil {
+		return nil, err
+	}
+	if c.provider.TLSConfig != nil {
+		tlsConn := tls.Client(conn, c.provider.TLSConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		return tlsConn, nil
+	}
+	return conn, nil
+}
+
+// connect dials a connection, authenticates and switches it to streaming mode.
+func (c *StreamingClient) connect(ctx context.Context) (*streamConn, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: connecting: %w", c.name, err)
+	}
+
+	sc := &streamConn{
+		conn:     conn,
+		r:        bufio.NewReader(conn),
+		w:        bufio.NewWriter(conn),
+		pending:  make(map[string][]chan streamResult),
+		lastUsed: time.Now(),
+	}
+	if err := c.handshake(sc); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+
+	go sc.readLoop()
+	return sc, nil
+}
+
+func (c *StreamingClient) handshake(sc *streamConn) error {
+	_ = sc.conn.SetDeadline(time.Now().Add(streamHandshakeTimeout))
+	defer func() { _ = sc.conn.SetDeadline(time.Time{}) }()
+
+	cmd := func(line string) (int, string, error) {
+		if _, err := sc.w.WriteString(line + "\r\n"); err != nil {
+			return 0, "", err
+		}
+		if err := sc.w.Flush(); err != nil {
+			return 0, "", err
+		}
+		return readStatus(sc.r)
+	}
+
+	code, msg, err := readStatus(sc.r)
+	if err != nil {
+		return fmt.Errorf("reading greeting: %w", err)
+	}
+	if code != 200 && code != 201 {
+		return fmt.Errorf("unexpected greeting: %d %s", code, msg)
+	}
+
+	if auth := c.provider.Auth; auth.Username != "" {
+		code, msg, err = cmd("AUTHINFO USER " + auth.Username)
+		if err != nil {
+			return err
+		}
+		if code == 381 {
+			code, msg, err = cmd("AUTHINFO PASS " + auth.Password)
+			if err != nil {
+				return err
+			}
+		}
+		if code != 281 {
+			return fmt.Errorf("authentication failed: %d %s", code, msg)
+		}
+	}
+
+	code, msg, err = cmd("MODE STREAM")
+	if err != nil {
+		return err
+	}
+	if code != codeStreamingPermitted {
+		return fmt.Errorf("server does not support streaming (MODE STREAM: %d %s)", code, msg)
+	}
+	return nil
+}
+
+// acquire reserves an inflight slot on the least loaded connection, dialing a
+// new one while the existing ones are busy and the connection limit allows.
+func (c *StreamingClient) acquire(ctx context.Context) (*streamConn, error) {
+	select {
+	case c.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	sc, err := c.pick(ctx)
+	if err != nil {
+		<-c.slots
+		return nil, err
+	}
+
+	sc.mu.Lock()
+	sc.inflight++
+	sc.mu.Unlock()
+	return sc, nil
+}
+
+func (c *StreamingClient) pick(ctx context.Context) (*streamConn, error) {
+	for {
+		best, needDial, err := c.leastLoaded()
+		if err != nil || !needDial {
+			return best, err
+		}
+
+		// Dials are serialized so concurrent requests can't overshoot the
+		// connection limit.
+		if !c.dialMu.TryLock() {
+			if best != nil {
+				return best, nil
+			}
+			c.dialMu.Lock()
+			c.dialMu.Unlock()
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		sc, err := c.connect(ctx)
+		if err != nil {
+			c.dialMu.Unlock()
+			c.errors.Add(1)
+			if best != nil {
+				return best, nil
+			}
+			return nil, err
+		}
+
+		c.mu.Lock()
+		closed := c.closed
+		if !closed {
+			c.conns = append(c.conns, sc)
+		}
+		c.mu.Unlock()
+		c.dialMu.Unlock()
+
+		if closed {
+			sc.fail(errStreamClosed)
+			return nil, errStreamClosed
+		}
+		return sc, nil
+	}
+}
+
+// leastLoaded prunes dead and idle connections and returns the least loaded
+// one, and whether a new connection should be dialed.
+func (c *StreamingClient) leastLoaded() (*streamConn, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, false, errStreamClosed
+	}
+
+	now := time.Now()
+	c.conns = slices.DeleteFunc(c.conns, func(sc *streamConn) bool {
+		sc.mu.Lock()
+		idle := sc.inflight == 0 && c.provider.IdleTimeout > 0 && now.Sub(sc.lastUsed) > c.provider.IdleTimeout
+		dead := sc.err != nil
+		sc.mu.Unlock()
+		if idle && !dead {
+			sc.fail(errors.New("idle timeout"))
+		}
+		return idle || dead
+	})
+
+	var best *streamConn
+	for _, sc := range c.conns {
+		if best == nil || sc.load() < best.load() {
+			best = sc
+		}
+	}
+	needDial := best == nil || (best.load() > 0 && len(c.conns) < c.provider.Connections)
+	return best, needDial, nil
+}
+
+func (c *StreamingClient) release(sc *streamConn) {
+	sc.mu.Lock()
+	sc.inflight--
+	sc.lastUsed = time.Now()
+	sc.mu.Unlock()
+	<-c.slots
+}
+
+func bracketID(id string) string {
+	if strings.HasPrefix(id, "<") {
+		return id
+	}
+	return "<" + id + ">"
+}
+
+// PostYenc encodes the article and sends it with TAKETHIS.
+func (c *StreamingClient) PostYenc(ctx context.Context, headers nntppool.PostHeaders, body io.Reader, meta rapidyenc.Meta) (*nntppool.PostResult, error) {
+	if headers.MessageID == "" {
+		return nil, errors.New("streaming posts require a Message-ID")
+	}
+	id := bracketID(headers.MessageID)
+
+	var buf bytes.Buffer
+	buf.Grow(int(meta.PartSize) + int(meta.PartSize)/32 + 1024)
+	if _, err := headers.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	enc, err := rapidyenc.NewEncoder(&buf, meta)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(enc, body); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	// The encoder terminates the last line; only the dot-line is missing.
+	buf.WriteString(".\r\n")
+
+	sc, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(sc)
+
+	res := sc.roundTrip(ctx, "TAKETHIS", id, buf.Bytes())
+	switch {
+	case res.err != nil:
+		if ctx.Err() == nil {
+			c.errors.Add(1)
+		}
+		return nil, res.err
+	case res.code == codeTakeThisRejected:
+		c.errors.Add(1)
+		return nil, fmt.Errorf("article rejected (%d %s): %w", res.code, res.status, nntppool.ErrPostingFailed)
+	}
+
+	c.bytesPosted.Add(int64(buf.Len()))
+	return &nntppool.PostResult{StatusCode: res.code, Status: res.status}, nil
+}
+
+// Stat CHECKs the article: "not wanted" (438) means the server already has
+// it, "send it" (238) means it is missing.
+func (c *StreamingClient) Stat(ctx context.Context, messageID string) (*nntppool.StatResult, error) {
+	sc, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(sc)
+
+	res := sc.roundTrip(ctx, "CHECK", bracketID(messageID), nil)
+	switch {
+	case res.err != nil:
+		return nil, res.err
+	case res.code == codeCheckNotWanted:
+		return &nntppool.StatResult{MessageID: messageID}, nil
+	case res.code == codeCheckSend:
+		return nil, nntppool.ErrArticleNotFound
+	default:
+		return nil, fmt.Errorf("CHECK %s: %d %s", messageID, res.code, res.status)
+	}
+}
+
+// StatMany CHECKs ids concurrently, bounded by opts.Concurrency (default: all
+// inflight slots).
+func (c *StreamingClient) StatMany(ctx context.Context, messageIDs []string, opts nntppool.StatManyOptions) <-chan nntppool.StatManyResult {
+	out := make(chan nntppool.StatManyResult, len(messageIDs))
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = cap(c.slots)
+	}
+
+	go func() {
+		defer close(out)
+
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for _, id := range messageIDs {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				wg.Wait()
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				res, err := c.Stat(ctx, id)
+				out <- nntppool.StatManyResult{MessageID: id, Result: res, Err: err}
+			}()
+		}
+		wg.Wait()
+	}()
+
+	return out
+}
+
+// Stats reports the streaming connections as a single provider.
+func (c *StreamingClient) Stats() nntppool.ClientStats {
+	c.mu.Lock()
+	active := 0
+	for _, sc := range c.conns {
+		if !sc.dead() {
+			active++
+		}
+	}
+	c.mu.Unlock()
+
+	elapsed := time.Since(c.start)
+	posted := c.bytesPosted.Load()
+	var speed float64
+	if elapsed > 0 {
+		speed = float64(posted) / elapsed.Seconds()
+	}
+
+	return nntppool.ClientStats{
+		Providers: []nntppool.ProviderStats{{
+			Name:              c.name,
+			AvgSpeed:          speed,
+			BytesConsumed:     posted,
+			Errors:            c.errors.Load(),
+			ActiveConnections: active,
+			MaxConnections:    c.provider.Connections,
+			AvailableSlots:    c.provider.Connections - active,
+		}},
+		AvgSpeed:      speed,
+		BytesConsumed: posted,
+		Elapsed:       elapsed,
+	}
+}
+
+// AddProvider is not supported: a StreamingClient serves a single provider.
+func (c *StreamingClient) AddProvider(nntppool.Provider) error {
+	return errors.New("streaming client serves a single provider")
+}
+
+// RemoveProvider is not supported: a StreamingClient serves a single provider.
+func (c *StreamingClient) RemoveProvider(string) error {
+	return errors.New("streaming client serves a single provider")
+}
+
+// Close closes every connection and fails their pending requests.
+func (c *StreamingClient) Close() error {
+	c.mu.Lock()
+	conns := c.conns
+	c.conns = nil
+	c.closed = true
+	c.mu.Unlock()
+
+	for _, sc := range conns {
+		sc.fail(errStreamClosed)
+	}
+	return nil
+}
+
+// readStatus reads one "<code> <text>" status line.
+func readStatus(r *bufio.Reader) (int, string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+
+	codeStr, rest, _ := strings.Cut(line, " ")
+	code, err := strconv.Atoi(codeStr)
+	if err != nil || len(codeStr) != 3 {
+		return 0, "", fmt.Errorf("malformed status line %q", line)
+	}
+	return code, rest, nil
+}
@@ -0,0 +1,228 @@
+package pool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/javi11/nntppool/v4"
+	"github.com/mnightingale/rapidyenc"
+)
+
+// fakeStreamServer is a minimal RFC 4644 peer. It accepts every TAKETHIS
+// except message-IDs listed in reject, and answers CHECK with 438 for stored
+// articles.
+type fakeStreamServer struct {
+	ln     net.Listener
+	reject map[string]bool
+
+	mu       sync.Mutex
+	articles map[string][]byte
+	commands []string
+}
+
+func newFakeStreamServer(t *testing.T) *fakeStreamServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeStreamServer{ln: ln, reject: make(map[string]bool), articles: make(map[string][]byte)}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeStreamServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	reply := func(line string) {
+		_, _ = w.WriteString(line + "\r\n")
+		_ = w.Flush()
+	}
+
+	reply("200 fake ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "AUTHINFO":
+			if strings.HasPrefix(arg, "USER") {
+				reply("381 password required")
+			} else {
+				reply("281 ok")
+			}
+		case "MODE":
+			reply("203 streaming permitted")
+		case "CHECK":
+			s.mu.Lock()
+			_, ok := s.articles[arg]
+			s.mu.Unlock()
+			if ok {
+				reply("438 " + arg)
+			} else {
+				reply("238 " + arg)
+			}
+		case "TAKETHIS":
+			var body bytes.Buffer
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				body.WriteString(l)
+			}
+			if s.reject[arg] {
+				reply("439 " + arg)
+				continue
+			}
+			s.mu.Lock()
+			s.articles[arg] = body.Bytes()
+			s.mu.Unlock()
+			reply("239 " + arg)
+		default:
+			reply("500 unknown command")
+		}
+	}
+}
+
+func newTestStreamingClient(s *fakeStreamServer) *StreamingClient {
+	return NewStreamingClient(nntppool.Provider{
+		Host:        s.ln.Addr().String(),
+		Auth:        nntppool.Auth{Username: "user", Password: "pass"},
+		Connections: 2,
+		Inflight:    4,
+		IdleTimeout: time.Minute,
+	})
+}
+
+func testHeaders(id string) nntppool.PostHeaders {
+	return nntppool.PostHeaders{
+		From:       "poster <poster@example.com>",
+		Subject:    "test",
+		Newsgroups: []string{"alt.binaries.test"},
+		MessageID:  "<" + id + ">",
+	}
+}
+
+func TestStreamingClient_PostAndCheck(t *testing.T) {
+	srv := newFakeStreamServer(t)
+	srv.reject["<bad@test>"] = true
+	c := newTestStreamingClient(srv)
+	defer func() { _ = c.Close() }()
+	ctx := context.Background()
+
+	payload := []byte(strings.Repeat("data.", 1000))
+	meta := rapidyenc.Meta{FileName: "f.bin", FileSize: int64(len(payload)), PartNumber: 1, TotalParts: 1, PartSize: int64(len(payload))}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := "a" + string(rune('a'+i)) + "@test"
+			res, err := c.PostYenc(ctx, testHeaders(id), bytes.NewReader(payload), meta)
+			if err == nil && res.StatusCode != codeTakeThisAccepted {
+				err = errors.New(res.Status)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+	}
+
+	if _, err := c.PostYenc(ctx, testHeaders("bad@test"), bytes.NewReader(payload), meta); !errors.Is(err, nntppool.ErrPostingFailed) {
+		t.Errorf("expected a rejected TAKETHIS to map to ErrPostingFailed, got %v", err)
+	}
+
+	if _, err := c.Stat(ctx, "aa@test"); err != nil {
+		t.Errorf("CHECK of a posted article: %v", err)
+	}
+	if _, err := c.Stat(ctx, "missing@test"); !errors.Is(err, nntppool.ErrArticleNotFound) {
+		t.Errorf("CHECK of a missing article: expected ErrArticleNotFound, got %v", err)
+	}
+
+	missing, err := StatMissing(ctx, c, []string{"ab@test", "nope@test"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := missing["nope@test"]; !ok || len(missing) != 1 {
+		t.Errorf("expected only nope@test missing, got %v", missing)
+	}
+
+	stats := c.Stats()
+	if len(stats.Providers) != 1 || stats.Providers[0].ActiveConnections == 0 || stats.Providers[0].ActiveConnections > 2 {
+		t.Errorf("unexpected stats: %+v", stats.Providers)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.articles) != 20 {
+		t.Errorf("server stored %d articles, want 20", len(srv.articles))
+	}
+	if !bytes.Contains(srv.articles["<aa@test>"], []byte("=ybegin")) {
+		t.Error("article body is not yEnc encoded")
+	}
+	if srv.commands[0] != "AUTHINFO USER user" {
+		t.Errorf("expected authentication first, got %q", srv.commands[0])
+	}
+}
+
+func TestStreamingClient_RequiresStreamingSupport(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, _ = conn.Write([]byte("200 ready\r\n"))
+		r := bufio.NewReader(conn)
+		_, _ = r.ReadString('\n')
+		_, _ = conn.Write([]byte("500 what?\r\n"))
+	}()
+
+	c := NewStreamingClient(nntppool.Provider{Host: ln.Addr().String(), Connections: 1, Inflight: 1})
+	defer func() { _ = c.Close() }()
+
+	_, err = c.PostYenc(context.Background(), testHeaders("x@test"), bytes.NewReader([]byte("x")), rapidyenc.Meta{FileName: "x", FileSize: 1, PartNumber: 1, TotalParts: 1, PartSize: 1})
+	if err == nil || !strings.Contains(err.Error(), "does not support streaming") {
+		t.Errorf("expected a MODE STREAM failure, got %v", err)
+	}
+}
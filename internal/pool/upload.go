@@ -0,0 +1,467 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/javi11/nntppool/v4"
+	"github.com/mnightingale/rapidyenc"
+
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/throttle"
+)
+
+// UploadProvider is one upload provider of an UploadClient: its own connection
+// pool plus the weight used to share articles between providers and its
+// bandwidth and quota limits.
+type UploadProvider struct {
+	Name   string
+	Weight int
+	Client NNTPClient
+	// ThrottleRate caps the bytes per second posted to this provider. 0 means unlimited.
+	ThrottleRate int64
+	// DailyQuotaBytes pauses the provider once this many bytes were posted in
+	// the current period. 0 means unlimited.
+	DailyQuotaBytes int64
+	// QuotaResetTime is the local "HH:MM" time the quota period ends.
+	QuotaResetTime string
+	// Streaming posts with CHECK/TAKETHIS through a StreamingClient instead of
+	// an nntppool.Client. Only used by AddServer.
+	Streaming bool
+}
+
+// uploadProvider is an UploadProvider with its smooth weighted round-robin
+// state and its own throttle.
+type uploadProvider struct {
+	UploadProvider
+	current    int
+	throttle   *throttle.Throttle
+	resetClock time.Duration
+}
+
+func newUploadProvider(p UploadProvider) (*uploadProvider, error) {
+	resetClock, err := parseResetClock(p.QuotaResetTime)
+	if err != nil {
+		return nil, fmt.Errorf("upload provider %q: %w", p.Name, err)
+	}
+	p.Weight = max(p.Weight, 1)
+	return &uploadProvider{
+		UploadProvider: p,
+		throttle:       throttle.New(p.ThrottleRate, time.Second),
+		resetClock:     resetClock,
+	}, nil
+}
+
+// UploadClient spreads posts across several upload providers, which may sit on
+// different backbones. Each provider keeps its own connection pool; articles
+// are assigned by smooth weighted round-robin on the configured weights, and a
+// POST that fails on one provider is retried on the others before the error is
+// returned.
+//
+// STATs succeed when any provider has the article, since an article posted to
+// one backbone may not have propagated to the others yet. The verify pool is an
+// UploadClient over the verify servers too, so StatMissingByProvider can report
+// propagation per backbone.
+//
+// Providers with a daily quota are skipped once it is used up and resume when
+// it resets. Providers quarantined by the health circuit breaker are skipped
+// while any other provider is available.
+type UploadClient struct {
+	mu        sync.Mutex
+	providers []*uploadProvider
+	quota     *QuotaTracker
+	health    *HealthTracker
+}
+
+var _ NNTPClient = (*UploadClient)(nil)
+
+// NewUploadClient creates one connection pool per upload server. Daily quota
+// usage is persisted at usagePath; an empty path keeps it in memory.
+func NewUploadClient(servers []config.ServerConfig, usagePath string) (*UploadClient, error) {
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("no upload servers configured")
+	}
+
+	quota, err := NewQuotaTracker(usagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &UploadClient{quota: quota, health: NewHealthTracker()}
+	for _, s := range servers {
+		if err := c.AddServer(s); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// NewUploadClientFromProviders creates an UploadClient over already connected
+// provider pools. Providers with a non-positive weight get a weight of 1. A nil
+// quota tracker keeps quota usage in memory.
+func NewUploadClientFromProviders(providers []UploadProvider, quota *QuotaTracker) (*UploadClient, error) {
+	if quota == nil {
+		quota, _ = NewQuotaTracker("")
+	}
+
+	c := &UploadClient{quota: quota, health: NewHealthTracker()}
+	for _, p := range providers {
+		up, err := newUploadProvider(p)
+		if err != nil {
+			return nil, err
+		}
+		c.providers = append(c.providers, up)
+	}
+	return c, nil
+}
+
+// AddServer connects an upload server and adds it with its configured
+// upload_weight, throttle_rate, daily quota and transport.
+func (c *UploadClient) AddServer(s config.ServerConfig) error {
+	return c.addProvider(config.ServerConfigToProvider(s), UploadProvider{
+		Weight:          s.UploadWeight,
+		ThrottleRate:    s.ThrottleRate,
+		DailyQuotaBytes: s.DailyQuotaBytes,
+		QuotaResetTime:  s.QuotaResetTime,
+		Streaming:       s.Transport == config.ServerTransportStreaming,
+	})
+}
+
+// AddProvider connects a provider and adds it weighted by its connection count.
+func (c *UploadClient) AddProvider(p nntppool.Provider) error {
+	return c.addProvider(p, UploadProvider{})
+}
+
+func (c *UploadClient) addProvider(p nntppool.Provider, opts UploadProvider) error {
+	name := uploadProviderName(p)
+	opts.Name = name
+	if opts.Weight <= 0 {
+		opts.Weight = p.Connections
+	}
+
+	c.mu.Lock()
+	exists := slices.ContainsFunc(c.providers, func(up *uploadProvider) bool { return up.Name == name })
+	c.mu.Unlock()
+	if exists {
+		return fmt.Errorf("upload provider %q already exists", name)
+	}
+
+	if opts.Streaming {
+		opts.Client = NewStreamingClient(p)
+	} else {
+		client, err := nntppool.NewClient(context.Background(), []nntppool.Provider{p}, nntppool.WithDispatchStrategy(nntppool.DispatchRoundRobin))
+		if err != nil {
+			return fmt.Errorf("error creating upload pool for %q: %w", name, err)
+		}
+		opts.Client = client
+	}
+
+	up, err := newUploadProvider(opts)
+	if err != nil {
+		_ = opts.Client.Close()
+		return err
+	}
+
+	c.mu.Lock()
+	c.providers = append(c.providers, up)
+	c.mu.Unlock()
+
+	slog.Info("Upload provider added", "provider", name, "weight", up.Weight,
+		"throttleRate", up.ThrottleRate, "dailyQuotaBytes", up.DailyQuotaBytes, "streaming", up.Streaming)
+	return nil
+}
+
+// RemoveProvider closes and removes the named provider.
+func (c *UploadClient) RemoveProvider(name string) error {
+	c.mu.Lock()
+	idx := slices.IndexFunc(c.providers, func(up *uploadProvider) bool { return up.Name == name })
+	if idx < 0 {
+		c.mu.Unlock()
+		return fmt.Errorf("upload provider %q not found", name)
+	}
+	removed := c.providers[idx]
+	c.providers = slices.Delete(c.providers, idx, idx+1)
+	c.mu.Unlock()
+
+	return removed.Client.Close()
+}
+
+// uploadProviderName mirrors nntppool's provider naming so providerKey matches
+// the names used by RemoveProvider.
+func uploadProviderName(p nntppool.Provider) string {
+	if p.Auth.Username != "" {
+		return p.Host + "+" + p.Auth.Username
+	}
+	return p.Host
+}
+
+// order returns the providers to try for the next post: the provider picked by
+// smooth weighted round-robin first, then the rest by descending weight as
+// failover candidates. Providers over their daily quota are left out, and so
+// are quarantined ones unless every remaining provider is quarantined.
+func (c *UploadClient) order() ([]*uploadProvider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.providers) == 0 {
+		return nil, errors.New("no upload providers available")
+	}
+
+	var (
+		live      = make([]*uploadProvider, 0, len(c.providers))
+		nextReset time.Time
+	)
+	for _, p := range c.providers {
+		exhausted, resetAt := c.quota.Exhausted(p.Name, p.DailyQuotaBytes, p.resetClock)
+		if exhausted {
+			if nextReset.IsZero() || resetAt.Before(nextReset) {
+				nextReset = resetAt
+			}
+			continue
+		}
+		live = append(live, p)
+	}
+	if len(live) == 0 {
+		return nil, &QuotaExhaustedError{ResetAt: nextReset}
+	}
+
+	healthy := slices.DeleteFunc(slices.Clone(live), func(p *uploadProvider) bool { return !c.health.Allowed(p.Name) })
+	if len(healthy) > 0 {
+		live = healthy
+	}
+
+	total := 0
+	var best *uploadProvider
+	for _, p := range live {
+		p.current += p.Weight
+		total += p.Weight
+		if best == nil || p.current > best.current {
+			best = p
+		}
+	}
+	best.current -= total
+
+	order := make([]*uploadProvider, 0, len(live))
+	order = append(order, best)
+	for _, p := range live {
+		if p != best {
+			order = append(order, p)
+		}
+	}
+	slices.SortStableFunc(order[1:], func(a, b *uploadProvider) int { return b.Weight - a.Weight })
+	return order, nil
+}
+
+// snapshot returns the current providers.
+func (c *UploadClient) snapshot() []*uploadProvider {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.providers)
+}
+
+// PostYenc posts the article to the next provider in weighted order, failing
+// over to the remaining providers when one rejects it. Failover needs to resend
+// the body, so it only happens when body is an io.Seeker. Each attempt waits on
+// that provider's throttle, and successful posts count towards its quota.
+func (c *UploadClient) PostYenc(ctx context.Context, headers nntppool.PostHeaders, body io.Reader, meta rapidyenc.Meta) (*nntppool.PostResult, error) {
+	providers, err := c.order()
+	if err != nil {
+		return nil, err
+	}
+
+	seeker, canRetry := body.(io.Seeker)
+
+	var lastErr error
+	for i, p := range providers {
+		if i > 0 {
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return nil, fmt.Errorf("rewinding article body for failover: %w", err)
+			}
+			slog.WarnContext(ctx, "Upload provider failed to post article, failing over",
+				"failed", providers[i-1].Name, "next", p.Name, "messageID", headers.MessageID, "error", lastErr)
+		}
+
+		p.throttle.Wait(meta.PartSize)
+		start := time.Now()
+		res, err := p.Client.PostYenc(ctx, headers, body, meta)
+		if ctx.Err() == nil {
+			c.health.Record(p.Name, time.Since(start), err)
+		}
+		if err == nil {
+			c.quota.Add(p.Name, p.DailyQuotaBytes, p.resetClock, meta.PartSize)
+			return res, nil
+		}
+		lastErr = err
+
+		if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || !canRetry {
+			return nil, err
+		}
+	}
+
+	return nil, lastErr
+}
+
+// Stat reports the article as found when any provider has it.
+func (c *UploadClient) Stat(ctx context.Context, messageID string) (*nntppool.StatResult, error) {
+	providers := c.snapshot()
+	if len(providers) == 0 {
+		return nil, errors.New("no upload providers available")
+	}
+
+	var lastErr error
+	for _, p := range providers {
+		res, err := p.Client.Stat(ctx, messageID)
+		if err == nil {
+			return res, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// StatMany STATs ids against the first provider and re-checks the misses
+// against each remaining provider. Only ids missing everywhere are reported
+// with an error.
+func (c *UploadClient) StatMany(ctx context.Context, messageIDs []string, opts nntppool.StatManyOptions) <-chan nntppool.StatManyResult {
+	out := make(chan nntppool.StatManyResult, len(messageIDs))
+	providers := c.snapshot()
+
+	go func() {
+		defer close(out)
+
+		if len(providers) == 0 {
+			for _, id := range messageIDs {
+				out <- nntppool.StatManyResult{MessageID: id, Err: errors.New("no upload providers available")}
+			}
+			return
+		}
+
+		pending := messageIDs
+		for i, p := range providers {
+			last := i == len(providers)-1
+			var misses []string
+			for res := range p.Client.StatMany(ctx, pending, opts) {
+				if res.Err != nil && !last && ctx.Err() == nil {
+					misses = append(misses, res.MessageID)
+					continue
+				}
+				out <- res
+			}
+			if len(misses) == 0 {
+				return
+			}
+			pending = misses
+		}
+	}()
+
+	return out
+}
+
+// StatMissingByProvider STATs ids against every provider separately, rather
+// than stopping at the first provider that has an article, and returns the
+// message-IDs not confirmed present on each provider, keyed by provider name.
+// Providers are checked concurrently; see StatMissingOpts for batchSize and
+// opts.
+func (c *UploadClient) StatMissingByProvider(ctx context.Context, ids []string, batchSize int, opts nntppool.StatManyOptions) (map[string]map[string]struct{}, error) {
+	providers := c.snapshot()
+	if len(providers) == 0 {
+		return nil, errors.New("no upload providers available")
+	}
+
+	results := make([]map[string]struct{}, len(providers))
+	errs := make([]error, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = StatMissingOpts(ctx, p.Client, ids, batchSize, opts)
+		}()
+	}
+	wg.Wait()
+
+	out := make(map[string]map[string]struct{}, len(providers))
+	for i, p := range providers {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name, errs[i])
+		}
+		out[p.Name] = results[i]
+	}
+	return out, nil
+}
+
+// names returns the names of the current providers.
+func (c *UploadClient) names() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	names := make([]string, 0, len(c.providers))
+	for _, p := range c.providers {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// Health returns the health of every upload provider.
+func (c *UploadClient) Health() []ProviderHealth {
+	return c.health.Health(c.names())
+}
+
+// EvaluateHealth runs the circuit breaker over every upload provider,
+// quarantining unhealthy ones and releasing recovered ones, and returns their
+// health.
+func (c *UploadClient) EvaluateHealth() []ProviderHealth {
+	return c.health.Evaluate(c.names())
+}
+
+// Stats merges the provider stats of every upload provider. The quota fields
+// report the daily upload quota rather than nntppool's download quota.
+func (c *UploadClient) Stats() nntppool.ClientStats {
+	var stats nntppool.ClientStats
+	for _, p := range c.snapshot() {
+		s := p.Client.Stats()
+		if p.DailyQuotaBytes > 0 {
+			usage, _ := c.quota.Usage(p.Name)
+			for i := range s.Providers {
+				s.Providers[i].QuotaBytes = p.DailyQuotaBytes
+				s.Providers[i].QuotaUsed = usage.Used
+				s.Providers[i].QuotaResetAt = usage.ResetAt
+				s.Providers[i].QuotaExceeded = usage.Used >= p.DailyQuotaBytes
+			}
+		}
+		stats.Providers = append(stats.Providers, s.Providers...)
+		stats.AvgSpeed += s.AvgSpeed
+		stats.BytesConsumed += s.BytesConsumed
+		stats.Elapsed = max(stats.Elapsed, s.Elapsed)
+	}
+	return stats
+}
+
+// Close closes every provider pool and persists the quota usage.
+func (c *UploadClient) Close() error {
+	c.mu.Lock()
+	providers := c.providers
+	c.providers = nil
+	c.mu.Unlock()
+
+	var errs []error
+	if err := c.quota.Save(); err != nil {
+		errs = append(errs, fmt.Errorf("saving provider usage: %w", err))
+	}
+	for _, p := range providers {
+		if err := p.Client.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,272 @@
+package pool_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/javi11/nntppool/v4"
+	"github.com/mnightingale/rapidyenc"
+	"go.uber.org/mock/gomock"
+
+	"github.com/javi11/postie/internal/mocks"
+	"github.com/javi11/postie/internal/pool"
+)
+
+// postRecorder accepts every post and counts it under name. It reads the body
+// so failover has to rewind it.
+func postRecorder(name string, counts map[string]int, fail error) func(context.Context, nntppool.PostHeaders, io.Reader, rapidyenc.Meta) (*nntppool.PostResult, error) {
+	return func(_ context.Context, _ nntppool.PostHeaders, body io.Reader, _ rapidyenc.Meta) (*nntppool.PostResult, error) {
+		data, _ := io.ReadAll(body)
+		if string(data) != "article body" {
+			return nil, errors.New("body not rewound: " + string(data))
+		}
+		if fail != nil {
+			return nil, fail
+		}
+		counts[name]++
+		return &nntppool.PostResult{StatusCode: 240}, nil
+	}
+}
+
+func TestUploadClient_PostYencWeighted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	counts := make(map[string]int)
+	a := mocks.NewMockNNTPClient(ctrl)
+	b := mocks.NewMockNNTPClient(ctrl)
+	a.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(postRecorder("a", counts, nil)).AnyTimes()
+	b.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(postRecorder("b", counts, nil)).AnyTimes()
+
+	client, _ := pool.NewUploadClientFromProviders([]pool.UploadProvider{
+		{Name: "a", Weight: 3, Client: a},
+		{Name: "b", Weight: 1, Client: b},
+	}, nil)
+
+	for range 8 {
+		if _, err := client.PostYenc(context.Background(), nntppool.PostHeaders{}, bytes.NewReader([]byte("article body")), rapidyenc.Meta{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if counts["a"] != 6 || counts["b"] != 2 {
+		t.Errorf("expected a 3:1 split (6/2), got a=%d b=%d", counts["a"], counts["b"])
+	}
+}
+
+func TestUploadClient_PostYencFailsOver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	counts := make(map[string]int)
+	a := mocks.NewMockNNTPClient(ctrl)
+	b := mocks.NewMockNNTPClient(ctrl)
+	a.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(postRecorder("a", counts, nntppool.ErrPostingFailed)).Times(1)
+	b.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(postRecorder("b", counts, nil)).Times(1)
+
+	client, _ := pool.NewUploadClientFromProviders([]pool.UploadProvider{
+		{Name: "a", Weight: 10, Client: a},
+		{Name: "b", Weight: 1, Client: b},
+	}, nil)
+
+	if _, err := client.PostYenc(context.Background(), nntppool.PostHeaders{}, bytes.NewReader([]byte("article body")), rapidyenc.Meta{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if counts["b"] != 1 {
+		t.Errorf("expected the post to fail over to b, got %v", counts)
+	}
+}
+
+func TestUploadClient_PostYencAllFail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	counts := make(map[string]int)
+	a := mocks.NewMockNNTPClient(ctrl)
+	b := mocks.NewMockNNTPClient(ctrl)
+	a.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(postRecorder("a", counts, errors.New("a down"))).Times(1)
+	b.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(postRecorder("b", counts, nntppool.ErrPostingNotPermitted)).Times(1)
+
+	client, _ := pool.NewUploadClientFromProviders([]pool.UploadProvider{
+		{Name: "a", Weight: 2, Client: a},
+		{Name: "b", Weight: 1, Client: b},
+	}, nil)
+
+	_, err := client.PostYenc(context.Background(), nntppool.PostHeaders{}, bytes.NewReader([]byte("article body")), rapidyenc.Meta{})
+	if !errors.Is(err, nntppool.ErrPostingNotPermitted) {
+		t.Errorf("expected the last provider's error, got %v", err)
+	}
+}
+
+func TestUploadClient_PostYencNoFailoverOnCancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := mocks.NewMockNNTPClient(ctrl)
+	b := mocks.NewMockNNTPClient(ctrl) // no PostYenc expected
+	a.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, nntppool.PostHeaders, io.Reader, rapidyenc.Meta) (*nntppool.PostResult, error) {
+			cancel()
+			return nil, context.Canceled
+		}).Times(1)
+
+	client, _ := pool.NewUploadClientFromProviders([]pool.UploadProvider{
+		{Name: "a", Weight: 2, Client: a},
+		{Name: "b", Weight: 1, Client: b},
+	}, nil)
+
+	if _, err := client.PostYenc(ctx, nntppool.PostHeaders{}, bytes.NewReader([]byte("article body")), rapidyenc.Meta{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestUploadClient_StatManyChecksEveryProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var aCalls, bCalls [][]string
+	a := mocks.NewMockNNTPClient(ctrl)
+	b := mocks.NewMockNNTPClient(ctrl)
+	a.EXPECT().StatMany(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(statManyStub(map[string]error{"m1": nntppool.ErrArticleNotFound, "m2": nntppool.ErrArticleNotFound}, &aCalls)).Times(1)
+	b.EXPECT().StatMany(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(statManyStub(map[string]error{"m2": nntppool.ErrArticleNotFound}, &bCalls)).Times(1)
+
+	client, _ := pool.NewUploadClientFromProviders([]pool.UploadProvider{
+		{Name: "a", Client: a},
+		{Name: "b", Client: b},
+	}, nil)
+
+	missing, err := pool.StatMissing(context.Background(), client, []string{"m0", "m1", "m2"}, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bCalls) != 1 || len(bCalls[0]) != 2 {
+		t.Errorf("expected only a's misses to be re-checked on b, got %v", bCalls)
+	}
+	if _, ok := missing["m2"]; !ok || len(missing) != 1 {
+		t.Errorf("expected only m2 missing, got %v", missing)
+	}
+}
+
+func TestUploadClient_RemoveProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	counts := make(map[string]int)
+	a := mocks.NewMockNNTPClient(ctrl)
+	b := mocks.NewMockNNTPClient(ctrl)
+	a.EXPECT().Close().Return(nil).Times(1)
+	b.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(postRecorder("b", counts, nil)).Times(2)
+
+	client, _ := pool.NewUploadClientFromProviders([]pool.UploadProvider{
+		{Name: "a", Weight: 5, Client: a},
+		{Name: "b", Weight: 1, Client: b},
+	}, nil)
+
+	if err := client.RemoveProvider("a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.RemoveProvider("a"); err == nil {
+		t.Error("expected an error removing an unknown provider")
+	}
+
+	for range 2 {
+		if _, err := client.PostYenc(context.Background(), nntppool.PostHeaders{}, bytes.NewReader([]byte("article body")), rapidyenc.Meta{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestUploadClient_SkipsProvidersOverQuota(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	counts := make(map[string]int)
+	a := mocks.NewMockNNTPClient(ctrl)
+	b := mocks.NewMockNNTPClient(ctrl)
+	a.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(postRecorder("a", counts, nil)).AnyTimes()
+	b.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(postRecorder("b", counts, nil)).AnyTimes()
+
+	client, err := pool.NewUploadClientFromProviders([]pool.UploadProvider{
+		{Name: "a", Weight: 1, Client: a, DailyQuotaBytes: 200},
+		{Name: "b", Weight: 1, Client: b, DailyQuotaBytes: 100},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := func() error {
+		_, err := client.PostYenc(context.Background(), nntppool.PostHeaders{}, bytes.NewReader([]byte("article body")), rapidyenc.Meta{PartSize: 100})
+		return err
+	}
+	for range 3 {
+		if err := post(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if counts["a"] != 2 || counts["b"] != 1 {
+		t.Errorf("expected b to pause after its quota, got a=%d b=%d", counts["a"], counts["b"])
+	}
+
+	err = post()
+	if !errors.Is(err, pool.ErrQuotaExhausted) {
+		t.Errorf("expected ErrQuotaExhausted once every provider is paused, got %v", err)
+	}
+	var quotaErr *pool.QuotaExhaustedError
+	if !errors.As(err, &quotaErr) || !quotaErr.ResetAt.After(time.Now()) {
+		t.Errorf("expected the next reset time in the error, got %v", err)
+	}
+}
+
+func TestUploadClient_SkipsQuarantinedProviders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	counts := make(map[string]int)
+	a := mocks.NewMockNNTPClient(ctrl)
+	b := mocks.NewMockNNTPClient(ctrl)
+	a.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(postRecorder("a", counts, nntppool.ErrPostingFailed)).AnyTimes()
+	b.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(postRecorder("b", counts, nil)).AnyTimes()
+
+	client, err := pool.NewUploadClientFromProviders([]pool.UploadProvider{
+		{Name: "a", Weight: 1, Client: a},
+		{Name: "b", Weight: 1, Client: b},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := func() {
+		t.Helper()
+		if _, err := client.PostYenc(context.Background(), nntppool.PostHeaders{}, bytes.NewReader([]byte("article body")), rapidyenc.Meta{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for range 20 {
+		post()
+	}
+
+	// Posting alone trips the breaker: the CLI has no monitor calling
+	// EvaluateHealth.
+	health := client.Health()
+	if health[0].State != pool.CircuitOpen {
+		t.Fatalf("expected a to be quarantined, got %+v", health[0])
+	}
+
+	before := len(counts)
+	for range 5 {
+		post()
+	}
+	if counts["b"] != 25 || len(counts) != before {
+		t.Errorf("quarantined provider still received posts: %v", counts)
+	}
+	if h := client.Health()[0]; h.Samples != 10 {
+		t.Errorf("expected no new attempts on a, got %d samples", h.Samples)
+	}
+}
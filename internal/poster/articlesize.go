@@ -0,0 +1,34 @@
+package poster
+
+import (
+	"math/rand"
+
+	"github.com/javi11/postie/internal/config"
+)
+
+// articleSizes splits a file of fileSize bytes into article sizes: the
+// article_size_rules size for the file, varied by article_size_jitter once per
+// file or for every article. The last article holds the remainder.
+func (p *poster) articleSizes(fileSize int64) []int64 {
+	base := max(int64(p.cfg.ArticleSizeFor(fileSize)), 1)
+	spread := base * int64(p.cfg.ArticleSizeJitter) / 100
+	draw := func() int64 {
+		if spread <= 0 {
+			return base
+		}
+		return base - spread + rand.Int63n(2*spread+1)
+	}
+
+	perArticle := p.cfg.ArticleSizeJitterMode == config.JitterModePerArticle
+	size := draw()
+	sizes := make([]int64, 0, fileSize/base+1)
+	for offset := int64(0); offset < fileSize; {
+		if perArticle && offset > 0 {
+			size = draw()
+		}
+		n := min(size, fileSize-offset)
+		sizes = append(sizes, n)
+		offset += n
+	}
+	return sizes
+}
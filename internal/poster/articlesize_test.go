@@ -0,0 +1,96 @@
+package poster
+
+import (
+	"testing"
+
+	"github.com/javi11/postie/internal/config"
+)
+
+func sumSizes(sizes []int64) int64 {
+	var total int64
+	for _, s := range sizes {
+		total += s
+	}
+	return total
+}
+
+func TestArticleSizes_FixedSize(t *testing.T) {
+	p := &poster{cfg: config.PostingConfig{ArticleSizeInBytes: 100}}
+
+	sizes := p.articleSizes(450)
+	want := []int64{100, 100, 100, 100, 50}
+	if len(sizes) != len(want) {
+		t.Fatalf("articleSizes = %v, want %v", sizes, want)
+	}
+	for i := range want {
+		if sizes[i] != want[i] {
+			t.Fatalf("articleSizes = %v, want %v", sizes, want)
+		}
+	}
+}
+
+func TestArticleSizes_RulesBySize(t *testing.T) {
+	p := &poster{cfg: config.PostingConfig{
+		ArticleSizeInBytes: 100,
+		ArticleSizeRules: []config.ArticleSizeRule{
+			{MaxFileSize: 50, ArticleSize: 10},
+			{MaxFileSize: 500, ArticleSize: 40},
+		},
+	}}
+
+	for _, tc := range []struct {
+		fileSize int64
+		parts    int
+	}{
+		{fileSize: 50, parts: 5},
+		{fileSize: 400, parts: 10},
+		{fileSize: 1000, parts: 10},
+	} {
+		sizes := p.articleSizes(tc.fileSize)
+		if len(sizes) != tc.parts || sumSizes(sizes) != tc.fileSize {
+			t.Errorf("articleSizes(%d) = %v, want %d parts summing to the file size", tc.fileSize, sizes, tc.parts)
+		}
+	}
+}
+
+func TestArticleSizes_Jitter(t *testing.T) {
+	const fileSize = 100_000
+
+	t.Run("per_file", func(t *testing.T) {
+		p := &poster{cfg: config.PostingConfig{ArticleSizeInBytes: 1000, ArticleSizeJitter: 20}}
+		sizes := p.articleSizes(fileSize)
+		if sumSizes(sizes) != fileSize {
+			t.Fatalf("sizes sum to %d, want %d", sumSizes(sizes), fileSize)
+		}
+		if sizes[0] < 800 || sizes[0] > 1200 {
+			t.Errorf("article size %d outside 800-1200", sizes[0])
+		}
+		for i, s := range sizes[:len(sizes)-1] {
+			if s != sizes[0] {
+				t.Fatalf("article %d size %d differs from %d with per_file jitter", i+1, s, sizes[0])
+			}
+		}
+	})
+
+	t.Run("per_article", func(t *testing.T) {
+		p := &poster{cfg: config.PostingConfig{
+			ArticleSizeInBytes:    1000,
+			ArticleSizeJitter:     20,
+			ArticleSizeJitterMode: config.JitterModePerArticle,
+		}}
+		sizes := p.articleSizes(fileSize)
+		if sumSizes(sizes) != fileSize {
+			t.Fatalf("sizes sum to %d, want %d", sumSizes(sizes), fileSize)
+		}
+		distinct := make(map[int64]bool)
+		for i, s := range sizes[:len(sizes)-1] {
+			if s < 800 || s > 1200 {
+				t.Errorf("article %d size %d outside 800-1200", i+1, s)
+			}
+			distinct[s] = true
+		}
+		if len(distinct) < 2 {
+			t.Errorf("per_article jitter produced a single size %v", sizes[0])
+		}
+	})
+}
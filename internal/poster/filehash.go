@@ -0,0 +1,49 @@
+package poster
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"maps"
+	"path/filepath"
+
+	"github.com/javi11/postie/internal/filehash"
+	"github.com/javi11/postie/internal/nzb"
+)
+
+// recordFileSums records the whole-file checksums of a posted file and adds
+// them to the NZB. They come from the article bodies read for posting; when
+// those were not read in file order (shuffled article order, a resumed or
+// partly present file) the file is read once more to hash it.
+func (p *poster) recordFileSums(ctx context.Context, post *Post, nzbGen nzb.NZBGenerator) {
+	sums, ok := post.hasher.Sum()
+	if !ok {
+		var err error
+		sums, err = filehash.HashReader(io.NewSectionReader(post.file, 0, post.filesize))
+		if err != nil {
+			slog.WarnContext(ctx, "Error hashing file", "error", err, "file", post.FilePath)
+			return
+		}
+	}
+
+	// The NZB generator keys files by their original name.
+	name := filepath.Base(post.FilePath)
+	if len(post.Articles) > 0 {
+		name = post.Articles[0].OriginalName
+	}
+	nzbGen.AddFileHash(name, sums.String())
+
+	p.fileSumsMu.Lock()
+	defer p.fileSumsMu.Unlock()
+	if p.fileSums == nil {
+		p.fileSums = make(map[string]filehash.Sums)
+	}
+	p.fileSums[post.FilePath] = sums
+}
+
+// FileHashes returns the checksums of every file posted so far, by path.
+func (p *poster) FileHashes() map[string]filehash.Sums {
+	p.fileSumsMu.Lock()
+	defer p.fileSumsMu.Unlock()
+	return maps.Clone(p.fileSums)
+}
@@ -0,0 +1,59 @@
+package poster
+
+import (
+	"math/rand"
+
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/manifest"
+)
+
+// identities returns the post_headers.from_pool identities, or nil when no
+// pool is configured.
+func (p *poster) identities() []string {
+	p.fromPoolInit.Do(func() {
+		if fp := p.cfg.PostHeaders.FromPool; fp != nil {
+			p.fromPool = fp.Build()
+		}
+	})
+	return p.fromPool
+}
+
+// fromSelection returns the from_pool selection policy, per_file by default.
+func (p *poster) fromSelection() config.FromSelection {
+	if fp := p.cfg.PostHeaders.FromPool; fp != nil && fp.Selection != "" {
+		return fp.Selection
+	}
+	return config.FromSelectionPerFile
+}
+
+// pickFrom returns an identity from the pool for a file or an article. With
+// the per_transfer selection every call returns the transfer's identity.
+func (p *poster) pickFrom(pool []string) string {
+	if p.fromSelection() != config.FromSelectionPerTransfer {
+		return pool[rand.Intn(len(pool))]
+	}
+
+	p.transferMu.Lock()
+	defer p.transferMu.Unlock()
+	if p.transferFrom == "" {
+		p.transferFrom = pool[rand.Intn(len(pool))]
+	}
+	return p.transferFrom
+}
+
+// adoptTransferFrom makes the From of a recovered file's manifest the
+// transfer's identity, so files posted after a restart keep the identity of
+// those posted before it. Files are recorded in posting order, so the first
+// file of a resumed transfer is recovered before any new file is posted.
+func (p *poster) adoptTransferFrom(recs []manifest.ArticleRecord) {
+	if len(recs) == 0 || recs[0].From == "" || len(p.identities()) == 0 ||
+		p.fromSelection() != config.FromSelectionPerTransfer {
+		return
+	}
+
+	p.transferMu.Lock()
+	defer p.transferMu.Unlock()
+	if p.transferFrom == "" {
+		p.transferFrom = recs[0].From
+	}
+}
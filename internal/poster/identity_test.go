@@ -0,0 +1,116 @@
+package poster
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/javi11/postie/internal/article"
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/manifest"
+	"github.com/javi11/postie/internal/mocks"
+)
+
+// recoveringSink reports a manifest for one file, as after a restart.
+type recoveringSink struct {
+	path string
+	recs []manifest.ArticleRecord
+}
+
+func (s *recoveringSink) RecordFile(context.Context, string, []*article.Article) error { return nil }
+
+func (s *recoveringSink) ExistingArticles(_ context.Context, filePath string) ([]manifest.ArticleRecord, bool, error) {
+	if filePath == s.path {
+		return s.recs, true, nil
+	}
+	return nil, false, nil
+}
+
+func addTestPost(t *testing.T, p *poster, filePath string, nzbGen *mocks.MockNZBGenerator) *Post {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	var postsInFlight sync.WaitGroup
+	var failedPosts atomic.Int64
+	postQueue := make(chan *Post, 1)
+
+	wg.Add(1)
+	require.NoError(t, p.addPost(context.Background(), filePath, "", 1, 1, &wg, &failedPosts, postQueue, nzbGen, &postsInFlight))
+	post := <-postQueue
+	t.Cleanup(func() { _ = post.file.Close() })
+	return post
+}
+
+func TestAddPost_FromPool(t *testing.T) {
+	pool := []string{"Anna Reed <anna@example.net>", "Ben Hall <ben@example.net>", "Sam King <sam@example.net>"}
+
+	newPoster := func(t *testing.T, selection config.FromSelection) (*poster, *mocks.MockNZBGenerator) {
+		ctrl := gomock.NewController(t)
+		cfg := createTestConfig()
+		cfg.ArticleSizeInBytes = 10
+		cfg.ObfuscationPolicy = config.ObfuscationPolicyFull
+		cfg.PostHeaders.FromPool = &config.FromPool{Identities: pool, Selection: selection}
+
+		mockJobProgress := mocks.NewMockJobProgress(ctrl)
+		mockJobProgress.EXPECT().AddProgress(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mocks.NewMockProgress(ctrl)).AnyTimes()
+		return &poster{cfg: cfg, jobProgress: mockJobProgress}, mocks.NewMockNZBGenerator(ctrl)
+	}
+
+	testFile := createTestFile(t, strings.Repeat("x", 200))
+	defer func() { _ = os.Remove(testFile) }()
+	otherFile := createTestFile(t, strings.Repeat("y", 200))
+	defer func() { _ = os.Remove(otherFile) }()
+
+	t.Run("per_file uses one pool identity per file", func(t *testing.T) {
+		p, nzbGen := newPoster(t, config.FromSelectionPerFile)
+		post := addTestPost(t, p, testFile, nzbGen)
+		from := post.Articles[0].From
+		assert.Contains(t, pool, from)
+		for _, art := range post.Articles {
+			assert.Equal(t, from, art.From)
+		}
+	})
+
+	t.Run("per_article picks from the pool for every article", func(t *testing.T) {
+		p, nzbGen := newPoster(t, config.FromSelectionPerArticle)
+		post := addTestPost(t, p, testFile, nzbGen)
+		used := make(map[string]bool)
+		for _, art := range post.Articles {
+			assert.Contains(t, pool, art.From)
+			used[art.From] = true
+		}
+		assert.Greater(t, len(used), 1, "20 articles should not all get the same identity")
+	})
+
+	t.Run("per_transfer keeps one identity across files", func(t *testing.T) {
+		p, nzbGen := newPoster(t, config.FromSelectionPerTransfer)
+		first := addTestPost(t, p, testFile, nzbGen)
+		second := addTestPost(t, p, otherFile, nzbGen)
+		from := first.Articles[0].From
+		assert.Contains(t, pool, from)
+		for _, art := range append(first.Articles, second.Articles...) {
+			assert.Equal(t, from, art.From)
+		}
+	})
+
+	t.Run("per_transfer adopts the identity of a recovered manifest", func(t *testing.T) {
+		p, nzbGen := newPoster(t, config.FromSelectionPerTransfer)
+		recovered := "Earlier Poster <earlier@example.net>"
+		p.manifestSink = &recoveringSink{path: testFile, recs: []manifest.ArticleRecord{
+			{MessageID: "m1", From: recovered, PartNumber: 1, TotalParts: 1, BodySize: 200, FileSize: 200},
+		}}
+
+		addTestPost(t, p, testFile, nzbGen)
+		post := addTestPost(t, p, otherFile, nzbGen)
+		for _, art := range post.Articles {
+			assert.Equal(t, recovered, art.From)
+		}
+	})
+}
@@ -62,6 +62,7 @@ func TestPost_RecordsManifestBeforePosting(t *testing.T) {
 
 	nzbGen := mocks.NewMockNZBGenerator(ctrl)
 	nzbGen.EXPECT().AddArticle(gomock.Any()).Return().AnyTimes()
+	nzbGen.EXPECT().AddFileHash(gomock.Any(), gomock.Any()).AnyTimes()
 
 	mockJobProgress := mocks.NewMockJobProgress(ctrl)
 	mockProgress := mocks.NewMockProgress(ctrl)
@@ -118,6 +119,7 @@ func TestPost_SinkErrorAbortsBeforePosting(t *testing.T) {
 
 	nzbGen := mocks.NewMockNZBGenerator(ctrl)
 	nzbGen.EXPECT().AddArticle(gomock.Any()).AnyTimes()
+	nzbGen.EXPECT().AddFileHash(gomock.Any(), gomock.Any()).AnyTimes()
 	mockJobProgress := mocks.NewMockJobProgress(ctrl)
 	mockProgress := mocks.NewMockProgress(ctrl)
 	mockJobProgress.EXPECT().AddProgress(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockProgress).AnyTimes()
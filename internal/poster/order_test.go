@@ -0,0 +1,207 @@
+package poster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/javi11/nntppool/v4"
+	"github.com/mnightingale/rapidyenc"
+	"go.uber.org/mock/gomock"
+
+	"github.com/javi11/postie/internal/article"
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/filehash"
+	"github.com/javi11/postie/internal/mocks"
+)
+
+func TestPost_ShuffledArticleOrderInterleavesFiles(t *testing.T) {
+	const filesN, partsN = 3, 10
+
+	var files []string
+	for i := 0; i < filesN; i++ {
+		f := createTestFile(t, strings.Repeat("x", partsN*100))
+		defer func() { _ = os.Remove(f) }()
+		files = append(files, f)
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	type sent struct {
+		file string
+		part int64
+	}
+	var mu sync.Mutex
+	var order []sent
+
+	mockPool := createMockNNTPClient(ctrl)
+	mockPool.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ nntppool.PostHeaders, _ any, meta rapidyenc.Meta) (*nntppool.PostResult, error) {
+			mu.Lock()
+			order = append(order, sent{file: meta.FileName, part: meta.PartNumber})
+			mu.Unlock()
+			return &nntppool.PostResult{}, nil
+		}).AnyTimes()
+
+	var added []*article.Article
+	nzbGen := mocks.NewMockNZBGenerator(ctrl)
+	nzbGen.EXPECT().AddArticle(gomock.Any()).Do(func(a *article.Article) {
+		mu.Lock()
+		added = append(added, a)
+		mu.Unlock()
+	}).AnyTimes()
+	// Shuffled reads arrive out of order, so the hashes come from a second pass.
+	hashes := make(map[string]string)
+	nzbGen.EXPECT().AddFileHash(gomock.Any(), gomock.Any()).Do(func(name, sums string) {
+		mu.Lock()
+		hashes[name] = sums
+		mu.Unlock()
+	}).Times(filesN)
+
+	mockJobProgress := mocks.NewMockJobProgress(ctrl)
+	mockProgress := mocks.NewMockProgress(ctrl)
+	mockJobProgress.EXPECT().AddProgress(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockProgress).AnyTimes()
+	mockJobProgress.EXPECT().FinishProgress(gomock.Any()).AnyTimes()
+	mockProgress.EXPECT().UpdateProgress(gomock.Any()).AnyTimes()
+	mockProgress.EXPECT().GetID().Return(uuid.New()).AnyTimes()
+
+	cfg := createTestConfig()
+	cfg.ArticleSizeInBytes = 100
+	cfg.ArticleOrder = config.ArticleOrderShuffled
+
+	checkCfg := createTestPostCheckConfig()
+	disabled := false
+	checkCfg.Enabled = &disabled
+
+	// One upload worker so articles are posted in exactly the queued order.
+	p := &poster{
+		cfg:              cfg,
+		checkCfg:         checkCfg,
+		uploadPool:       mockPool,
+		numOfConnections: 1,
+		stats:            &Stats{StartTime: time.Now()},
+		jobProgress:      mockJobProgress,
+	}
+	defer p.Close()
+
+	if err := p.Post(context.Background(), files, "", nzbGen); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	if len(order) != filesN*partsN {
+		t.Fatalf("posted %d articles, want %d", len(order), filesN*partsN)
+	}
+	if len(added) != filesN*partsN {
+		t.Fatalf("added %d articles to the NZB, want %d", len(added), filesN*partsN)
+	}
+	for _, f := range files {
+		want, err := filehash.HashFile(f)
+		if err != nil {
+			t.Fatalf("HashFile: %v", err)
+		}
+		if got := hashes[filepath.Base(f)]; got != want.String() {
+			t.Errorf("hash for %s = %q, want %q", filepath.Base(f), got, want.String())
+		}
+	}
+	seen := make(map[sent]bool)
+	switches := 0
+	for i, s := range order {
+		if seen[s] {
+			t.Fatalf("article %s part %d posted twice", s.file, s.part)
+		}
+		seen[s] = true
+		if i > 0 && order[i-1].file != s.file {
+			switches++
+		}
+	}
+	// Sequential posting switches file filesN-1 times.
+	if switches <= filesN-1 {
+		t.Errorf("articles were not interleaved across files: %v", order)
+	}
+}
+
+func TestPost_ShuffledArticleOrderPostsInWindows(t *testing.T) {
+	const shuffleWindow = 3
+	const filesN, partsN = 2*shuffleWindow + 3, 4
+
+	window := make(map[string]int)
+	var files []string
+	for i := 0; i < filesN; i++ {
+		f := createTestFile(t, strings.Repeat("x", partsN*100))
+		defer func() { _ = os.Remove(f) }()
+		files = append(files, f)
+		window[filepath.Base(f)] = i / shuffleWindow
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var mu sync.Mutex
+	var order []string
+
+	mockPool := createMockNNTPClient(ctrl)
+	mockPool.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ nntppool.PostHeaders, _ any, meta rapidyenc.Meta) (*nntppool.PostResult, error) {
+			mu.Lock()
+			order = append(order, meta.FileName)
+			mu.Unlock()
+			return &nntppool.PostResult{}, nil
+		}).AnyTimes()
+
+	nzbGen := mocks.NewMockNZBGenerator(ctrl)
+	nzbGen.EXPECT().AddArticle(gomock.Any()).AnyTimes()
+	nzbGen.EXPECT().AddFileHash(gomock.Any(), gomock.Any()).Times(filesN)
+
+	mockJobProgress := mocks.NewMockJobProgress(ctrl)
+	mockProgress := mocks.NewMockProgress(ctrl)
+	mockJobProgress.EXPECT().AddProgress(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mockProgress).AnyTimes()
+	mockJobProgress.EXPECT().FinishProgress(gomock.Any()).AnyTimes()
+	mockProgress.EXPECT().UpdateProgress(gomock.Any()).AnyTimes()
+	mockProgress.EXPECT().GetID().Return(uuid.New()).AnyTimes()
+
+	cfg := createTestConfig()
+	cfg.ArticleSizeInBytes = 100
+	cfg.ArticleOrder = config.ArticleOrderShuffled
+	cfg.ArticleShuffleWindow = shuffleWindow
+
+	checkCfg := createTestPostCheckConfig()
+	disabled := false
+	checkCfg.Enabled = &disabled
+
+	p := &poster{
+		cfg:              cfg,
+		checkCfg:         checkCfg,
+		uploadPool:       mockPool,
+		numOfConnections: 1,
+		stats:            &Stats{StartTime: time.Now()},
+		jobProgress:      mockJobProgress,
+	}
+	defer p.Close()
+
+	if err := p.Post(context.Background(), files, "", nzbGen); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	if len(order) != filesN*partsN {
+		t.Fatalf("posted %d articles, want %d", len(order), filesN*partsN)
+	}
+	// postPipelineDepth windows are posted at a time, so the last window
+	// starts only once one of the first two has been posted completely.
+	left := make(map[int]int)
+	for _, name := range order {
+		left[window[name]]++
+	}
+	for _, name := range order {
+		w := window[name]
+		if w == 2 && left[0] > 0 && left[1] > 0 {
+			t.Fatalf("window 2 started with windows 0 and 1 unfinished: %v", order)
+		}
+		left[w]--
+	}
+}
@@ -5,21 +5,20 @@ import (
 	"crypto/md5"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"math/rand"
-	"net"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"sync/atomic"
-	"syscall"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/javi11/nxg"
 	"github.com/javi11/postie/internal/article"
 	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/filehash"
 	"github.com/javi11/postie/internal/manifest"
 	"github.com/javi11/postie/internal/nzb"
 	"github.com/javi11/postie/internal/par2"
@@ -119,6 +118,11 @@ type Poster interface {
 	PostWithRelativePaths(ctx context.Context, files []string, rootDir string, nzbGen nzb.NZBGenerator, relativePaths map[string]string) error
 	// Stats returns a point-in-time snapshot of posting statistics
 	Stats() StatsSnapshot
+	// FileHashes returns the whole-file checksums of every file posted, by path
+	FileHashes() map[string]filehash.Sums
+	// AddChecksumFiles marks paths as generated checksum sidecars, posted
+	// under checksum_obfuscation_policy
+	AddChecksumFiles(paths []string)
 	// Close closes the poster
 	Close()
 }
@@ -148,6 +152,15 @@ type Post struct {
 	wg       *sync.WaitGroup
 	failed   *atomic.Int64
 	progress progress.Progress
+	// batch holds the posts sent together when article_order is shuffled.
+	// A batch Post carries no file or articles of its own.
+	batch []*Post
+	// hasher computes the whole-file checksums from the article bodies as
+	// they are read. It is set on a file's first post only, not on retries.
+	hasher *filehash.Hasher
+	// from is the From header rendered when the file was prepared. Retries
+	// give it to articles that have none.
+	from string
 }
 
 // FailedArticleInfo contains information about an article that failed verification
@@ -170,6 +183,7 @@ func (e *DeferredCheckError) Error() string {
 
 // articleWithBody holds an article with its pre-read body data for read-ahead buffering
 type articleWithBody struct {
+	post     *Post
 	article  *article.Article
 	body     []byte
 	poolBuf  []byte // original pooled buffer (may be larger than body)
@@ -258,6 +272,30 @@ type poster struct {
 	// manifestSink, when non-nil, records a durable manifest for each file
 	// before its articles are posted. Nil = standalone (no manifest recording).
 	manifestSink ManifestSink
+
+	// fileSums holds the checksums of every file posted, by path.
+	fileSumsMu sync.Mutex
+	fileSums   map[string]filehash.Sums
+
+	// checksumFiles holds the paths of the checksum sidecars generated for
+	// the transfer. Other files are posted under the regular policy even
+	// when they are named like sidecars.
+	checksumFilesMu sync.Mutex
+	checksumFiles   map[string]bool
+
+	// groupCursor is the next round-robin position of the each_article group
+	// policy. It is shared by all files so consecutive files continue the
+	// rotation instead of all starting on the first group.
+	groupCursor atomic.Uint64
+
+	// fromPool holds the post_headers.from_pool identities, built on first
+	// use. transferFrom is the identity of the per_transfer selection: one
+	// poster serves one transfer, so it is chosen once, or adopted from the
+	// manifest of a recovered file so reposts keep the same identity.
+	fromPoolInit sync.Once
+	fromPool     []string
+	transferMu   sync.Mutex
+	transferFrom string
 }
 
 // ensureWorkersStarted spins up the shared upload worker pool exactly once.
@@ -365,6 +403,25 @@ func (p *poster) immediateCheckEnabled() bool {
 	return p.manifestSink == nil && p.checkCfg.Enabled != nil && *p.checkCfg.Enabled
 }
 
+// AddChecksumFiles marks paths as checksum sidecars generated for the
+// transfer, so they are posted under checksum_obfuscation_policy.
+func (p *poster) AddChecksumFiles(paths []string) {
+	p.checksumFilesMu.Lock()
+	defer p.checksumFilesMu.Unlock()
+	if p.checksumFiles == nil {
+		p.checksumFiles = make(map[string]bool, len(paths))
+	}
+	for _, path := range paths {
+		p.checksumFiles[path] = true
+	}
+}
+
+func (p *poster) isChecksumFile(path string) bool {
+	p.checksumFilesMu.Lock()
+	defer p.checksumFilesMu.Unlock()
+	return p.checksumFiles[path]
+}
+
 func (p *poster) Close() {
 	p.closeOnce.Do(func() {
 		p.closed.Store(true)
@@ -464,8 +521,19 @@ func (p *poster) PostWithRelativePaths(
 	// other if more than one error fires before the main goroutine drains.
 	errChan := make(chan error, 4)
 
+	// In shuffled mode the files are collected in windows of shuffleWindow
+	// and each window is sent as one batch, so processBatch can interleave
+	// the articles of its files. A queued batch holds shuffleWindow open
+	// files, so the post queue holds fewer of them.
+	shuffled := p.cfg.ArticleOrder == config.ArticleOrderShuffled && len(files) > 1
+	shuffleWindow := p.cfg.ShuffleWindow()
+	postQueueSize := 100
+	if shuffled {
+		postQueueSize = max(postQueueSize/shuffleWindow, 1)
+	}
+
 	// Create channels for post and check queues
-	postQueue := make(chan *Post, 100)
+	postQueue := make(chan *Post, postQueueSize)
 	checkQueue := make(chan *Post, 100)
 
 	// Track posts in flight (initial + retries) so we close postQueue only once
@@ -487,11 +555,46 @@ func (p *poster) PostWithRelativePaths(
 		slog.InfoContext(ctx, "In-poster check disabled - verification deferred to durable service or skipped")
 	}
 
+	intake := postQueue
+	var collected chan *Post
+	if shuffled {
+		collected = make(chan *Post, shuffleWindow)
+		intake = collected
+	}
+	abandonCollected := func() {
+		if collected == nil {
+			return
+		}
+		close(collected)
+		for post := range collected {
+			p.abandonPost(ctx, post, &postsInFlight)
+		}
+	}
+	// sendBatch sends the collected posts as one batch. The batch itself is
+	// not tracked in postsInFlight; its members are.
+	sendBatch := func() error {
+		batch := &Post{}
+		for len(collected) > 0 {
+			batch.batch = append(batch.batch, <-collected)
+		}
+		if len(batch.batch) == 0 {
+			return nil
+		}
+		select {
+		case postQueue <- batch:
+			return nil
+		case <-ctx.Done():
+			p.abandonPost(ctx, batch, &postsInFlight)
+			return ctx.Err()
+		}
+	}
+
 	wg.Add(len(files))
 	for i, file := range files {
 		// Check if context is canceled before adding more posts
 		select {
 		case <-ctx.Done():
+			abandonCollected()
 			return ctx.Err()
 		default:
 		}
@@ -502,9 +605,17 @@ func (p *poster) PostWithRelativePaths(
 			displayName = relativePaths[file]
 		}
 
-		if err := p.addPost(ctx, file, displayName, i+1, len(files), &wg, &failedPosts, postQueue, nzbGen, &postsInFlight); err != nil {
+		if err := p.addPost(ctx, file, displayName, i+1, len(files), &wg, &failedPosts, intake, nzbGen, &postsInFlight); err != nil {
+			abandonCollected()
 			return fmt.Errorf("error adding file %s to posting queue: %w", file, err)
 		}
+
+		if shuffled && (len(collected) == shuffleWindow || i == len(files)-1) {
+			if err := sendBatch(); err != nil {
+				abandonCollected()
+				return err
+			}
+		}
 	}
 
 	// Close postQueue only when no posts are in-flight (initial + any retries
@@ -576,6 +687,13 @@ func (p *poster) PostWithRelativePaths(
 // and balances the in-flight queue counter and the per-file WaitGroup so
 // Post()'s completion goroutines can terminate instead of leaking.
 func (p *poster) abandonPost(ctx context.Context, post *Post, postsInFlight *sync.WaitGroup) {
+	if post.batch != nil {
+		for _, member := range post.batch {
+			p.abandonPost(ctx, member, postsInFlight)
+		}
+		return
+	}
+
 	post.mu.Lock()
 	if post.Status != PostStatusFailed {
 		post.Status = PostStatusCancelled
@@ -675,234 +793,319 @@ func (p *poster) postLoop(ctx context.Context, postQueue chan *Post, checkQueue
 // it. It always balances the post's accounting (postsInFlight, per-file wg,
 // file handle) and reports fatal errors through reportFatal.
 func (p *poster) processPost(ctx context.Context, post *Post, checkQueue chan<- *Post, nzbGen nzb.NZBGenerator, postsInFlight *sync.WaitGroup, reportFatal func(error)) {
+	if post.batch != nil {
+		p.processBatch(ctx, post.batch, checkQueue, nzbGen, postsInFlight, reportFatal)
+		return
+	}
+
 	// Set post status to Posting
 	post.mu.Lock()
 	post.Status = PostStatusPosting
 	post.mu.Unlock()
-	{
 
-		// Per-post context so the read-ahead goroutine always terminates
-		// when this post's block exits, even if the parent ctx is still
-		// alive (e.g. on the deferred-check non-fatal-error path).
-		postCtx, postCancel := context.WithCancel(ctx)
+	items := make([]postArticle, len(post.Articles))
+	for i, art := range post.Articles {
+		items[i] = postArticle{post: post, art: art}
+	}
+	results, streamErr := p.uploadArticles(ctx, items)
+	p.finishPost(ctx, post, results[post], streamErr, checkQueue, nzbGen, postsInFlight, reportFatal)
+}
 
-		// Create read-ahead channel (buffer 50 articles ahead to overlap I/O with network)
-		readAheadChan := make(chan articleWithBody, 50)
+// processBatch posts the articles of several files in one random order, so
+// files and parts are interleaved (article_order: shuffled), then finishes
+// each file as processPost does. The NZB is unaffected: the generator sorts
+// each file's segments by part number.
+func (p *poster) processBatch(ctx context.Context, posts []*Post, checkQueue chan<- *Post, nzbGen nzb.NZBGenerator, postsInFlight *sync.WaitGroup, reportFatal func(error)) {
+	var items []postArticle
+	for _, post := range posts {
+		post.mu.Lock()
+		post.Status = PostStatusPosting
+		post.mu.Unlock()
+		for _, art := range post.Articles {
+			items = append(items, postArticle{post: post, art: art})
+		}
+	}
+	rand.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
 
-		// Per-article reservation against the process-wide buffer budget; 0
-		// when no engine is configured (reservation is then a no-op).
-		reserveBytes := p.engine.PerArticleBytes()
+	results, streamErr := p.uploadArticles(ctx, items)
+	for _, post := range posts {
+		p.finishPost(ctx, post, results[post], streamErr, checkQueue, nzbGen, postsInFlight, reportFatal)
+	}
+}
 
-		var articleWG sync.WaitGroup
-		var firstErr atomic.Pointer[error]
-		recordErr := func(err error) {
-			if err == nil {
-				return
-			}
-			e := err
-			firstErr.CompareAndSwap(nil, &e)
-		}
+// postArticle is one article of a post, as sent by uploadArticles.
+type postArticle struct {
+	post *Post
+	art  *article.Article
+}
 
-		// Start read-ahead goroutine to pre-read article bodies
-		go func() {
-			defer close(readAheadChan)
-			for _, art := range post.Articles {
-				select {
-				case <-postCtx.Done():
-					return
-				default:
-					// Reserve global buffer budget before allocating/reading.
-					// This blocks (and so bounds total in-flight memory across
-					// all jobs) when the budget is exhausted.
-					if err := p.engine.ReserveBuffer(postCtx, reserveBytes); err != nil {
-						// Usually postCtx cancellation, but record it so a
-						// non-cancel failure can't silently truncate the post.
-						recordErr(fmt.Errorf("error reserving buffer for %s: %w", post.FilePath, err))
-						return
-					}
+// postResult is the outcome of a post's articles in uploadArticles.
+type postResult struct {
+	completed []*article.Article
+	err       error
+}
 
-					// Get buffer from pool, resize if needed. Outliers are dropped
-					// on Put (see putBodyBuffer) so they cannot inflate the pool.
-					poolBuf := bodyBufferPool.Get().([]byte)
-					if cap(poolBuf) < int(art.Size) {
-						poolBuf = make([]byte, art.Size)
-					}
-					body := poolBuf[:art.Size]
-
-					if _, err := readAtWithStallGuard(postCtx, post.file, body, art.Offset); err != nil {
-						putBodyBuffer(poolBuf)
-						p.engine.ReleaseBuffer(reserveBytes)
-						slog.ErrorContext(ctx, "Error pre-reading article", "error", err, "offset", art.Offset)
-						// Propagate the read failure: without this the
-						// remaining segments are silently dropped and the
-						// post is reported as fully posted with a
-						// truncated article set / NZB.
-						recordErr(fmt.Errorf("error pre-reading article at offset %d of %s: %w", art.Offset, post.FilePath, err))
-						return
-					}
+// uploadArticles posts items, in order, through the shared upload worker pool.
+// It returns the completed articles and first error of each post, and the
+// first error of any post. A post whose file cannot be read skips its
+// remaining items; the other posts' items are still sent.
+func (p *poster) uploadArticles(ctx context.Context, items []postArticle) (map[*Post]*postResult, error) {
+	results := make(map[*Post]*postResult)
+	for _, it := range items {
+		if results[it.post] == nil {
+			results[it.post] = &postResult{}
+		}
+	}
+	var resultsMu sync.Mutex
+
+	// Per-post context so the read-ahead goroutine always terminates
+	// when this post's block exits, even if the parent ctx is still
+	// alive (e.g. on the deferred-check non-fatal-error path).
+	postCtx, postCancel := context.WithCancel(ctx)
+	// Read-ahead goroutine has finished (readAheadChan is drained below)
+	// but cancel the per-post ctx so any straggler observes Done.
+	defer postCancel()
+
+	// Create read-ahead channel (buffer 50 articles ahead to overlap I/O with network)
+	readAheadChan := make(chan articleWithBody, 50)
+
+	// Per-article reservation against the process-wide buffer budget; 0
+	// when no engine is configured (reservation is then a no-op).
+	reserveBytes := p.engine.PerArticleBytes()
+
+	var articleWG sync.WaitGroup
+	var firstErr atomic.Pointer[error]
+	recordErr := func(post *Post, err error) {
+		if err == nil {
+			return
+		}
+		e := err
+		firstErr.CompareAndSwap(nil, &e)
+		resultsMu.Lock()
+		if r := results[post]; r.err == nil {
+			r.err = err
+		}
+		resultsMu.Unlock()
+	}
 
-					select {
-					case readAheadChan <- articleWithBody{article: art, body: body, poolBuf: poolBuf, reserved: reserveBytes}:
-					case <-postCtx.Done():
-						putBodyBuffer(poolBuf)
-						p.engine.ReleaseBuffer(reserveBytes)
-						return
-					}
-				}
-			}
-		}()
-
-		// Submit articles to the shared upload worker pool. Total posting
-		// concurrency across all in-flight Post() calls is capped at
-		// p.numOfConnections; this prevents the goroutine + buffer explosion
-		// that caused OOM under MaxConcurrentUploads > 1 with PAR2 enabled.
-		//
-		// We intentionally do NOT cancel sibling articles when one fails
-		// (e.g. TLS timeout) — they should continue.
-
-		// Collect completed articles for batch NZB addition (reduces lock contention)
-		var completedArticles []*article.Article
-		var completedMu sync.Mutex
-
-		for artWithBody := range readAheadChan {
-			art := artWithBody.article
-			body := artWithBody.body
-			poolBuf := artWithBody.poolBuf
-			reserved := artWithBody.reserved
-
-			articleWG.Add(1)
-			job := uploadJob{
-				ctx:      postCtx,
-				art:      art,
-				body:     body,
-				poolBuf:  poolBuf,
-				reserved: reserved,
-				done: func(err error) {
-					defer articleWG.Done()
-					if err != nil {
-						recordErr(err)
-						return
-					}
-					post.progress.UpdateProgress(int64(art.Size))
-					completedMu.Lock()
-					completedArticles = append(completedArticles, art)
-					completedMu.Unlock()
-				},
+	// Start read-ahead goroutine to pre-read article bodies
+	go func() {
+		defer close(readAheadChan)
+		unreadable := make(map[*Post]bool)
+		for _, it := range items {
+			post, art := it.post, it.art
+			if unreadable[post] {
+				continue
 			}
-
 			select {
-			case p.uploadJobs <- job:
-			case <-p.shutdown:
-				putBodyBuffer(poolBuf)
-				p.engine.ReleaseBuffer(reserved)
-				recordErr(ErrPosterClosed)
-				articleWG.Done()
 			case <-postCtx.Done():
-				putBodyBuffer(poolBuf)
-				p.engine.ReleaseBuffer(reserved)
-				recordErr(postCtx.Err())
-				articleWG.Done()
-			}
-		}
+				return
+			default:
+				// Reserve global buffer budget before allocating/reading.
+				// This blocks (and so bounds total in-flight memory across
+				// all jobs) when the budget is exhausted.
+				if err := p.engine.ReserveBuffer(postCtx, reserveBytes); err != nil {
+					// Usually postCtx cancellation, but record it so a
+					// non-cancel failure can't silently truncate the post.
+					recordErr(post, fmt.Errorf("error reserving buffer for %s: %w", post.FilePath, err))
+					return
+				}
 
-		// Wait for all submitted articles to finish.
-		articleWG.Wait()
+				// Get buffer from pool, resize if needed. Outliers are dropped
+				// on Put (see putBodyBuffer) so they cannot inflate the pool.
+				poolBuf := bodyBufferPool.Get().([]byte)
+				if cap(poolBuf) < int(art.Size) {
+					poolBuf = make([]byte, art.Size)
+				}
+				body := poolBuf[:art.Size]
+
+				if _, err := readAtWithStallGuard(postCtx, post.file, body, art.Offset); err != nil {
+					putBodyBuffer(poolBuf)
+					p.engine.ReleaseBuffer(reserveBytes)
+					slog.ErrorContext(ctx, "Error pre-reading article", "error", err, "offset", art.Offset)
+					// Propagate the read failure: without this the
+					// remaining segments are silently dropped and the
+					// post is reported as fully posted with a
+					// truncated article set / NZB.
+					recordErr(post, fmt.Errorf("error pre-reading article at offset %d of %s: %w", art.Offset, post.FilePath, err))
+					unreadable[post] = true
+					continue
+				}
+				post.hasher.Write(art.Offset, body)
 
-		// Collect first error (if any). Matches the previous WithFirstError
-		// semantic from conc/pool: other workers continued; we surface the
-		// first failure to the caller.
-		var errs error
-		if ePtr := firstErr.Load(); ePtr != nil {
-			errs = *ePtr
+				select {
+				case readAheadChan <- articleWithBody{post: post, article: art, body: body, poolBuf: poolBuf, reserved: reserveBytes}:
+				case <-postCtx.Done():
+					putBodyBuffer(poolBuf)
+					p.engine.ReleaseBuffer(reserveBytes)
+					return
+				}
+			}
 		}
+	}()
 
-		// Read-ahead goroutine has finished (readAheadChan was drained above)
-		// but cancel the per-post ctx so any straggler observes Done.
-		postCancel()
-
-		// Batch add completed articles to NZB generator (reduces lock contention)
-		for _, art := range completedArticles {
-			nzbGen.AddArticle(art)
+	// Submit articles to the shared upload worker pool. Total posting
+	// concurrency across all in-flight Post() calls is capped at
+	// p.numOfConnections; this prevents the goroutine + buffer explosion
+	// that caused OOM under MaxConcurrentUploads > 1 with PAR2 enabled.
+	//
+	// We intentionally do NOT cancel sibling articles when one fails
+	// (e.g. TLS timeout) — they should continue.
+	for artWithBody := range readAheadChan {
+		post := artWithBody.post
+		art := artWithBody.article
+		body := artWithBody.body
+		poolBuf := artWithBody.poolBuf
+		reserved := artWithBody.reserved
+
+		articleWG.Add(1)
+		job := uploadJob{
+			ctx:      postCtx,
+			art:      art,
+			body:     body,
+			poolBuf:  poolBuf,
+			reserved: reserved,
+			done: func(err error) {
+				defer articleWG.Done()
+				if err != nil {
+					recordErr(post, err)
+					return
+				}
+				post.progress.UpdateProgress(int64(art.Size))
+				// Collect completed articles for batch NZB addition (reduces lock contention)
+				resultsMu.Lock()
+				results[post].completed = append(results[post].completed, art)
+				resultsMu.Unlock()
+			},
 		}
 
-		p.jobProgress.FinishProgress(post.progress.GetID())
-
-		if errs != nil {
-			post.mu.Lock()
-			if errors.Is(errs, context.Canceled) {
-				post.Status = PostStatusCancelled
-				post.Error = fmt.Errorf("posting cancelled: %v", errs)
-			} else {
-				post.Status = PostStatusFailed
-				post.Error = fmt.Errorf("failed to post articles: %v", errs)
-			}
-			post.mu.Unlock()
+		select {
+		case p.uploadJobs <- job:
+		case <-p.shutdown:
+			putBodyBuffer(poolBuf)
+			p.engine.ReleaseBuffer(reserved)
+			recordErr(post, ErrPosterClosed)
+			articleWG.Done()
+		case <-postCtx.Done():
+			putBodyBuffer(poolBuf)
+			p.engine.ReleaseBuffer(reserved)
+			recordErr(post, postCtx.Err())
+			articleWG.Done()
+		}
+	}
 
-			// Mark this post as done in the queue tracking
-			postsInFlight.Done()
+	// Wait for all submitted articles to finish.
+	articleWG.Wait()
 
-			// Close the underlying file so the descriptor isn't leaked on
-			// failure. Long-running daemons that hit intermittent NNTP
-			// errors otherwise exhaust the process fd ulimit and stall.
-			if post.file != nil {
-				if cerr := post.file.Close(); cerr != nil {
-					slog.WarnContext(ctx, "Error closing file handle on post failure", "error", cerr, "file", post.FilePath)
-				}
-			}
+	// Collect first error (if any). Matches the previous WithFirstError
+	// semantic from conc/pool: other workers continued; we surface the
+	// first failure to the caller.
+	var streamErr error
+	if ePtr := firstErr.Load(); ePtr != nil {
+		streamErr = *ePtr
+	}
+	return results, streamErr
+}
 
-			// Stop intake of further posts. A cancellation is not reported
-			// (Post() observes ctx.Done itself); a real failure is sent to
-			// errChan (at most once) BEFORE wg.Done so Post() cannot wake
-			// on `done` before the error is observable.
-			if errors.Is(errs, context.Canceled) {
-				reportFatal(nil)
-			} else {
-				reportFatal(fmt.Errorf("failed to post file %s after %d retries: %v", post.FilePath, p.cfg.MaxRetries, errs))
-			}
+// finishPost completes a post after uploadArticles: it adds the posted
+// articles to the NZB and then fails the post, hands it to the check queue
+// (immediate-check mode) or completes it. A post with articles left unsent
+// because another post of the same batch failed fails with that error.
+func (p *poster) finishPost(ctx context.Context, post *Post, result *postResult, streamErr error, checkQueue chan<- *Post, nzbGen nzb.NZBGenerator, postsInFlight *sync.WaitGroup, reportFatal func(error)) {
+	if result == nil {
+		result = &postResult{}
+	}
+	errs := result.err
+	if errs == nil && len(result.completed) < len(post.Articles) {
+		errs = streamErr
+	}
 
-			// Balance the per-file WaitGroup so Post()'s wg.Wait() can
-			// complete even when the parent ctx is still alive (e.g. an
-			// internal post timeout); otherwise Post() blocks forever.
-			post.wg.Done()
+	// Batch add completed articles to NZB generator (reduces lock contention)
+	for _, art := range result.completed {
+		nzbGen.AddArticle(art)
+	}
 
-			return
-		}
+	p.jobProgress.FinishProgress(post.progress.GetID())
 
+	if errs != nil {
 		post.mu.Lock()
-		post.Status = PostStatusPosted
+		if errors.Is(errs, context.Canceled) {
+			post.Status = PostStatusCancelled
+			post.Error = fmt.Errorf("posting cancelled: %v", errs)
+		} else {
+			post.Status = PostStatusFailed
+			post.Error = fmt.Errorf("failed to post articles: %v", errs)
+		}
 		post.mu.Unlock()
 
-		if p.immediateCheckEnabled() {
-			// Guard the send: if checkLoop has already exited (e.g. on a
-			// verify error) the buffered checkQueue can fill and this send
-			// would block forever, leaking postLoop and every queued post.
-			select {
-			case checkQueue <- post:
-			case <-ctx.Done():
-				if post.file != nil {
-					if cerr := post.file.Close(); cerr != nil {
-						slog.WarnContext(ctx, "Error closing file after ctx canceled during check enqueue", "error", cerr, "file", post.FilePath)
-					}
-				}
-				postsInFlight.Done()
-				post.wg.Done()
+		// Mark this post as done in the queue tracking
+		postsInFlight.Done()
+
+		// Close the underlying file so the descriptor isn't leaked on
+		// failure. Long-running daemons that hit intermittent NNTP
+		// errors otherwise exhaust the process fd ulimit and stall.
+		if post.file != nil {
+			if cerr := post.file.Close(); cerr != nil {
+				slog.WarnContext(ctx, "Error closing file handle on post failure", "error", cerr, "file", post.FilePath)
 			}
+		}
 
-			return
+		// Stop intake of further posts. A cancellation is not reported
+		// (Post() observes ctx.Done itself); a real failure is sent to
+		// errChan (at most once) BEFORE wg.Done so Post() cannot wake
+		// on `done` before the error is observable.
+		if errors.Is(errs, context.Canceled) {
+			reportFatal(nil)
+		} else {
+			reportFatal(fmt.Errorf("failed to post file %s after %d retries: %v", post.FilePath, p.cfg.MaxRetries, errs))
 		}
 
-		// Post complete without check - mark as done in queue tracking
-		postsInFlight.Done()
+		// Balance the per-file WaitGroup so Post()'s wg.Wait() can
+		// complete even when the parent ctx is still alive (e.g. an
+		// internal post timeout); otherwise Post() blocks forever.
+		post.wg.Done()
 
-		// Close file
-		if post.file != nil {
-			if err := post.file.Close(); err != nil {
-				slog.WarnContext(ctx, "Error closing file handle", "error", err, "file", post.FilePath)
+		return
+	}
+
+	post.mu.Lock()
+	post.Status = PostStatusPosted
+	post.mu.Unlock()
+
+	if post.hasher != nil {
+		p.recordFileSums(ctx, post, nzbGen)
+	}
+
+	if p.immediateCheckEnabled() {
+		// Guard the send: if checkLoop has already exited (e.g. on a
+		// verify error) the buffered checkQueue can fill and this send
+		// would block forever, leaking postLoop and every queued post.
+		select {
+		case checkQueue <- post:
+		case <-ctx.Done():
+			if post.file != nil {
+				if cerr := post.file.Close(); cerr != nil {
+					slog.WarnContext(ctx, "Error closing file after ctx canceled during check enqueue", "error", cerr, "file", post.FilePath)
+				}
 			}
+			postsInFlight.Done()
+			post.wg.Done()
 		}
 
-		post.wg.Done()
+		return
 	}
+
+	// Post complete without check - mark as done in queue tracking
+	postsInFlight.Done()
+
+	// Close file
+	if post.file != nil {
+		if err := post.file.Close(); err != nil {
+			slog.WarnContext(ctx, "Error closing file handle", "error", err, "file", post.FilePath)
+		}
+	}
+
+	post.wg.Done()
 }
 
 // checkLoop processes posts from the check queue
@@ -1031,11 +1234,10 @@ func (p *poster) checkLoop(ctx context.Context, checkQueue chan *Post, postQueue
 					for _, art := range failedArticles {
 						// Ensure required NNTP headers are non-empty
 						if art.From == "" {
-							if defaultFrom := p.cfg.PostHeaders.DefaultFrom; defaultFrom != "" {
-								art.From = defaultFrom
-							} else if from, err := article.GenerateFrom(); err == nil {
-								art.From = from
+							if post.from == "" {
+								post.from, _ = article.GenerateFrom()
 							}
+							art.From = post.from
 						}
 						if art.Subject == "" {
 							art.Subject = article.GenerateRandomSubject()
@@ -1056,6 +1258,7 @@ func (p *poster) checkLoop(ctx context.Context, checkQueue chan *Post, postQueue
 						failed:   post.failed,
 						Retries:  post.Retries,
 						progress: p.jobProgress.AddProgress(uuid.New(), fmt.Sprintf("%s (retry)", filepath.Base(post.FilePath)), progress.ProgressTypeUploading, post.filesize),
+						from:     post.from,
 					}
 
 					slog.InfoContext(ctx,
@@ -1226,6 +1429,57 @@ func (p *poster) checkLoop(ctx context.Context, checkQueue chan *Post, postQueue
 	}
 }
 
+// headerTemplates are the parsed subject, From and custom header templates.
+type headerTemplates struct {
+	subject          *article.Template
+	from             *article.Template // nil = no default_from
+	customNames      []string
+	custom           []*article.Template
+	customPerArticle bool
+}
+
+// parseHeaderTemplates parses the configured header templates. Validate
+// rejects bad templates, so errors only surface for unvalidated configs.
+func (p *poster) parseHeaderTemplates() (*headerTemplates, error) {
+	h := p.cfg.PostHeaders
+	subjectTmpl := h.SubjectTemplate
+	if subjectTmpl == "" {
+		subjectTmpl = article.DefaultSubjectTemplate
+	}
+
+	var (
+		t   headerTemplates
+		err error
+	)
+	if t.subject, err = article.ParseTemplate(subjectTmpl); err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+	if h.DefaultFrom != "" {
+		if t.from, err = article.ParseTemplate(h.DefaultFrom); err != nil {
+			return nil, fmt.Errorf("invalid default_from template: %w", err)
+		}
+	}
+	for _, ch := range h.CustomHeaders {
+		tmpl, err := article.ParseTemplate(ch.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for header %s: %w", ch.Name, err)
+		}
+		t.customNames = append(t.customNames, ch.Name)
+		t.custom = append(t.custom, tmpl)
+		t.customPerArticle = t.customPerArticle || tmpl.PerArticle()
+	}
+	return &t, nil
+}
+
+// expandCustom renders the custom headers for one article.
+func (t *headerTemplates) expandCustom(v article.TemplateVars) map[string]string {
+	headers := make(map[string]string, len(t.custom))
+	for i, tmpl := range t.custom {
+		headers[t.customNames[i]] = tmpl.Expand(v)
+	}
+	return headers
+}
+
 // addPost adds a file to the posting queue
 // displayName is the name to use in the subject (e.g., "Folder/subfolder/file.mp4")
 // If displayName is empty, the filename is used
@@ -1235,9 +1489,11 @@ func (p *poster) checkLoop(ctx context.Context, checkQueue chan *Post, postQueue
 // missing ones. It never rewrites the manifest. If every article is already
 // present the post is enqueued with no articles and completes immediately.
 func (p *poster) addRecoveredPost(ctx context.Context, filePath string, file *os.File, fileInfo os.FileInfo, recs []manifest.ArticleRecord, wg *sync.WaitGroup, failedPosts *atomic.Int64, postQueue chan<- *Post, postsInFlight *sync.WaitGroup) error {
+	p.adoptTransferFrom(recs)
+
 	all := make([]*article.Article, 0, len(recs))
 	for _, r := range recs {
-		all = append(all, articleFromRecord(r))
+		all = append(all, manifest.ArticleFromRecord(r))
 	}
 	missing := p.filterMissing(ctx, all)
 
@@ -1253,6 +1509,8 @@ func (p *poster) addRecoveredPost(ctx context.Context, filePath string, file *os
 		wg:       wg,
 		failed:   failedPosts,
 		progress: p.jobProgress.AddProgress(uuid.New(), filepath.Base(filePath), progress.ProgressTypeUploading, fileInfo.Size()),
+		hasher:   filehash.NewHasher(fileInfo.Size()),
+		from:     firstFrom(all),
 	}
 
 	postsInFlight.Add(1)
@@ -1337,9 +1595,10 @@ func (p *poster) addPost(ctx context.Context, filePath string, displayName strin
 		}
 	}
 
-	// Calculate number of segments
-	segmentSize := p.cfg.ArticleSizeInBytes
-	numSegments := int((fileInfo.Size() + int64(segmentSize) - 1) / int64(segmentSize))
+	// Calculate the segments: their sizes depend on the file size rules and
+	// the article size jitter
+	segmentSizes := p.articleSizes(fileInfo.Size())
+	numSegments := len(segmentSizes)
 	nxgHeader := nxg.GenerateNXGHeader(int64(numSegments), 0)
 
 	groups := make([]string, 0)
@@ -1356,45 +1615,95 @@ func (p *poster) addPost(ctx context.Context, filePath string, displayName strin
 		for _, group := range p.cfg.Groups {
 			groups = append(groups, group.Name)
 		}
+	case config.GroupPolicyEachArticle:
+		if len(p.cfg.Groups) == 0 {
+			return fmt.Errorf("group policy is %q but no newsgroups are configured", config.GroupPolicyEachArticle)
+		}
 	}
 
-	from, err := article.GenerateFrom()
-	if err != nil {
-		return fmt.Errorf("error generating from header: %w", err)
+	// nextArticleGroups returns the groups of one article: the file's groups,
+	// or one group per article with the each_article policy. The one-group
+	// slices are shared between articles and never modified.
+	var articleGroups [][]string
+	if p.cfg.GroupPolicy == config.GroupPolicyEachArticle {
+		articleGroups = make([][]string, len(p.cfg.Groups))
+		for i, group := range p.cfg.Groups {
+			articleGroups[i] = []string{group.Name}
+		}
+	}
+	nextArticleGroups := func() []string {
+		if articleGroups == nil {
+			return groups
+		}
+		if p.cfg.GroupRotation == config.GroupRotationRandom {
+			return articleGroups[rand.Intn(len(articleGroups))]
+		}
+		return articleGroups[(p.groupCursor.Add(1)-1)%uint64(len(articleGroups))]
 	}
 
-	customHeaders := make(map[string]string)
-	if len(p.cfg.PostHeaders.CustomHeaders) > 0 {
-		for _, v := range p.cfg.PostHeaders.CustomHeaders {
-			customHeaders[v.Name] = v.Value
+	// From is a random poster per file unless post_headers picks one from
+	// the from_pool (per transfer, file or article) or renders default_from.
+	identities := p.identities()
+	fromPerArticle := len(identities) > 0 && p.fromSelection() == config.FromSelectionPerArticle
+	var from string
+	if len(identities) > 0 {
+		from = p.pickFrom(identities)
+	} else {
+		from, err = article.GenerateFrom()
+		if err != nil {
+			return fmt.Errorf("error generating from header: %w", err)
 		}
 	}
 
+	// Subject, From and custom header values are templates evaluated per
+	// article; see config.PostHeaders for the tokens.
+	headerTmpl, err := p.parseHeaderTemplates()
+	if err != nil {
+		return err
+	}
+	vars := article.NewTemplateVars(filePath, displayName, fileNumber, totalFiles, fileInfo.Size(), time.Now())
+	vars.Parts = numSegments
+
+	// Header values without {part} are shared by every article of the file.
+	var customHeaders map[string]string
+	if !headerTmpl.customPerArticle {
+		customHeaders = headerTmpl.expandCustom(vars)
+	}
+
 	partType := nxg.PartTypeData
 	if par2.IsPar2File(filePath) {
 		partType = nxg.PartTypePar2
 	}
 
-	// Create articles for each segment
-	articles := make([]*article.Article, 0, numSegments)
-	for i := range numSegments {
-		offset := int64(i) * int64(segmentSize)
-		size := int64(segmentSize)
-		if offset+size > fileInfo.Size() {
-			size = fileInfo.Size() - offset
+	// hmac Message-IDs are keyed by the file's content and each part's
+	// offset and size, so the same file split the same way always maps to
+	// the same IDs.
+	var contentHash []byte
+	if p.cfg.MessageIDFormat == config.MessageIDFormatHMAC {
+		contentHash, err = article.FileContentHash(io.NewSectionReader(file, 0, fileInfo.Size()))
+		if err != nil {
+			return fmt.Errorf("error hashing file for message IDs: %w", err)
 		}
+	}
 
+	// Create articles for each segment
+	articles := make([]*article.Article, 0, numSegments)
+	var offset int64
+	for i, size := range segmentSizes {
 		partNumber := i + 1
 
 		messageID := ""
-		if p.cfg.MessageIDFormat == config.MessageIDFormatRandom {
+		switch p.cfg.MessageIDFormat {
+		case config.MessageIDFormatRandom:
 			msgID, err := article.GenerateMessageID()
 			if err != nil {
 				return fmt.Errorf("error generating message ID: %w", err)
 			}
 
 			messageID = msgID
-		} else {
+		case config.MessageIDFormatHMAC:
+			messageID = article.DeriveMessageID(p.cfg.MessageIDSecret, contentHash, partNumber, offset, size, p.cfg.MessageIDDomain)
+		default:
 			msgID, err := nxgHeader.GenerateSegmentID(partType, int64(partNumber))
 			if err != nil {
 				return fmt.Errorf("error generating message ID: %w", err)
@@ -1403,13 +1712,9 @@ func (p *poster) addPost(ctx context.Context, filePath string, displayName strin
 			messageID = msgID
 		}
 
-		// Use displayName if provided, otherwise fall back to filename
 		fileName := filepath.Base(filePath)
-		subjectName := fileName
-		if displayName != "" {
-			subjectName = displayName
-		}
-		subject := article.GenerateSubject(fileNumber, totalFiles, subjectName, partNumber, numSegments)
+		vars.Part = partNumber
+		subject := headerTmpl.subject.Expand(vars)
 		originalSubject := subject
 
 		var fName string
@@ -1417,6 +1722,8 @@ func (p *poster) addPost(ctx context.Context, filePath string, displayName strin
 		obfuscationPolicy := p.cfg.ObfuscationPolicy
 		if par2.IsPar2File(filePath) {
 			obfuscationPolicy = p.cfg.Par2ObfuscationPolicy
+		} else if p.isChecksumFile(filePath) {
+			obfuscationPolicy = p.cfg.ChecksumPolicy()
 		}
 
 		switch obfuscationPolicy {
@@ -1430,7 +1737,7 @@ func (p *poster) addPost(ctx context.Context, filePath string, displayName strin
 			_, _ = fmt.Fprintf(hasher, "%s%d", fileName, partNumber)
 			fName = fmt.Sprintf("%x", hasher.Sum(nil))
 
-			if p.cfg.MessageIDFormat == config.MessageIDFormatRandom {
+			if p.cfg.MessageIDFormat == config.MessageIDFormatRandom || p.cfg.MessageIDFormat == config.MessageIDFormatHMAC {
 				hasher := md5.New()
 				_, _ = hasher.Write([]byte(subject))
 				subject = fmt.Sprintf("%x", hasher.Sum(nil))
@@ -1442,9 +1749,12 @@ func (p *poster) addPost(ctx context.Context, filePath string, displayName strin
 			}
 		}
 
-		defaultFrom := p.cfg.PostHeaders.DefaultFrom
-		if defaultFrom != "" {
-			from = defaultFrom
+		if headerTmpl.from != nil {
+			from = headerTmpl.from.Expand(vars)
+		} else if len(identities) > 0 {
+			if fromPerArticle {
+				from = p.pickFrom(identities)
+			}
 		} else if p.cfg.ObfuscationPolicy == config.ObfuscationPolicyFull {
 			from, err = article.GenerateFrom()
 			if err != nil {
@@ -1474,19 +1784,24 @@ func (p *poster) addPost(ctx context.Context, filePath string, displayName strin
 			date = &rd
 		}
 
+		artHeaders := customHeaders
+		if headerTmpl.customPerArticle {
+			artHeaders = headerTmpl.expandCustom(vars)
+		}
+
 		art := article.New(
 			messageID,
 			subject,
 			originalSubject,
 			from,
-			groups,
+			nextArticleGroups(),
 			partNumber,
 			numSegments,
 			fileInfo.Size(),
 			fName,
 			fileNumber,
 			fileName,
-			customHeaders,
+			artHeaders,
 		)
 
 		if date != nil {
@@ -1499,6 +1814,7 @@ func (p *poster) addPost(ctx context.Context, filePath string, displayName strin
 
 		art.Offset = offset
 		art.Size = uint64(size)
+		offset += size
 
 		articles = append(articles, art)
 	}
@@ -1513,15 +1829,38 @@ func (p *poster) addPost(ctx context.Context, filePath string, displayName strin
 		}
 	}
 
+	// hmac Message-IDs of a file that was posted before are known in
+	// advance: STAT them and post only the missing articles. The present
+	// ones go straight to the NZB.
+	toPost := articles
+	if p.cfg.MessageIDFormat == config.MessageIDFormatHMAC {
+		toPost = p.filterMissing(ctx, articles)
+		if present := len(articles) - len(toPost); present > 0 {
+			missing := make(map[*article.Article]struct{}, len(toPost))
+			for _, art := range toPost {
+				missing[art] = struct{}{}
+			}
+			for _, art := range articles {
+				if _, ok := missing[art]; !ok {
+					nzbGen.AddArticle(art)
+				}
+			}
+			slog.InfoContext(ctx, "Articles already posted; posting only missing articles",
+				"file", filePath, "total", len(articles), "missing", len(toPost))
+		}
+	}
+
 	post := &Post{
 		FilePath: filePath,
-		Articles: articles,
+		Articles: toPost,
 		Status:   PostStatusPending,
 		file:     file,
 		filesize: fileInfo.Size(),
 		wg:       wg,
 		failed:   failedPosts,
 		progress: p.jobProgress.AddProgress(uuid.New(), filepath.Base(filePath), progress.ProgressTypeUploading, fileInfo.Size()),
+		hasher:   filehash.NewHasher(fileInfo.Size()),
+		from:     firstFrom(articles),
 	}
 
 	// Track this post as in-flight until it's sent to the queue
@@ -1541,6 +1880,16 @@ func (p *poster) addPost(ctx context.Context, filePath string, displayName strin
 	}
 }
 
+// firstFrom returns the From header of the first article that has one.
+func firstFrom(articles []*article.Article) string {
+	for _, art := range articles {
+		if art.From != "" {
+			return art.From
+		}
+	}
+	return ""
+}
+
 // postArticle posts an article to Usenet (reads body from file)
 func (p *poster) postArticle(ctx context.Context, article *article.Article, file *os.File) error {
 	// Check if we should pause before posting
@@ -1570,31 +1919,11 @@ func (p *poster) postArticleWithBody(ctx context.Context, art *article.Article,
 	return postYenc(ctx, p.uploadPool, p.throttle, p.stats, art, body)
 }
 
-// isStaleConnError reports whether err looks like a stale pooled connection:
-// the remote server silently closed the TCP socket (broken pipe / connection
-// reset) or the connection went half-open and the read deadline fired before
-// any response arrived (i/o timeout). In all of these cases the pool discards
-// the bad connection, so retrying picks a fresh one.
-//
-// True context cancellation / deadline exceeded on the caller's postCtx is
-// short-circuited at the call site before this is invoked, so any net.Error
-// timeout reaching here is a per-socket read deadline rather than the
-// per-article envelope.
+// isStaleConnError reports whether err looks like a stale pooled connection.
+// See pool.IsStaleConnError, which the upload pool also uses to score provider
+// health.
 func isStaleConnError(err error) bool {
-	if err == nil {
-		return false
-	}
-	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
-		return true
-	}
-	var ne net.Error
-	if errors.As(err, &ne) && ne.Timeout() {
-		return true
-	}
-	msg := err.Error()
-	return strings.Contains(msg, "broken pipe") ||
-		strings.Contains(msg, "connection reset by peer") ||
-		strings.Contains(msg, "i/o timeout")
+	return pool.IsStaleConnError(err)
 }
 
 // checkArticle checks if an article exists using the check pool
@@ -17,6 +17,7 @@ import (
 	"github.com/javi11/nntppool/v4"
 	"github.com/javi11/postie/internal/article"
 	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/filehash"
 	"github.com/javi11/postie/internal/mocks"
 	"github.com/javi11/postie/internal/pausable"
 	"github.com/javi11/postie/internal/pool"
@@ -122,6 +123,9 @@ func TestPost(t *testing.T) {
 
 		nzbGen := mocks.NewMockNZBGenerator(ctrl)
 		nzbGen.EXPECT().AddArticle(gomock.Any()).Return().AnyTimes()
+		wantSums, err := filehash.HashFile(testFile)
+		require.NoError(t, err)
+		nzbGen.EXPECT().AddFileHash(filepath.Base(testFile), wantSums.String()).Times(1)
 
 		// Mock the job progress
 		mockJobProgress := mocks.NewMockJobProgress(ctrl)
@@ -146,9 +150,10 @@ func TestPost(t *testing.T) {
 			jobProgress: mockJobProgress,
 		}
 
-		err := p.Post(ctx, []string{testFile}, "", nzbGen)
+		err = p.Post(ctx, []string{testFile}, "", nzbGen)
 
 		assert.NoError(t, err)
+		assert.Equal(t, wantSums, p.FileHashes()[testFile])
 
 		// Close after test completes
 		p.Close()
@@ -191,6 +196,7 @@ func TestPost(t *testing.T) {
 
 		nzbGen := mocks.NewMockNZBGenerator(ctrl)
 		nzbGen.EXPECT().AddArticle(gomock.Any()).Return().AnyTimes()
+		nzbGen.EXPECT().AddFileHash(gomock.Any(), gomock.Any()).AnyTimes()
 
 		// Create poster with check disabled to simplify test
 		checkCfg := createTestPostCheckConfig()
@@ -235,6 +241,7 @@ func TestPost(t *testing.T) {
 
 		nzbGen := mocks.NewMockNZBGenerator(ctrl)
 		nzbGen.EXPECT().AddArticle(gomock.Any()).Return().AnyTimes()
+		nzbGen.EXPECT().AddFileHash(gomock.Any(), gomock.Any()).AnyTimes()
 
 		// Mock the job progress
 		mockJobProgress := mocks.NewMockJobProgress(ctrl)
@@ -290,6 +297,7 @@ func TestPost(t *testing.T) {
 
 		nzbGen := mocks.NewMockNZBGenerator(ctrl)
 		nzbGen.EXPECT().AddArticle(gomock.Any()).Return().AnyTimes()
+		nzbGen.EXPECT().AddFileHash(gomock.Any(), gomock.Any()).AnyTimes()
 
 		// Mock the job progress
 		mockJobProgress := mocks.NewMockJobProgress(ctrl)
@@ -345,6 +353,7 @@ func TestPost(t *testing.T) {
 
 		nzbGen := mocks.NewMockNZBGenerator(ctrl)
 		nzbGen.EXPECT().AddArticle(gomock.Any()).Return().AnyTimes()
+		nzbGen.EXPECT().AddFileHash(gomock.Any(), gomock.Any()).AnyTimes()
 
 		// Mock the job progress
 		mockJobProgress := mocks.NewMockJobProgress(ctrl)
@@ -854,6 +863,256 @@ func TestAddPost(t *testing.T) {
 		}
 	})
 
+	t.Run("templated headers", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		testFile := createTestFile(t, strings.Repeat("x", 250))
+		defer func() { _ = os.Remove(testFile) }()
+
+		cfg := createTestConfig()
+		cfg.ArticleSizeInBytes = 100
+		cfg.PostHeaders.SubjectTemplate = `{folder} [{0filenum}/{files}] - "{filename}" yEnc ({part}/{parts}) {size}`
+		cfg.PostHeaders.DefaultFrom = "poster-{filenum}/{files} <poster@example.com>"
+		cfg.PostHeaders.CustomHeaders = []config.CustomHeader{
+			{Name: "X-Static", Value: "{relpath}"},
+			{Name: "X-Part", Value: "{0part}"},
+		}
+
+		mockJobProgress := mocks.NewMockJobProgress(ctrl)
+		mockJobProgress.EXPECT().AddProgress(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mocks.NewMockProgress(ctrl)).AnyTimes()
+
+		p := &poster{cfg: cfg, jobProgress: mockJobProgress}
+
+		var wg sync.WaitGroup
+		var postsInFlight sync.WaitGroup
+		var failedPosts atomic.Int64
+		postQueue := make(chan *Post, 10)
+
+		wg.Add(1)
+		err := p.addPost(context.Background(), testFile, "Show/S01/ep.mkv", 3, 12, &wg, &failedPosts, postQueue, mocks.NewMockNZBGenerator(ctrl), &postsInFlight)
+		require.NoError(t, err)
+
+		post := <-postQueue
+		defer func() { _ = post.file.Close() }()
+		require.Len(t, post.Articles, 3)
+
+		name := filepath.Base(testFile)
+		art := post.Articles[1]
+		assert.Equal(t, `Show [03/12] - "`+name+`" yEnc (2/3) 250`, art.Subject)
+		assert.Equal(t, art.Subject, art.OriginalSubject)
+		assert.Equal(t, "poster-3/12 <poster@example.com>", art.From)
+		// Retries reuse the From rendered here, with the real file count.
+		assert.Equal(t, art.From, post.from)
+		assert.Equal(t, "Show/S01/ep.mkv", art.CustomHeaders["X-Static"])
+		assert.Equal(t, "2", art.CustomHeaders["X-Part"])
+		assert.Equal(t, "3", post.Articles[2].CustomHeaders["X-Part"])
+	})
+
+	t.Run("each_article group policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		testFile := createTestFile(t, strings.Repeat("x", 450))
+		defer func() { _ = os.Remove(testFile) }()
+
+		enabled := true
+		cfg := createTestConfig()
+		cfg.ArticleSizeInBytes = 100
+		cfg.GroupPolicy = config.GroupPolicyEachArticle
+		cfg.Groups = []config.NewsgroupConfig{
+			{Name: "alt.a", Enabled: &enabled},
+			{Name: "alt.b", Enabled: &enabled},
+		}
+
+		mockJobProgress := mocks.NewMockJobProgress(ctrl)
+		mockJobProgress.EXPECT().AddProgress(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mocks.NewMockProgress(ctrl)).AnyTimes()
+
+		p := &poster{cfg: cfg, jobProgress: mockJobProgress}
+
+		var wg sync.WaitGroup
+		var postsInFlight sync.WaitGroup
+		var failedPosts atomic.Int64
+		postQueue := make(chan *Post, 10)
+
+		wg.Add(1)
+		err := p.addPost(context.Background(), testFile, "", 1, 1, &wg, &failedPosts, postQueue, mocks.NewMockNZBGenerator(ctrl), &postsInFlight)
+		require.NoError(t, err)
+
+		post := <-postQueue
+		defer func() { _ = post.file.Close() }()
+		require.Len(t, post.Articles, 5)
+		for i, art := range post.Articles {
+			want := []string{"alt.a", "alt.b"}[i%2]
+			assert.Equal(t, []string{want}, art.Groups, "article %d", i+1)
+		}
+	})
+
+	t.Run("generated checksum sidecars follow the checksum obfuscation policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		sfvFile := filepath.Join(t.TempDir(), "show.sfv")
+		require.NoError(t, os.WriteFile(sfvFile, []byte("show.mkv 3610A686\n"), 0644))
+
+		cfg := createTestConfig()
+		cfg.ObfuscationPolicy = config.ObfuscationPolicyFull
+		cfg.Par2ObfuscationPolicy = config.ObfuscationPolicyNone
+
+		mockJobProgress := mocks.NewMockJobProgress(ctrl)
+		mockJobProgress.EXPECT().AddProgress(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mocks.NewMockProgress(ctrl)).AnyTimes()
+
+		p := &poster{cfg: cfg, jobProgress: mockJobProgress}
+
+		subjectFor := func() string {
+			var wg sync.WaitGroup
+			var postsInFlight sync.WaitGroup
+			var failedPosts atomic.Int64
+			postQueue := make(chan *Post, 1)
+
+			wg.Add(1)
+			err := p.addPost(context.Background(), sfvFile, "", 1, 1, &wg, &failedPosts, postQueue, mocks.NewMockNZBGenerator(ctrl), &postsInFlight)
+			require.NoError(t, err)
+
+			post := <-postQueue
+			_ = post.file.Close()
+			require.Len(t, post.Articles, 1)
+			return post.Articles[0].Subject
+		}
+
+		// A .sfv that was not generated for the transfer is a regular file.
+		assert.NotContains(t, subjectFor(), "show.sfv")
+
+		// Unset, the checksum policy falls back to the PAR2 policy.
+		p.AddChecksumFiles([]string{sfvFile})
+		assert.Contains(t, subjectFor(), "show.sfv")
+
+		p.cfg.ChecksumObfuscationPolicy = config.ObfuscationPolicyFull
+		assert.NotContains(t, subjectFor(), "show.sfv")
+	})
+
+	t.Run("hmac message IDs skip articles already posted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		content := strings.Repeat("x", 450)
+		testFile := createTestFile(t, content)
+		defer func() { _ = os.Remove(testFile) }()
+
+		cfg := createTestConfig()
+		cfg.ArticleSizeInBytes = 100
+		cfg.MessageIDFormat = config.MessageIDFormatHMAC
+		cfg.MessageIDSecret = "secret"
+		cfg.MessageIDDomain = "example.net"
+
+		hash, err := article.FileContentHash(strings.NewReader(content))
+		require.NoError(t, err)
+		missingID := article.DeriveMessageID("secret", hash, 3, 200, 100, "example.net")
+
+		verifyPool := mocks.NewMockNNTPClient(ctrl)
+		verifyPool.EXPECT().StatMany(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			statManyStub(map[string]error{missingID: errors.New("430 no such article")}),
+		).AnyTimes()
+
+		var added []string
+		nzbGen := mocks.NewMockNZBGenerator(ctrl)
+		nzbGen.EXPECT().AddArticle(gomock.Any()).Do(func(a *article.Article) {
+			added = append(added, a.MessageID)
+		}).Times(4)
+
+		mockJobProgress := mocks.NewMockJobProgress(ctrl)
+		mockJobProgress.EXPECT().AddProgress(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mocks.NewMockProgress(ctrl)).AnyTimes()
+
+		p := &poster{cfg: cfg, verifyPool: verifyPool, jobProgress: mockJobProgress}
+
+		var wg sync.WaitGroup
+		var postsInFlight sync.WaitGroup
+		var failedPosts atomic.Int64
+		postQueue := make(chan *Post, 10)
+
+		wg.Add(1)
+		err = p.addPost(context.Background(), testFile, "", 1, 1, &wg, &failedPosts, postQueue, nzbGen, &postsInFlight)
+		require.NoError(t, err)
+
+		post := <-postQueue
+		defer func() { _ = post.file.Close() }()
+		require.Len(t, post.Articles, 1)
+		assert.Equal(t, missingID, post.Articles[0].MessageID)
+		assert.Equal(t, 3, post.Articles[0].PartNumber)
+		for i, part := range []int{1, 2, 4, 5} {
+			offset := int64(part-1) * 100
+			size := min(100, int64(len(content))-offset)
+			assert.Equal(t, article.DeriveMessageID("secret", hash, part, offset, size, "example.net"), added[i])
+		}
+	})
+
+	t.Run("hmac message IDs change with the article size", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		testFile := createTestFile(t, strings.Repeat("x", 450))
+		defer func() { _ = os.Remove(testFile) }()
+
+		cfg := createTestConfig()
+		cfg.MessageIDFormat = config.MessageIDFormatHMAC
+		cfg.MessageIDSecret = "secret"
+		cfg.MessageIDDomain = "example.net"
+
+		// Everything posted so far is on the server.
+		posted := make(map[string]bool)
+		verifyPool := mocks.NewMockNNTPClient(ctrl)
+		verifyPool.EXPECT().StatMany(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, ids []string, _ nntppool.StatManyOptions) <-chan nntppool.StatManyResult {
+				out := make(chan nntppool.StatManyResult, len(ids))
+				for _, id := range ids {
+					res := nntppool.StatManyResult{MessageID: id, Result: &nntppool.StatResult{MessageID: id}}
+					if !posted[id] {
+						res = nntppool.StatManyResult{MessageID: id, Err: errors.New("430 no such article")}
+					}
+					out <- res
+				}
+				close(out)
+				return out
+			},
+		).AnyTimes()
+
+		mockJobProgress := mocks.NewMockJobProgress(ctrl)
+		mockJobProgress.EXPECT().AddProgress(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(mocks.NewMockProgress(ctrl)).AnyTimes()
+
+		p := &poster{cfg: cfg, verifyPool: verifyPool, jobProgress: mockJobProgress}
+
+		postWithSize := func(size uint64) *Post {
+			p.cfg.ArticleSizeInBytes = size
+
+			var wg sync.WaitGroup
+			var postsInFlight sync.WaitGroup
+			var failedPosts atomic.Int64
+			postQueue := make(chan *Post, 1)
+
+			wg.Add(1)
+			err := p.addPost(context.Background(), testFile, "", 1, 1, &wg, &failedPosts, postQueue, mocks.NewMockNZBGenerator(ctrl), &postsInFlight)
+			require.NoError(t, err)
+
+			post := <-postQueue
+			_ = post.file.Close()
+			return post
+		}
+
+		first := postWithSize(100)
+		require.Len(t, first.Articles, 5)
+		for _, art := range first.Articles {
+			posted[art.MessageID] = true
+		}
+
+		// Reposted with another article size, no part matches one already
+		// posted, so every article is posted again.
+		second := postWithSize(150)
+		require.Len(t, second.Articles, 3)
+		for _, art := range second.Articles {
+			assert.False(t, posted[art.MessageID], "part %d reused a message ID", art.PartNumber)
+		}
+	})
+
 	t.Run("file not found", func(t *testing.T) {
 		p := &poster{
 			cfg: createTestConfig(),
@@ -1001,6 +1260,7 @@ func TestPostIntegration(t *testing.T) {
 		// Mock NZB generator
 		nzbGen := mocks.NewMockNZBGenerator(ctrl)
 		nzbGen.EXPECT().AddArticle(gomock.Any()).Return()
+		nzbGen.EXPECT().AddFileHash(gomock.Any(), gomock.Any()).AnyTimes()
 
 		// Create poster with realistic config
 		cfg := createTestConfig()
@@ -1389,6 +1649,7 @@ func TestSharedUploadPool(t *testing.T) {
 
 		nzbGen := mocks.NewMockNZBGenerator(ctrl)
 		nzbGen.EXPECT().AddArticle(gomock.Any()).Return().AnyTimes()
+		nzbGen.EXPECT().AddFileHash(gomock.Any(), gomock.Any()).AnyTimes()
 
 		mockJobProgress := mocks.NewMockJobProgress(ctrl)
 		mockProgress := mocks.NewMockProgress(ctrl)
@@ -1450,6 +1711,7 @@ func TestSharedUploadPool(t *testing.T) {
 
 		nzbGen := mocks.NewMockNZBGenerator(ctrl)
 		nzbGen.EXPECT().AddArticle(gomock.Any()).Return().AnyTimes()
+		nzbGen.EXPECT().AddFileHash(gomock.Any(), gomock.Any()).AnyTimes()
 
 		mockJobProgress := mocks.NewMockJobProgress(ctrl)
 		mockProgress := mocks.NewMockProgress(ctrl)
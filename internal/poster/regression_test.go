@@ -52,6 +52,7 @@ func newRegressionMocks(t *testing.T) (*gomock.Controller, *mocks.MockNNTPClient
 
 	nzbGen := mocks.NewMockNZBGenerator(ctrl)
 	nzbGen.EXPECT().AddArticle(gomock.Any()).Return().AnyTimes()
+	nzbGen.EXPECT().AddFileHash(gomock.Any(), gomock.Any()).AnyTimes()
 
 	return ctrl, mockPool, nzbGen, mockJobProgress, mockProgress
 }
@@ -21,7 +21,8 @@ import (
 // retrying up to 3 times on stale pooled connections. It is the shared posting
 // primitive used by both the normal upload path (postArticleWithBody) and the
 // durable re-post path (Reposter.Repost); extracting it keeps the exact same
-// Message-ID and header handling for re-posts.
+// Message-ID and header handling for re-posts. The upload pool decides the
+// transport per server: POST, or TAKETHIS for streaming servers.
 func postYenc(ctx context.Context, uploadPool pool.NNTPClient, throttle *Throttle, stats *Stats, art *article.Article, body []byte) error {
 	headers := nntppool.PostHeaders{
 		From:       art.From,
@@ -58,22 +59,53 @@ func postYenc(ctx context.Context, uploadPool pool.NNTPClient, throttle *Throttl
 		throttle.Wait(int64(art.Size))
 	}
 
+	// When every upload provider is over its daily quota, posting pauses
+	// until the earliest reset instead of failing the job.
+	for {
+		err := sendYenc(ctx, uploadPool, headers, body, meta, art.MessageID)
+		var quotaErr *pool.QuotaExhaustedError
+		if !errors.As(err, &quotaErr) {
+			if err != nil {
+				return err
+			}
+			break
+		}
+		if err := waitForQuotaReset(ctx, quotaErr.ResetAt); err != nil {
+			return err
+		}
+	}
+
+	if stats != nil {
+		stats.mu.Lock()
+		stats.ArticlesPosted++
+		stats.BytesPosted += int64(art.Size)
+		stats.mu.Unlock()
+	}
+
+	return nil
+}
+
+// sendYenc posts one article, retrying up to 3 times on stale pooled
+// connections. Each attempt is bounded by a 2-minute timeout.
+func sendYenc(ctx context.Context, uploadPool pool.NNTPClient, headers nntppool.PostHeaders, body []byte, meta rapidyenc.Meta, messageID string) error {
 	// Post article with timeout to prevent indefinite TLS hangs.
 	postCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
 
 	// Retry on stale pooled connection (see isStaleConnError). bytes.NewReader
 	// is cheap to recreate so the body is fully re-readable on each attempt.
+	// It must stay seekable: the upload pool rewinds it to fail a post over
+	// to another upload provider.
 	var lastErr error
 	for attempt := range 3 {
 		if attempt > 0 {
 			slog.WarnContext(ctx, "Retrying article post after stale pooled connection",
-				"messageID", art.MessageID, "attempt", attempt, "prevErr", lastErr.Error())
+				"messageID", messageID, "attempt", attempt, "prevErr", lastErr.Error())
 		}
 
 		_, lastErr = uploadPool.PostYenc(postCtx, headers, bytes.NewReader(body), meta)
 		if lastErr == nil {
-			break
+			return nil
 		}
 		if errors.Is(lastErr, context.Canceled) || errors.Is(lastErr, context.DeadlineExceeded) {
 			if ctx.Err() != nil {
@@ -91,38 +123,27 @@ func postYenc(ctx context.Context, uploadPool pool.NNTPClient, throttle *Throttl
 		}
 	}
 
-	if lastErr != nil {
-		return fmt.Errorf("error posting article: %w", lastErr)
-	}
+	return fmt.Errorf("error posting article: %w", lastErr)
+}
 
-	if stats != nil {
-		stats.mu.Lock()
-		stats.ArticlesPosted++
-		stats.BytesPosted += int64(art.Size)
-		stats.mu.Unlock()
+// waitForQuotaReset blocks until resetAt, the next daily quota reset, or until
+// ctx ends.
+func waitForQuotaReset(ctx context.Context, resetAt time.Time) error {
+	wait := time.Until(resetAt)
+	slog.WarnContext(ctx, "All upload providers exhausted their daily quota, pausing posting until the reset",
+		"resetAt", resetAt, "wait", wait)
+	if wait <= 0 {
+		return nil
 	}
 
-	return nil
-}
-
-// articleFromRecord reconstructs an article from a manifest record so it can be
-// re-posted byte-for-byte with its original Message-ID and headers.
-func articleFromRecord(rec manifest.ArticleRecord) *article.Article {
-	return &article.Article{
-		MessageID:       rec.MessageID,
-		Subject:         rec.Subject,
-		OriginalSubject: rec.OriginalSubject,
-		From:            rec.From,
-		Groups:          rec.Groups,
-		PartNumber:      rec.PartNumber,
-		TotalParts:      rec.TotalParts,
-		FileName:        rec.FileName,
-		Date:            rec.Date,
-		Offset:          rec.Offset,
-		Size:            rec.BodySize,
-		FileSize:        rec.FileSize,
-		CustomHeaders:   rec.CustomHeaders,
-		XNxgHeader:      rec.XNxgHeader,
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return context.Canceled
+	case <-timer.C:
+		slog.InfoContext(ctx, "Daily quota reset, resuming posting")
+		return nil
 	}
 }
 
@@ -183,7 +204,7 @@ func (r *Reposter) Repost(ctx context.Context, rec manifest.ArticleRecord) error
 	}
 	defer r.engine.ReleaseWorker()
 
-	return postYenc(ctx, r.uploadPool, r.throttle, r.stats, articleFromRecord(rec), body)
+	return postYenc(ctx, r.uploadPool, r.throttle, r.stats, manifest.ArticleFromRecord(rec), body)
 }
 
 // Stats returns a snapshot of re-post statistics.
@@ -2,17 +2,21 @@ package poster
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/javi11/nntppool/v4"
 	"github.com/mnightingale/rapidyenc"
 	"go.uber.org/mock/gomock"
 
+	"github.com/javi11/postie/internal/article"
 	"github.com/javi11/postie/internal/manifest"
 	"github.com/javi11/postie/internal/mocks"
+	"github.com/javi11/postie/internal/pool"
 )
 
 func TestReposter_RepostReusesMessageIDAndBody(t *testing.T) {
@@ -117,3 +121,38 @@ func TestReposter_NoPoolErrors(t *testing.T) {
 		t.Error("expected error when reposter has no upload pool")
 	}
 }
+
+func TestPostYenc_PausesUntilQuotaReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	resetAt := time.Now().Add(100 * time.Millisecond)
+	mockPool := mocks.NewMockNNTPClient(ctrl)
+	gomock.InOrder(
+		mockPool.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, &pool.QuotaExhaustedError{ResetAt: resetAt}),
+		mockPool.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&nntppool.PostResult{}, nil),
+	)
+
+	stats := &Stats{}
+	art := &article.Article{MessageID: "part1@postie", Size: 10}
+	if err := postYenc(context.Background(), mockPool, nil, stats, art, make([]byte, 10)); err != nil {
+		t.Fatalf("postYenc: %v", err)
+	}
+	if time.Now().Before(resetAt) {
+		t.Error("the article was posted again before the quota reset")
+	}
+	if stats.ArticlesPosted != 1 {
+		t.Errorf("ArticlesPosted = %d, want 1", stats.ArticlesPosted)
+	}
+
+	// Cancelling the job ends the pause
+	mockPool.EXPECT().PostYenc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, &pool.QuotaExhaustedError{ResetAt: time.Now().Add(time.Hour)})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := postYenc(ctx, mockPool, nil, stats, art, make([]byte, 10)); !errors.Is(err, context.Canceled) {
+		t.Errorf("postYenc after cancel = %v, want context.Canceled", err)
+	}
+}
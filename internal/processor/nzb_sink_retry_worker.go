@@ -0,0 +1,179 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/nzbsink"
+	"github.com/javi11/postie/internal/queue"
+)
+
+// nzbSinkQueue is the subset of *queue.Queue used by NzbSinkRetryWorker.
+type nzbSinkQueue interface {
+	GetSinkDeliveriesForRetry(ctx context.Context, limit int) ([]queue.SinkDelivery, error)
+	UpdateSinkStatus(ctx context.Context, itemID, sink, nzbPath, status string, retryCount int, lastError string, nextRetryAt *time.Time, firstFailureAt *time.Time) error
+	MarkSinkCompleted(ctx context.Context, itemID, sink, nzbPath string) error
+	MarkSinkFailed(ctx context.Context, itemID, sink, lastError string) error
+}
+
+// NzbSinkRetryWorker retries failed NZB sink deliveries with exponential
+// backoff, one delivery per sink and completed item.
+type NzbSinkRetryWorker struct {
+	queue              nzbSinkQueue
+	sinksConfig        config.NzbSinksConfig
+	ctx                context.Context
+	cancel             context.CancelFunc
+	retryCheckInterval time.Duration
+	retry              retryPolicy
+}
+
+// NewNzbSinkRetryWorker creates a new NZB sink retry worker
+func NewNzbSinkRetryWorker(ctx context.Context, queue nzbSinkQueue, sinksConfig config.NzbSinksConfig) *NzbSinkRetryWorker {
+	workerCtx, cancel := context.WithCancel(ctx)
+
+	// Use configured retry check interval, default to 1 minute
+	checkInterval := sinksConfig.RetryCheckInterval.ToDuration()
+	if checkInterval <= 0 {
+		checkInterval = 1 * time.Minute
+	}
+
+	return &NzbSinkRetryWorker{
+		queue:              queue,
+		sinksConfig:        sinksConfig,
+		ctx:                workerCtx,
+		cancel:             cancel,
+		retryCheckInterval: checkInterval,
+		retry: retryPolicy{
+			retryDelay:       sinksConfig.RetryDelay.ToDuration(),
+			maxBackoff:       sinksConfig.MaxBackoff.ToDuration(),
+			maxRetries:       sinksConfig.MaxRetries,
+			maxRetryDuration: sinksConfig.MaxRetryDuration.ToDuration(),
+		},
+	}
+}
+
+// Start begins the retry worker loop
+func (w *NzbSinkRetryWorker) Start() {
+	if len(w.sinksConfig.Sinks) == 0 {
+		slog.Info("NZB sink retry worker not started (no sinks configured)")
+		return
+	}
+
+	slog.Info("Starting NZB sink retry worker", "checkInterval", w.retryCheckInterval)
+
+	go w.run()
+}
+
+// Stop stops the retry worker
+func (w *NzbSinkRetryWorker) Stop() {
+	slog.Info("Stopping NZB sink retry worker")
+	w.cancel()
+}
+
+// run is the main worker loop
+func (w *NzbSinkRetryWorker) run() {
+	ticker := time.NewTicker(w.retryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			slog.Info("NZB sink retry worker stopped")
+			return
+		case <-ticker.C:
+			w.processRetries()
+		}
+	}
+}
+
+// processRetries checks for and processes pending sink deliveries
+func (w *NzbSinkRetryWorker) processRetries() {
+	ctx := w.ctx
+
+	// Limit to 10 at a time to avoid overwhelming the sinks
+	deliveries, err := w.queue.GetSinkDeliveriesForRetry(ctx, 10)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get NZB sink deliveries for retry", "error", err)
+		return
+	}
+
+	if len(deliveries) == 0 {
+		return
+	}
+
+	slog.InfoContext(ctx, "Processing NZB sink retries", "count", len(deliveries))
+
+	for _, d := range deliveries {
+		if err := w.deliver(ctx, d); err != nil {
+			slog.ErrorContext(ctx, "NZB sink retry failed", "itemID", d.CompletedItemID, "sink", d.Sink, "nzbPath", d.NzbPath, "error", err)
+		}
+	}
+}
+
+// deliver retries one delivery and records its outcome
+func (w *NzbSinkRetryWorker) deliver(ctx context.Context, d queue.SinkDelivery) error {
+	sinkCfg, ok := w.sinksConfig.Sink(d.Sink)
+	if !ok {
+		// The sink was removed from the configuration since the first attempt
+		errorMsg := "sink is no longer configured"
+		if err := w.queue.MarkSinkFailed(ctx, d.CompletedItemID, d.Sink, errorMsg); err != nil {
+			slog.ErrorContext(ctx, "Failed to mark NZB sink delivery as failed", "itemID", d.CompletedItemID, "sink", d.Sink, "error", err)
+		}
+		return fmt.Errorf("%s", errorMsg)
+	}
+	sink, err := nzbsink.New(sinkCfg)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Retrying NZB sink delivery", "itemID", d.CompletedItemID, "sink", d.Sink, "nzbPath", d.NzbPath)
+
+	deliverCtx := ctx
+	if timeout := w.sinksConfig.Timeout.ToDuration(); timeout > 0 {
+		var cancel context.CancelFunc
+		deliverCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if err := sink.Deliver(deliverCtx, d.NzbPath); err != nil {
+		newRetryCount := d.RetryCount + 1
+
+		// Determine first failure time (use existing or set to now)
+		now := time.Now()
+		firstFailureAt := now
+		if d.FirstFailureAt != nil {
+			firstFailureAt = *d.FirstFailureAt
+		}
+
+		// Check if we should continue retrying
+		if reason := w.retry.failureReason(firstFailureAt, newRetryCount); reason != "" {
+			if updateErr := w.queue.MarkSinkFailed(ctx, d.CompletedItemID, d.Sink, err.Error()); updateErr != nil {
+				slog.ErrorContext(ctx, "Failed to mark NZB sink delivery as permanently failed", "itemID", d.CompletedItemID, "sink", d.Sink, "error", updateErr)
+			}
+			slog.WarnContext(ctx, "NZB sink delivery permanently failed", "itemID", d.CompletedItemID, "sink", d.Sink, "retries", newRetryCount, "reason", reason)
+			return fmt.Errorf("delivery failed permanently (%s): %w", reason, err)
+		}
+
+		// Calculate next retry with exponential backoff (capped)
+		backoffDelay := w.retry.backoff(newRetryCount)
+		nextRetry := now.Add(backoffDelay)
+
+		if updateErr := w.queue.UpdateSinkStatus(ctx, d.CompletedItemID, d.Sink, d.NzbPath, "pending_retry", newRetryCount, err.Error(), &nextRetry, &firstFailureAt); updateErr != nil {
+			slog.ErrorContext(ctx, "Failed to update NZB sink status for retry", "itemID", d.CompletedItemID, "sink", d.Sink, "error", updateErr)
+		}
+
+		slog.InfoContext(ctx, "Scheduled NZB sink retry", "itemID", d.CompletedItemID, "sink", d.Sink, "retryCount", newRetryCount, "nextRetry", nextRetry, "backoff", backoffDelay)
+		return fmt.Errorf("delivery failed, will retry in %v: %w", backoffDelay, err)
+	}
+
+	if err := w.queue.MarkSinkCompleted(ctx, d.CompletedItemID, d.Sink, d.NzbPath); err != nil {
+		slog.ErrorContext(ctx, "Failed to mark NZB sink delivery as completed", "itemID", d.CompletedItemID, "sink", d.Sink, "error", err)
+		return err
+	}
+
+	slog.InfoContext(ctx, "NZB delivered to sink on retry", "itemID", d.CompletedItemID, "sink", d.Sink)
+	return nil
+}
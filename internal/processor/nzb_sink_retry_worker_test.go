@@ -0,0 +1,101 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/queue"
+)
+
+// fakeSinkQueue is a hand-rolled implementation of nzbSinkQueue for tests.
+type fakeSinkQueue struct {
+	deliveries []queue.SinkDelivery
+	completed  []string
+	failed     []string
+	retried    []int // retry counts passed to UpdateSinkStatus
+}
+
+func (f *fakeSinkQueue) GetSinkDeliveriesForRetry(_ context.Context, _ int) ([]queue.SinkDelivery, error) {
+	return f.deliveries, nil
+}
+
+func (f *fakeSinkQueue) UpdateSinkStatus(_ context.Context, _, _, _, _ string, retryCount int, _ string, _ *time.Time, _ *time.Time) error {
+	f.retried = append(f.retried, retryCount)
+	return nil
+}
+
+func (f *fakeSinkQueue) MarkSinkCompleted(_ context.Context, _, sink, _ string) error {
+	f.completed = append(f.completed, sink)
+	return nil
+}
+
+func (f *fakeSinkQueue) MarkSinkFailed(_ context.Context, _, sink, _ string) error {
+	f.failed = append(f.failed, sink)
+	return nil
+}
+
+func TestNzbSinkRetryWorker_ProcessRetries(t *testing.T) {
+	dir := t.TempDir()
+	nzbPath := filepath.Join(dir, "job.nzb")
+	if err := os.WriteFile(nzbPath, []byte("<nzb/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// A regular file where the broken sink expects a directory.
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.WriteFile(blocked, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.NzbSinksConfig{
+		Sinks: []config.NzbSinkConfig{
+			{Name: "ok", Type: config.NzbSinkTypeDirectory, Directories: []string{filepath.Join(dir, "out")}},
+			{Name: "broken", Type: config.NzbSinkTypeDirectory, Directories: []string{filepath.Join(blocked, "out")}},
+		},
+		MaxRetries: 3,
+		RetryDelay: config.Duration("1s"),
+	}
+	firstFailure := time.Now().Add(-time.Minute)
+	q := &fakeSinkQueue{deliveries: []queue.SinkDelivery{
+		{CompletedItemID: "a", Sink: "ok", NzbPath: nzbPath, RetryCount: 1, FirstFailureAt: &firstFailure},
+		{CompletedItemID: "b", Sink: "broken", NzbPath: nzbPath, RetryCount: 0, FirstFailureAt: &firstFailure},
+		{CompletedItemID: "c", Sink: "broken", NzbPath: nzbPath, RetryCount: 2, FirstFailureAt: &firstFailure},
+		{CompletedItemID: "d", Sink: "removed", NzbPath: nzbPath},
+	}}
+
+	w := NewNzbSinkRetryWorker(context.Background(), q, cfg)
+	defer w.Stop()
+	w.processRetries()
+
+	if len(q.completed) != 1 || q.completed[0] != "ok" {
+		t.Errorf("completed = %v, want [ok]", q.completed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "job.nzb")); err != nil {
+		t.Errorf("NZB not delivered: %v", err)
+	}
+	if len(q.retried) != 1 || q.retried[0] != 1 {
+		t.Errorf("retried = %v, want one retry with count 1", q.retried)
+	}
+	// c reached max_retries and d's sink is no longer configured.
+	if len(q.failed) != 2 || q.failed[0] != "broken" || q.failed[1] != "removed" {
+		t.Errorf("failed = %v, want [broken removed]", q.failed)
+	}
+}
+
+func TestNzbSinkRetryWorker_CalculateBackoff(t *testing.T) {
+	w := NewNzbSinkRetryWorker(context.Background(), &fakeSinkQueue{}, config.NzbSinksConfig{
+		RetryDelay: config.Duration("30s"),
+		MaxBackoff: config.Duration("5m"),
+	})
+	defer w.Stop()
+
+	if got := w.retry.backoff(1); got != time.Minute {
+		t.Errorf("backoff(1) = %v, want 1m", got)
+	}
+	if got := w.retry.backoff(10); got != 5*time.Minute {
+		t.Errorf("backoff(10) = %v, want capped at 5m", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +16,8 @@ import (
 	"encoding/json"
 
 	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/filehash"
+	"github.com/javi11/postie/internal/nzb"
 	"github.com/javi11/postie/internal/pausable"
 	"github.com/javi11/postie/internal/pool"
 	"github.com/javi11/postie/internal/poster"
@@ -147,7 +150,7 @@ func New(opts ProcessorOptions) *Processor {
 		if opts.Queue != nil {
 			if db := opts.Queue.DB(); db != nil {
 				transferStore = transferstore.New(db)
-				manifestDir = filepath.Join(filepath.Dir(opts.Config.GetDatabaseConfig().DatabasePath), "transfer-manifests")
+				manifestDir = opts.Config.GetDatabaseConfig().ManifestDir()
 				// One-time migration of pre-durable deferred checks into the
 				// durable verification_failures table (STAT-only).
 				if migrated, err := transferStore.MigrateLegacyPendingChecks(providerCtx); err != nil {
@@ -329,7 +332,7 @@ func (p *Processor) processNextItem(ctx context.Context) error {
 	slog.Info("Processing file", "msg", msg.ID, "path", job.Path, "priority", job.Priority)
 
 	// Process the file and get both NZB path and postie instance
-	actualNzbPath, jobPostie, err := p.processFile(ctx, msg, job)
+	actualNzbPath, jobPostie, sourceFiles, err := p.processFile(ctx, msg, job)
 
 	// Check for DeferredCheckError first - this is a non-fatal error
 	var deferredErr *poster.DeferredCheckError
@@ -347,6 +350,7 @@ func (p *Processor) processNextItem(ctx context.Context) error {
 			slog.ErrorContext(ctx, "Error marking file as completed", "error", completeErr, "path", job.Path)
 			return completeErr
 		}
+		p.recordFileHashes(ctx, string(msg.ID), jobPostie.FileHashes(), sourceFiles)
 
 		// Store deferred articles in the database for the background worker
 		completedItemID := string(msg.ID)
@@ -372,11 +376,14 @@ func (p *Processor) processNextItem(ctx context.Context) error {
 			}
 		}
 
-		// Execute post upload script if configured (NZB is valid)
+		// Execute post upload script and deliver to NZB sinks if configured (NZB is valid)
 		sourcePath := strings.TrimPrefix(job.Path, "FOLDER:")
 		if scriptErr := jobPostie.ExecutePostUploadScript(ctx, actualNzbPath, sourcePath, completedItemID); scriptErr != nil {
 			slog.ErrorContext(ctx, "Post upload script execution failed", "error", scriptErr, "nzbPath", actualNzbPath)
 		}
+		if sinkErr := jobPostie.DeliverNzb(ctx, actualNzbPath, completedItemID); sinkErr != nil {
+			slog.ErrorContext(ctx, "NZB sink delivery failed", "error", sinkErr, "nzbPath", actualNzbPath)
+		}
 
 		if p.onJobComplete != nil {
 			p.onJobComplete()
@@ -413,6 +420,7 @@ func (p *Processor) processNextItem(ctx context.Context) error {
 		slog.ErrorContext(ctx, "Error marking file as completed", "error", err, "path", job.Path)
 		return err
 	}
+	p.recordFileHashes(ctx, string(msg.ID), jobPostie.FileHashes(), sourceFiles)
 
 	// In durable mode the upload is complete but verification runs in the
 	// background, so the item is pending verification rather than verified.
@@ -429,9 +437,9 @@ func (p *Processor) processNextItem(ctx context.Context) error {
 		}
 	}
 
-	// Execute post upload script if configured. In durable mode the script is
-	// deferred until verification succeeds (run by the transfer cleaner), so it
-	// only fires here in standalone mode.
+	// Execute post upload script and deliver to NZB sinks if configured. In
+	// durable mode both are deferred until verification succeeds (run by the
+	// transfer cleaner), so they only fire here in standalone mode.
 	// Note: We don't return the error here to avoid failing the completion if the script fails;
 	// the failure is tracked in the database for retry.
 	if !p.durableMode() {
@@ -439,6 +447,9 @@ func (p *Processor) processNextItem(ctx context.Context) error {
 		if scriptErr := jobPostie.ExecutePostUploadScript(ctx, actualNzbPath, sourcePath, string(msg.ID)); scriptErr != nil {
 			slog.ErrorContext(ctx, "Post upload script execution failed", "error", scriptErr, "nzbPath", actualNzbPath)
 		}
+		if sinkErr := jobPostie.DeliverNzb(ctx, actualNzbPath, string(msg.ID)); sinkErr != nil {
+			slog.ErrorContext(ctx, "NZB sink delivery failed", "error", sinkErr, "nzbPath", actualNzbPath)
+		}
 	}
 
 	if p.onJobComplete != nil {
@@ -448,7 +459,7 @@ func (p *Processor) processNextItem(ctx context.Context) error {
 	return nil
 }
 
-func (p *Processor) processFile(ctx context.Context, msg *goqite.Message, job *queue.FileJob) (string, *postie.Postie, error) {
+func (p *Processor) processFile(ctx context.Context, msg *goqite.Message, job *queue.FileJob) (string, *postie.Postie, []fileinfo.FileInfo, error) {
 	// Check if this is a folder job
 	isFolder := strings.HasPrefix(job.Path, "FOLDER:")
 	var fileName string
@@ -463,10 +474,10 @@ func (p *Processor) processFile(ctx context.Context, msg *goqite.Message, job *q
 		// Collect all files in the folder
 		files, err := p.collectFilesInFolder(folderPath)
 		if err != nil {
-			return "", nil, fmt.Errorf("failed to collect files in folder %s: %w", folderPath, err)
+			return "", nil, nil, fmt.Errorf("failed to collect files in folder %s: %w", folderPath, err)
 		}
 		if len(files) == 0 {
-			return "", nil, fmt.Errorf("no files found in folder %s", folderPath)
+			return "", nil, nil, fmt.Errorf("no files found in folder %s", folderPath)
 		}
 		filesToProcess = files
 
@@ -538,7 +549,7 @@ func (p *Processor) processFile(ctx context.Context, msg *goqite.Message, job *q
 	p.runningMux.Unlock()
 
 	if poolManager == nil {
-		return "", nil, fmt.Errorf("pool manager is not available for job %s", jobID)
+		return "", nil, nil, fmt.Errorf("pool manager is not available for job %s", jobID)
 	}
 
 	// Create a postie instance for this job, sharing the process-wide transfer
@@ -546,7 +557,7 @@ func (p *Processor) processFile(ctx context.Context, msg *goqite.Message, job *q
 	// globally rather than per queue job.
 	jobPostie, err := postie.NewWithRuntime(jobCtx, p.config, poolManager, progressJob, p.queue, p.transferRuntime, job.TransferID)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to create postie instance for job %s: %w", jobID, err)
+		return "", nil, nil, fmt.Errorf("failed to create postie instance for job %s: %w", jobID, err)
 	}
 	defer jobPostie.Close()
 
@@ -559,6 +570,9 @@ func (p *Processor) processFile(ctx context.Context, msg *goqite.Message, job *q
 		deleteOriginal = *job.DeleteOriginal
 	}
 	jobPostie.SetDeleteOriginal(deleteOriginal)
+	if job.NzbMeta != nil {
+		jobPostie.SetNzbMeta(*job.NzbMeta)
+	}
 
 	// Determine the input folder for maintaining folder structure
 	var inputFolder string
@@ -592,9 +606,9 @@ func (p *Processor) processFile(ctx context.Context, msg *goqite.Message, job *q
 		// Return them so the caller can execute the post-upload script and store deferred checks.
 		var deferredErr *poster.DeferredCheckError
 		if errors.As(err, &deferredErr) {
-			return actualNzbPath, jobPostie, err
+			return actualNzbPath, jobPostie, filesToProcess, err
 		}
-		return "", nil, err
+		return "", nil, nil, err
 	}
 
 	// Delete the original files if configured (deleteOriginal resolved above).
@@ -602,7 +616,9 @@ func (p *Processor) processFile(ctx context.Context, msg *goqite.Message, job *q
 	// original here would destroy the only recovery source before verification
 	// confirms the articles propagated. Retain originals; deferred cleanup after
 	// successful verification is handled by the durable transfer lifecycle.
-	if deleteOriginal && p.durableMode() {
+	// Packed jobs are the exception: the packed volumes are the recovery
+	// source, so the originals are no longer needed once posted.
+	if deleteOriginal && p.durableMode() && !p.config.GetPostingConfig().Packing.Enabled() {
 		slog.InfoContext(ctx, "Durable mode: retaining original files until verification succeeds",
 			"path", job.Path)
 		deleteOriginal = false
@@ -614,7 +630,7 @@ func (p *Processor) processFile(ctx context.Context, msg *goqite.Message, job *q
 			case <-time.After(p.deleteDelay):
 			case <-ctx.Done():
 				slog.WarnContext(ctx, "Context cancelled while waiting to delete files")
-				return actualNzbPath, jobPostie, nil
+				return actualNzbPath, jobPostie, filesToProcess, nil
 			}
 		}
 		for _, fileInfo := range filesToProcess {
@@ -624,7 +640,36 @@ func (p *Processor) processFile(ctx context.Context, msg *goqite.Message, job *q
 		}
 	}
 
-	return actualNzbPath, jobPostie, nil
+	return actualNzbPath, jobPostie, filesToProcess, nil
+}
+
+// recordFileHashes stores the checksums of a job's source files on its
+// completed item, so the local copies can be checked against them later.
+// sums holds every file posted; the PAR2 files, checksum sidecars and packed
+// volumes the job generated are left out, as they are removed after upload.
+// A failure only loses that check, so it is logged rather than returned.
+func (p *Processor) recordFileHashes(ctx context.Context, completedItemID string, sums map[string]filehash.Sums, sourceFiles []fileinfo.FileInfo) {
+	hashes := make([]queue.FileHash, 0, len(sourceFiles))
+	for _, f := range sourceFiles {
+		s, ok := sums[f.Path]
+		if !ok {
+			continue
+		}
+		hashes = append(hashes, queue.FileHash{
+			Path:   f.Path,
+			Size:   s.Size,
+			CRC32:  s.CRC32Hex(),
+			SHA256: s.SHA256Hex(),
+		})
+	}
+	if len(hashes) == 0 {
+		return
+	}
+	slices.SortFunc(hashes, func(a, b queue.FileHash) int { return strings.Compare(a.Path, b.Path) })
+
+	if err := p.queue.SetCompletedItemFileHashes(ctx, completedItemID, hashes); err != nil {
+		slog.ErrorContext(ctx, "Failed to store file hashes", "error", err, "id", completedItemID)
+	}
 }
 
 func (p *Processor) handleProcessingError(ctx context.Context, msg *goqite.Message, job *queue.FileJob, jobID string, err error) error {
@@ -639,7 +684,9 @@ func (p *Processor) handleProcessingError(ctx context.Context, msg *goqite.Messa
 
 	job.RetryCount++
 
-	if job.RetryCount >= maxRetries {
+	// A skipped NZB fails the same way on every retry, and a retry would
+	// post the files again: fail the job now. The files are kept.
+	if job.RetryCount >= maxRetries || errors.Is(err, nzb.ErrNzbExists) {
 		fileName := getFileName(job.Path)
 		slog.ErrorContext(ctx, "Job failed permanently after reaching max retries",
 			"path", job.Path,
@@ -987,7 +1034,9 @@ func (p *Processor) GetAutoPauseReason() string {
 	return p.autoPauseReason
 }
 
-// monitorProviderAvailability monitors provider status and pauses/resumes processing accordingly
+// monitorProviderAvailability monitors provider status and pauses/resumes processing accordingly.
+// Each tick also runs the upload providers' circuit breakers, so a flaky provider is quarantined
+// while the healthy ones keep posting.
 func (p *Processor) monitorProviderAvailability() {
 	ticker := time.NewTicker(30 * time.Second) // Check every 30 seconds
 	defer ticker.Stop()
@@ -1025,13 +1074,30 @@ func (p *Processor) checkAndHandleProviderAvailability() {
 		return
 	}
 
+	// Score provider health and open/close circuit breakers. Quarantined
+	// providers are unavailable; providers posting successfully are available
+	// even when nntppool's lifetime error counter is non-zero.
+	quarantined := make(map[string]bool)
+	healthy := make(map[string]bool)
+	for _, h := range poolManager.EvaluateProviderHealth() {
+		switch {
+		case h.State == pool.CircuitOpen:
+			quarantined[h.Name] = true
+		case h.Healthy():
+			healthy[h.Name] = true
+		}
+	}
+
 	// Count connected/active providers
 	activeProviders := 0
 	totalProviders := len(metrics.Providers)
 
 	for _, provider := range metrics.Providers {
-		// Consider a provider active if it has active connections or no errors
-		if provider.ActiveConnections > 0 || provider.Errors == 0 {
+		if quarantined[provider.Name] {
+			continue
+		}
+		// Consider a provider active if it has active connections, no errors or recent successful posts
+		if provider.ActiveConnections > 0 || provider.Errors == 0 || healthy[provider.Name] {
 			activeProviders++
 		}
 	}
@@ -1042,6 +1108,7 @@ func (p *Processor) checkAndHandleProviderAvailability() {
 
 	slog.Debug("Provider availability check",
 		"activeProviders", activeProviders,
+		"quarantinedProviders", len(quarantined),
 		"totalProviders", totalProviders,
 		"wasAutoPaused", wasAutoPaused)
 
@@ -1055,9 +1122,14 @@ func (p *Processor) checkAndHandleProviderAvailability() {
 		slog.Warn("No providers available - blocking new jobs (running jobs continue unaffected)")
 		p.setAutoBlock(true)
 
+		reason := "All NNTP providers are unavailable"
+		if len(quarantined) == totalProviders {
+			reason = "All NNTP providers are quarantined after repeated errors"
+		}
+
 		p.autoPausedMux.Lock()
 		p.isAutoPaused = true
-		p.autoPauseReason = "All NNTP providers are unavailable"
+		p.autoPauseReason = reason
 		p.autoPausedMux.Unlock()
 	}
 
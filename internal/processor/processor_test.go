@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/database"
+	"github.com/javi11/postie/internal/filehash"
+	"github.com/javi11/postie/internal/nzb"
+	"github.com/javi11/postie/internal/queue"
+	"github.com/javi11/postie/pkg/fileinfo"
+)
+
+func newTestQueue(t *testing.T) *queue.Queue {
+	t.Helper()
+	ctx := context.Background()
+	db, err := database.New(ctx, config.DatabaseConfig{
+		DatabaseType: "sqlite",
+		DatabasePath: filepath.Join(t.TempDir(), "postie.db"),
+	})
+	if err != nil {
+		t.Fatalf("database.New: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := db.GetMigrationRunner().MigrateUp(); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	q, err := queue.New(ctx, db)
+	if err != nil {
+		t.Fatalf("queue.New: %v", err)
+	}
+	t.Cleanup(func() { _ = q.Close() })
+	return q
+}
+
+func TestHandleProcessingError_NzbExistsFailsWithoutRetry(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t)
+	if err := q.AddFile(ctx, "/data/movie.mkv", 100); err != nil {
+		t.Fatalf("AddFile: %v", err)
+	}
+	msg, job, err := q.ReceiveFile(ctx)
+	if err != nil || msg == nil {
+		t.Fatalf("ReceiveFile: %v", err)
+	}
+
+	p := &Processor{queue: q}
+	err = fmt.Errorf("error generating NZB file: %w", nzb.ErrNzbExists)
+	if err := p.handleProcessingError(ctx, msg, job, string(msg.ID), err); err != nil {
+		t.Fatalf("handleProcessingError: %v", err)
+	}
+
+	stats, err := q.GetQueueStats()
+	if err != nil {
+		t.Fatalf("GetQueueStats: %v", err)
+	}
+	// A retry would put the job back in the queue.
+	if stats["error"] != 1 || stats["pending"] != 0 {
+		t.Errorf("stats = %v, want the job failed and not re-queued", stats)
+	}
+}
+
+func TestRecordFileHashes_OnlySourceFiles(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t)
+	if err := q.AddFile(ctx, "/data/movie.mkv", 100); err != nil {
+		t.Fatalf("AddFile: %v", err)
+	}
+	msg, job, err := q.ReceiveFile(ctx)
+	if err != nil || msg == nil {
+		t.Fatalf("ReceiveFile: %v", err)
+	}
+	if err := q.CompleteFile(ctx, msg.ID, "/out/movie.nzb", job); err != nil {
+		t.Fatalf("CompleteFile: %v", err)
+	}
+
+	// With PAR2 and checksums enabled the job also posts files it generated
+	// in a temp directory, which are removed after upload.
+	sums := map[string]filehash.Sums{
+		"/data/movie.mkv":                 {Size: 100},
+		"/tmp/postie/movie.par2":          {Size: 10},
+		"/tmp/postie/movie.vol00+01.par2": {Size: 20},
+		"/tmp/postie/movie.mkv.sfv":       {Size: 5},
+	}
+	p := &Processor{queue: q}
+	p.recordFileHashes(ctx, string(msg.ID), sums, []fileinfo.FileInfo{{Path: "/data/movie.mkv", Size: 100}})
+
+	hashes, err := q.GetCompletedItemFileHashes(ctx, string(msg.ID))
+	if err != nil {
+		t.Fatalf("GetCompletedItemFileHashes: %v", err)
+	}
+	if len(hashes) != 1 || hashes[0].Path != "/data/movie.mkv" || hashes[0].Size != 100 {
+		t.Errorf("hashes = %+v, want only the source file", hashes)
+	}
+}
@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// retryPolicy holds the exponential backoff and retry limits shared by the
+// script and NZB sink retry workers.
+type retryPolicy struct {
+	retryDelay       time.Duration
+	maxBackoff       time.Duration
+	maxRetries       int           // 0 = unlimited
+	maxRetryDuration time.Duration // 0 = unlimited
+}
+
+// backoff returns the delay before retry number retryCount: retryDelay
+// doubled for every retry, capped at maxBackoff. The doubling stops before
+// it overflows, so unlimited retries keep a sane delay.
+func (p retryPolicy) backoff(retryCount int) time.Duration {
+	backoff := p.retryDelay
+	for range retryCount {
+		if backoff > math.MaxInt64/2 || (p.maxBackoff > 0 && backoff >= p.maxBackoff) {
+			break
+		}
+		backoff *= 2
+	}
+
+	if p.maxBackoff > 0 && backoff > p.maxBackoff {
+		return p.maxBackoff
+	}
+	return backoff
+}
+
+// failureReason returns a human-readable reason for why retries stopped, or
+// "" while they may continue.
+func (p retryPolicy) failureReason(firstFailure time.Time, retryCount int) string {
+	if p.maxRetryDuration > 0 && time.Since(firstFailure) > p.maxRetryDuration {
+		return fmt.Sprintf("exceeded max retry duration of %v", p.maxRetryDuration)
+	}
+	if p.maxRetries > 0 && retryCount >= p.maxRetries {
+		return fmt.Sprintf("exceeded max retries of %d", p.maxRetries)
+	}
+	return ""
+}
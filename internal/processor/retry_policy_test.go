@@ -0,0 +1,41 @@
+package processor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_BackoffUnlimitedRetries(t *testing.T) {
+	// Without max_backoff the doubling must stop before it overflows,
+	// however many retries have run.
+	p := retryPolicy{retryDelay: 30 * time.Second}
+
+	prev := time.Duration(0)
+	for _, n := range []int{0, 1, 10, 40, 63, 64, 100, 10000} {
+		got := p.backoff(n)
+		if got < prev {
+			t.Fatalf("backoff(%d) = %v, less than the previous %v", n, got, prev)
+		}
+		prev = got
+	}
+	if got := p.backoff(1); got != time.Minute {
+		t.Errorf("backoff(1) = %v, want 1m", got)
+	}
+}
+
+func TestRetryPolicy_FailureReason(t *testing.T) {
+	p := retryPolicy{maxRetries: 3, maxRetryDuration: time.Hour}
+
+	if reason := p.failureReason(time.Now(), 2); reason != "" {
+		t.Errorf("failureReason(2 retries) = %q, want none", reason)
+	}
+	if reason := p.failureReason(time.Now(), 3); reason == "" {
+		t.Error("expected a reason once max retries is reached")
+	}
+	if reason := p.failureReason(time.Now().Add(-2*time.Hour), 1); reason == "" {
+		t.Error("expected a reason once max retry duration has passed")
+	}
+	if reason := (retryPolicy{}).failureReason(time.Now().Add(-24*time.Hour), 1000); reason != "" {
+		t.Errorf("failureReason without limits = %q, want none", reason)
+	}
+}
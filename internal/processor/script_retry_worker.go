@@ -21,6 +21,7 @@ type ScriptRetryWorker struct {
 	ctx                 context.Context
 	cancel              context.CancelFunc
 	retryCheckInterval  time.Duration
+	retry               retryPolicy
 }
 
 // NewScriptRetryWorker creates a new script retry worker
@@ -39,6 +40,12 @@ func NewScriptRetryWorker(ctx context.Context, queue *queue.Queue, scriptConfig
 		ctx:                workerCtx,
 		cancel:             cancel,
 		retryCheckInterval: checkInterval,
+		retry: retryPolicy{
+			retryDelay:       scriptConfig.RetryDelay.ToDuration(),
+			maxBackoff:       scriptConfig.MaxBackoff.ToDuration(),
+			maxRetries:       scriptConfig.MaxRetries,
+			maxRetryDuration: scriptConfig.MaxRetryDuration.ToDuration(),
+		},
 	}
 }
 
@@ -140,9 +147,8 @@ func (w *ScriptRetryWorker) executeScript(ctx context.Context, item queue.Comple
 		}
 
 		// Check if we should continue retrying
-		if !w.shouldRetry(firstFailureAt, newRetryCount) {
+		if reason := w.retry.failureReason(firstFailureAt, newRetryCount); reason != "" {
 			// Mark as permanently failed
-			reason := w.getFailureReason(firstFailureAt, newRetryCount)
 			if updateErr := w.queue.MarkScriptFailed(ctx, item.ID, errorMsg); updateErr != nil {
 				slog.ErrorContext(ctx, "Failed to mark script as permanently failed", "itemID", item.ID, "error", updateErr)
 			}
@@ -151,7 +157,7 @@ func (w *ScriptRetryWorker) executeScript(ctx context.Context, item queue.Comple
 		}
 
 		// Calculate next retry with exponential backoff (capped)
-		backoffDelay := w.calculateBackoff(newRetryCount)
+		backoffDelay := w.retry.backoff(newRetryCount)
 		nextRetry := now.Add(backoffDelay)
 
 		// Update status for next retry
@@ -172,52 +178,3 @@ func (w *ScriptRetryWorker) executeScript(ctx context.Context, item queue.Comple
 	slog.InfoContext(ctx, "Post-upload script executed successfully on retry", "itemID", item.ID, "output", string(output))
 	return nil
 }
-
-// calculateBackoff calculates the backoff delay with exponential growth capped at MaxBackoff
-func (w *ScriptRetryWorker) calculateBackoff(retryCount int) time.Duration {
-	baseDelay := w.scriptConfig.RetryDelay.ToDuration()
-	maxBackoff := w.scriptConfig.MaxBackoff.ToDuration()
-
-	// Exponential backoff: base * 2^retryCount
-	backoff := baseDelay * time.Duration(1<<retryCount)
-
-	// Cap at max backoff
-	if maxBackoff > 0 && backoff > maxBackoff {
-		return maxBackoff
-	}
-
-	return backoff
-}
-
-// shouldRetry checks if we should continue retrying based on count and duration limits
-func (w *ScriptRetryWorker) shouldRetry(firstFailure time.Time, retryCount int) bool {
-	// Check time-based limit (max retry duration)
-	maxRetryDuration := w.scriptConfig.MaxRetryDuration.ToDuration()
-	if maxRetryDuration > 0 && time.Since(firstFailure) > maxRetryDuration {
-		return false
-	}
-
-	// Check count-based limit (0 = unlimited)
-	maxRetries := w.scriptConfig.MaxRetries
-	if maxRetries > 0 && retryCount >= maxRetries {
-		return false
-	}
-
-	return true
-}
-
-// getFailureReason returns a human-readable reason for why retries stopped
-func (w *ScriptRetryWorker) getFailureReason(firstFailure time.Time, retryCount int) string {
-	maxRetryDuration := w.scriptConfig.MaxRetryDuration.ToDuration()
-	maxRetries := w.scriptConfig.MaxRetries
-
-	// Check which limit was exceeded
-	if maxRetryDuration > 0 && time.Since(firstFailure) > maxRetryDuration {
-		return fmt.Sprintf("exceeded max retry duration of %v", maxRetryDuration)
-	}
-	if maxRetries > 0 && retryCount >= maxRetries {
-		return fmt.Sprintf("exceeded max retries of %d", maxRetries)
-	}
-
-	return "unknown reason"
-}
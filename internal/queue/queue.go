@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/javi11/postie/internal/config"
 	"github.com/javi11/postie/internal/database"
 	_ "github.com/mattn/go-sqlite3"
 	"maragu.dev/goqite"
@@ -58,11 +59,12 @@ type QueueInterface interface {
 }
 
 // AddOptions carries optional per-job fields when adding to the queue.
-// Zero values preserve existing behavior; only the API entry point sets these today.
+// Zero values preserve existing behavior.
 type AddOptions struct {
 	Priority       int
-	InputFolder    string // overrides processor's inferred root for relative-path → NZB output
-	DeleteOriginal *bool  // overrides watcher.DeleteOriginalFile for this job only
+	InputFolder    string          // overrides processor's inferred root for relative-path → NZB output
+	DeleteOriginal *bool           // overrides watcher.DeleteOriginalFile for this job only
+	NzbMeta        *config.NzbMeta // NZB <head> title/password/category/tags, over the configured nzb_meta
 }
 
 type Queue struct {
@@ -103,6 +105,18 @@ type QueueItem struct {
 	// Deferred verification progress (only set while pending_verification)
 	VerifiedArticles *int `json:"verifiedArticles,omitempty"`
 	TotalArticles    *int `json:"totalArticles,omitempty"`
+	// Per verify server share of the item's articles found at the first
+	// verification check (only set when dedicated verify servers are used)
+	Propagation []ProviderPropagation `json:"propagation,omitempty"`
+}
+
+// ProviderPropagation is how much of a completed item one verify server
+// (backbone) had at its first verification check.
+type ProviderPropagation struct {
+	Provider        string  `json:"provider"`
+	PresentArticles int     `json:"presentArticles"`
+	TotalArticles   int     `json:"totalArticles"`
+	Percent         float64 `json:"percent"`
 }
 
 type FileJob struct {
@@ -123,6 +137,9 @@ type FileJob struct {
 	// DeleteOriginal optionally overrides the global delete-original setting
 	// for this specific job. nil → use the processor's default.
 	DeleteOriginal *bool `json:"deleteOriginal,omitempty"`
+	// NzbMeta optionally sets the NZB <head> metadata of this job (title,
+	// password, category, tags). Set fields override the configured nzb_meta.
+	NzbMeta *config.NzbMeta `json:"nzbMeta,omitempty"`
 }
 
 type CompletedItem struct {
@@ -138,6 +155,14 @@ type CompletedItem struct {
 	ScriptFirstFailureAt *time.Time `json:"scriptFirstFailureAt"` // When the first script failure occurred
 }
 
+// FileHash is the whole-file checksum of one file posted for a completed item.
+type FileHash struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	CRC32  string `json:"crc32"`  // lowercase hex, 8 digits
+	SHA256 string `json:"sha256"` // lowercase hex
+}
+
 const (
 	StatusPending  = "pending"
 	StatusRunning  = "running"
@@ -396,6 +421,7 @@ func (q *Queue) AddFileWithOptions(ctx context.Context, path string, size int64,
 		TransferID:     genTransferID(),
 		InputFolder:    opts.InputFolder,
 		DeleteOriginal: opts.DeleteOriginal,
+		NzbMeta:        opts.NzbMeta,
 	}
 
 	jobData, err := json.Marshal(job)
@@ -634,6 +660,7 @@ func (q *Queue) GetQueueItems(params PaginationParams) (*PaginatedResult, error)
 
 	// Attach deferred verification progress to items awaiting verification
 	q.attachVerificationProgress(allItems)
+	q.attachPropagation(allItems)
 
 	// Calculate pagination metadata
 	totalPages := (totalCount + params.Limit - 1) / params.Limit // Ceiling division
@@ -707,6 +734,60 @@ func (q *Queue) attachVerificationProgress(items []QueueItem) {
 	`)
 }
 
+// attachPropagation populates Propagation for completed items from the
+// per-provider report recorded by the verification service.
+func (q *Queue) attachPropagation(items []QueueItem) {
+	indexByID := make(map[string]int)
+	for i := range items {
+		if items[i].VerificationStatus != nil {
+			indexByID[items[i].ID] = i
+		}
+	}
+	if len(indexByID) == 0 {
+		return
+	}
+
+	placeholders := make([]string, 0, len(indexByID))
+	args := make([]any, 0, len(indexByID))
+	for id := range indexByID {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT completed_item_id, provider, SUM(present_articles), SUM(total_articles)
+		FROM transfer_propagation
+		WHERE completed_item_id IN (%s)
+		GROUP BY completed_item_id, provider
+		ORDER BY completed_item_id, provider
+	`, strings.Join(placeholders, ","))
+
+	rows, err := q.db.Query(query, args...)
+	if err != nil {
+		slog.Error("Failed to query propagation", "error", err)
+		return
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var id string
+		var p ProviderPropagation
+		if err := rows.Scan(&id, &p.Provider, &p.PresentArticles, &p.TotalArticles); err != nil {
+			slog.Error("Failed to scan propagation row", "error", err)
+			return
+		}
+		i, ok := indexByID[id]
+		if !ok || p.TotalArticles <= 0 {
+			continue
+		}
+		p.Percent = float64(p.PresentArticles) * 100 / float64(p.TotalArticles)
+		items[i].Propagation = append(items[i].Propagation, p)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Failed to iterate propagation rows", "error", err)
+	}
+}
+
 // applyVerificationCounts runs a grouped (id, total, verified) query template
 // against the given item IDs and fills in progress on matching items.
 // It returns the IDs that received counts.
@@ -1454,6 +1535,16 @@ func (q *Queue) RemoveCompletedItem(id string) error {
 		return fmt.Errorf("failed to delete pending article checks: %w", err)
 	}
 
+	_, err = q.db.Exec("DELETE FROM transfer_propagation WHERE completed_item_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete propagation report: %w", err)
+	}
+
+	_, err = q.db.Exec("DELETE FROM nzb_sink_deliveries WHERE completed_item_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete NZB sink deliveries: %w", err)
+	}
+
 	// Delete the database record
 	_, err = q.db.Exec("DELETE FROM completed_items WHERE id = ?", id)
 	if err != nil {
@@ -1508,6 +1599,16 @@ func (q *Queue) ClearQueue() error {
 		return err
 	}
 
+	_, err = q.db.Exec("DELETE FROM transfer_propagation WHERE completed_item_id IS NOT NULL")
+	if err != nil {
+		return err
+	}
+
+	_, err = q.db.Exec("DELETE FROM nzb_sink_deliveries")
+	if err != nil {
+		return err
+	}
+
 	// Clear completed items from database
 	_, err = q.db.Exec("DELETE FROM completed_items")
 	if err != nil {
@@ -1560,6 +1661,16 @@ func (q *Queue) ClearCompletedItems() error {
 		return err
 	}
 
+	_, err = q.db.Exec("DELETE FROM transfer_propagation WHERE completed_item_id IS NOT NULL")
+	if err != nil {
+		return err
+	}
+
+	_, err = q.db.Exec("DELETE FROM nzb_sink_deliveries")
+	if err != nil {
+		return err
+	}
+
 	// Clear completed items from database
 	_, err = q.db.Exec("DELETE FROM completed_items")
 	if err != nil {
@@ -1718,6 +1829,46 @@ func (q *Queue) GetCompletedItemNzbPath(id string) (string, error) {
 	return nzbPath, nil
 }
 
+// SetCompletedItemFileHashes stores the checksums of the files posted for a
+// completed item, replacing any stored before.
+func (q *Queue) SetCompletedItemFileHashes(ctx context.Context, id string, hashes []FileHash) error {
+	data, err := json.Marshal(hashes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal file hashes: %w", err)
+	}
+
+	res, err := q.db.ExecContext(ctx, "UPDATE completed_items SET file_hashes = ? WHERE id = ?", string(data), id)
+	if err != nil {
+		return fmt.Errorf("failed to update file hashes: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("completed item not found: %s", id)
+	}
+	return nil
+}
+
+// GetCompletedItemFileHashes returns the checksums stored for a completed
+// item. Items completed before checksums were recorded have none.
+func (q *Queue) GetCompletedItemFileHashes(ctx context.Context, id string) ([]FileHash, error) {
+	var data sql.NullString
+	err := q.db.QueryRowContext(ctx, "SELECT file_hashes FROM completed_items WHERE id = ?", id).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("completed item not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to get file hashes: %w", err)
+	}
+	if !data.Valid || data.String == "" {
+		return nil, nil
+	}
+
+	var hashes []FileHash
+	if err := json.Unmarshal([]byte(data.String), &hashes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal file hashes: %w", err)
+	}
+	return hashes, nil
+}
+
 // DebugQueueItem returns debug information about a specific queue item
 func (q *Queue) DebugQueueItem(id string) (map[string]any, error) {
 	var received int
@@ -2039,6 +2190,150 @@ func (q *Queue) GetItemsForScriptRetry(ctx context.Context, limit int) ([]Comple
 	return items, rows.Err()
 }
 
+// SinkDelivery is the delivery state of a completed item's NZB to one NZB sink
+type SinkDelivery struct {
+	CompletedItemID string
+	Sink            string
+	NzbPath         string
+	Status          string // completed, pending_retry, failed_permanent
+	RetryCount      int
+	LastError       *string
+	NextRetryAt     *time.Time
+	FirstFailureAt  *time.Time
+}
+
+// UpdateSinkStatus records the delivery status of an item's NZB to a sink.
+// first_failure_at keeps the first value recorded for the delivery.
+func (q *Queue) UpdateSinkStatus(ctx context.Context, itemID, sink, nzbPath, status string, retryCount int, lastError string, nextRetryAt *time.Time, firstFailureAt *time.Time) error {
+	var nextRetryAtStr sql.NullString
+	if nextRetryAt != nil {
+		nextRetryAtStr = sql.NullString{String: nextRetryAt.Format("2006-01-02T15:04:05.000Z"), Valid: true}
+	}
+
+	var lastErrorStr sql.NullString
+	if lastError != "" {
+		lastErrorStr = sql.NullString{String: lastError, Valid: true}
+	}
+
+	var firstFailureAtStr sql.NullString
+	if firstFailureAt != nil {
+		firstFailureAtStr = sql.NullString{String: firstFailureAt.Format("2006-01-02T15:04:05.000Z"), Valid: true}
+	}
+
+	now := time.Now().Format("2006-01-02T15:04:05.000Z")
+	_, err := q.db.ExecContext(ctx, `
+		INSERT INTO nzb_sink_deliveries (completed_item_id, sink, nzb_path, status, retry_count, last_error, next_retry_at, first_failure_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (completed_item_id, sink) DO UPDATE
+		SET nzb_path = excluded.nzb_path,
+		    status = excluded.status,
+		    retry_count = excluded.retry_count,
+		    last_error = excluded.last_error,
+		    next_retry_at = excluded.next_retry_at,
+		    first_failure_at = COALESCE(nzb_sink_deliveries.first_failure_at, excluded.first_failure_at),
+		    updated_at = excluded.updated_at
+	`, itemID, sink, nzbPath, status, retryCount, lastErrorStr, nextRetryAtStr, firstFailureAtStr, now)
+
+	if err != nil {
+		return fmt.Errorf("failed to update NZB sink status: %w", err)
+	}
+
+	return nil
+}
+
+// MarkSinkCompleted marks the delivery of an item's NZB to a sink as completed
+func (q *Queue) MarkSinkCompleted(ctx context.Context, itemID, sink, nzbPath string) error {
+	now := time.Now().Format("2006-01-02T15:04:05.000Z")
+	_, err := q.db.ExecContext(ctx, `
+		INSERT INTO nzb_sink_deliveries (completed_item_id, sink, nzb_path, status, updated_at)
+		VALUES (?, ?, ?, 'completed', ?)
+		ON CONFLICT (completed_item_id, sink) DO UPDATE
+		SET nzb_path = excluded.nzb_path,
+		    status = 'completed',
+		    last_error = NULL,
+		    next_retry_at = NULL,
+		    first_failure_at = NULL,
+		    updated_at = excluded.updated_at
+	`, itemID, sink, nzbPath, now)
+
+	if err != nil {
+		return fmt.Errorf("failed to mark NZB sink delivery as completed: %w", err)
+	}
+
+	return nil
+}
+
+// MarkSinkFailed marks the delivery of an item's NZB to a sink as permanently failed
+func (q *Queue) MarkSinkFailed(ctx context.Context, itemID, sink, lastError string) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE nzb_sink_deliveries
+		SET status = 'failed_permanent',
+		    last_error = ?,
+		    next_retry_at = NULL,
+		    updated_at = ?
+		WHERE completed_item_id = ? AND sink = ?
+	`, lastError, time.Now().Format("2006-01-02T15:04:05.000Z"), itemID, sink)
+
+	if err != nil {
+		return fmt.Errorf("failed to mark NZB sink delivery as failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetSinkDeliveriesForRetry retrieves NZB sink deliveries that are due for retry
+func (q *Queue) GetSinkDeliveriesForRetry(ctx context.Context, limit int) ([]SinkDelivery, error) {
+	now := time.Now().Format("2006-01-02T15:04:05.000Z")
+
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT completed_item_id, sink, nzb_path, status, retry_count, last_error, next_retry_at, first_failure_at
+		FROM nzb_sink_deliveries
+		WHERE status = 'pending_retry'
+		  AND (next_retry_at IS NULL OR next_retry_at <= ?)
+		ORDER BY next_retry_at ASC
+		LIMIT ?
+	`, now, limit)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query NZB sink deliveries for retry: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var deliveries []SinkDelivery
+	for rows.Next() {
+		var d SinkDelivery
+		var lastErrorStr, nextRetryAtStr, firstFailureAtStr sql.NullString
+
+		err := rows.Scan(
+			&d.CompletedItemID, &d.Sink, &d.NzbPath, &d.Status, &d.RetryCount,
+			&lastErrorStr, &nextRetryAtStr, &firstFailureAtStr,
+		)
+		if err != nil {
+			continue
+		}
+
+		if lastErrorStr.Valid {
+			d.LastError = &lastErrorStr.String
+		}
+		if nextRetryAtStr.Valid {
+			if t, err := time.Parse("2006-01-02T15:04:05.000Z", nextRetryAtStr.String); err == nil {
+				d.NextRetryAt = &t
+			}
+		}
+		if firstFailureAtStr.Valid {
+			if t, err := time.Parse("2006-01-02T15:04:05.000Z", firstFailureAtStr.String); err == nil {
+				d.FirstFailureAt = &t
+			}
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
 // PendingArticleCheck represents an article that needs deferred verification
 type PendingArticleCheck struct {
 	ID              int64
@@ -367,3 +367,153 @@ func TestAttachVerificationProgress(t *testing.T) {
 		t.Error("GetQueueItems did not return completed item a")
 	}
 }
+
+func TestAttachPropagation(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+
+	// Two files of item "a" checked against two verify servers.
+	for _, r := range []struct {
+		file, provider string
+		present, total int
+	}{
+		{"f1", "news.a", 10, 10},
+		{"f2", "news.a", 30, 30},
+		{"f1", "news.b", 10, 10},
+		{"f2", "news.b", 0, 30},
+	} {
+		if _, err := q.db.ExecContext(ctx, `INSERT INTO transfer_propagation
+			(transfer_id, file_id, provider, completed_item_id, present_articles, total_articles, checked_at)
+			VALUES (?,?,?,?,?,?,?)`,
+			"tr-a", r.file, r.provider, "a", r.present, r.total, "2026-01-01T00:00:00Z"); err != nil {
+			t.Fatalf("insert propagation: %v", err)
+		}
+	}
+
+	verified := "verified"
+	items := []QueueItem{
+		{ID: "a", VerificationStatus: &verified},
+		{ID: "b", VerificationStatus: &verified},
+		{ID: "c"},
+	}
+	q.attachPropagation(items)
+
+	got := items[0].Propagation
+	if len(got) != 2 {
+		t.Fatalf("item a: propagation = %+v, want two providers", got)
+	}
+	if got[0].Provider != "news.a" || got[0].Percent != 100 {
+		t.Errorf("news.a = %+v, want 100%%", got[0])
+	}
+	if got[1].Provider != "news.b" || got[1].PresentArticles != 10 || got[1].TotalArticles != 40 || got[1].Percent != 25 {
+		t.Errorf("news.b = %+v, want 10/40 (25%%)", got[1])
+	}
+	if items[1].Propagation != nil || items[2].Propagation != nil {
+		t.Error("expected no propagation on items without a report")
+	}
+}
+
+func TestCompletedItemFileHashes(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+
+	if err := q.AddFile(ctx, "/tmp/hashed.bin", 100); err != nil {
+		t.Fatalf("AddFile: %v", err)
+	}
+	msg, job, err := q.ReceiveFile(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("ReceiveFile: job=%v err=%v", job, err)
+	}
+	if err := q.CompleteFile(ctx, msg.ID, "/tmp/hashed.nzb", job); err != nil {
+		t.Fatalf("CompleteFile: %v", err)
+	}
+	id := string(msg.ID)
+
+	got, err := q.GetCompletedItemFileHashes(ctx, id)
+	if err != nil {
+		t.Fatalf("GetCompletedItemFileHashes: %v", err)
+	}
+	if got != nil {
+		t.Errorf("hashes before recording = %+v, want none", got)
+	}
+
+	want := []FileHash{{Path: "/tmp/hashed.bin", Size: 100, CRC32: "0000abcd", SHA256: "ff"}}
+	if err := q.SetCompletedItemFileHashes(ctx, id, want); err != nil {
+		t.Fatalf("SetCompletedItemFileHashes: %v", err)
+	}
+	got, err = q.GetCompletedItemFileHashes(ctx, id)
+	if err != nil {
+		t.Fatalf("GetCompletedItemFileHashes: %v", err)
+	}
+	if len(got) != 1 || got[0] != want[0] {
+		t.Errorf("hashes = %+v, want %+v", got, want)
+	}
+
+	if err := q.SetCompletedItemFileHashes(ctx, "missing", want); err == nil {
+		t.Error("expected an error for an unknown completed item")
+	}
+}
+
+func TestSinkDeliveryRetryState(t *testing.T) {
+	q := newTestQueue(t)
+	ctx := context.Background()
+
+	if err := q.AddFile(ctx, "/tmp/sink.bin", 100); err != nil {
+		t.Fatalf("AddFile: %v", err)
+	}
+	msg, job, err := q.ReceiveFile(ctx)
+	if err != nil || job == nil {
+		t.Fatalf("ReceiveFile: job=%v err=%v", job, err)
+	}
+	if err := q.CompleteFile(ctx, msg.ID, "/tmp/sink.nzb", job); err != nil {
+		t.Fatalf("CompleteFile: %v", err)
+	}
+	id := string(msg.ID)
+
+	now := time.Now()
+	due := now.Add(-time.Minute)
+	if err := q.UpdateSinkStatus(ctx, id, "sab", "/tmp/sink.nzb", "pending_retry", 0, "connection refused", &due, &now); err != nil {
+		t.Fatalf("UpdateSinkStatus: %v", err)
+	}
+	later := now.Add(time.Hour)
+	if err := q.UpdateSinkStatus(ctx, id, "hook", "/tmp/sink.nzb", "pending_retry", 0, "502", &later, &now); err != nil {
+		t.Fatalf("UpdateSinkStatus: %v", err)
+	}
+	if err := q.MarkSinkCompleted(ctx, id, "dirs", "/tmp/sink.nzb"); err != nil {
+		t.Fatalf("MarkSinkCompleted: %v", err)
+	}
+
+	deliveries, err := q.GetSinkDeliveriesForRetry(ctx, 10)
+	if err != nil {
+		t.Fatalf("GetSinkDeliveriesForRetry: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Sink != "sab" || deliveries[0].NzbPath != "/tmp/sink.nzb" {
+		t.Fatalf("due deliveries = %+v, want only sab", deliveries)
+	}
+	if deliveries[0].FirstFailureAt == nil || deliveries[0].LastError == nil || *deliveries[0].LastError != "connection refused" {
+		t.Errorf("delivery = %+v, want first failure and last error", deliveries[0])
+	}
+
+	// A second failure keeps the first failure time.
+	if err := q.UpdateSinkStatus(ctx, id, "sab", "/tmp/sink.nzb", "pending_retry", 1, "timeout", &due, &later); err != nil {
+		t.Fatalf("UpdateSinkStatus: %v", err)
+	}
+	deliveries, _ = q.GetSinkDeliveriesForRetry(ctx, 10)
+	if len(deliveries) != 1 || deliveries[0].RetryCount != 1 || deliveries[0].FirstFailureAt.After(later.Add(-time.Minute)) {
+		t.Errorf("delivery after second failure = %+v", deliveries)
+	}
+
+	if err := q.MarkSinkFailed(ctx, id, "sab", "gave up"); err != nil {
+		t.Fatalf("MarkSinkFailed: %v", err)
+	}
+	if n := countRows(t, q, "nzb_sink_deliveries", "status = 'failed_permanent'"); n != 1 {
+		t.Errorf("failed deliveries = %d, want 1", n)
+	}
+
+	if err := q.RemoveCompletedItem(id); err != nil {
+		t.Fatalf("RemoveCompletedItem: %v", err)
+	}
+	if n := countRows(t, q, "nzb_sink_deliveries", ""); n != 0 {
+		t.Errorf("deliveries after removing the item = %d, want 0", n)
+	}
+}
@@ -0,0 +1,131 @@
+// Package sidecar writes checksum sidecar files (.sfv and .md5) listing the
+// files of a job, for downloaders that validate with them rather than PAR2.
+package sidecar
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Entry is a file listed in a sidecar.
+type Entry struct {
+	// Path is the file on disk.
+	Path string
+	// Name is the slash-separated name the sidecar lists the file under.
+	Name string
+}
+
+// Write creates name.sfv and/or name.md5 in dir listing entries, reading each
+// file once, and returns the paths written. With reuse, sidecars already in
+// dir are kept, so a retried job does not read its files again; only set it
+// when dir is specific to the entries.
+func Write(ctx context.Context, entries []Entry, dir, name string, sfv, md5sum, reuse bool) ([]string, error) {
+	var want []string
+	if sfv {
+		want = append(want, filepath.Join(dir, name+".sfv"))
+	}
+	if md5sum {
+		want = append(want, filepath.Join(dir, name+".md5"))
+	}
+	if len(want) == 0 {
+		return nil, nil
+	}
+	if reuse && allExist(want) {
+		return want, nil
+	}
+
+	var sfvBody, md5Body strings.Builder
+	sfvBody.WriteString("; Generated by postie\n")
+	for _, e := range entries {
+		crc, sum, err := hashFile(ctx, e.Path, md5sum)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&sfvBody, "%s %08X\n", e.Name, crc)
+		fmt.Fprintf(&md5Body, "%x *%s\n", sum, e.Name)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("sidecar: create %s: %w", dir, err)
+	}
+	for _, path := range want {
+		body := sfvBody.String()
+		if filepath.Ext(path) == ".md5" {
+			body = md5Body.String()
+		}
+		if err := writeAtomic(path, body); err != nil {
+			return nil, err
+		}
+	}
+	return want, nil
+}
+
+// hashFile returns the CRC32 and, when withMD5 is set, the MD5 of a file.
+func hashFile(ctx context.Context, path string, withMD5 bool) (uint32, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, fmt.Errorf("sidecar: %w", err)
+	}
+	defer f.Close()
+
+	crc := crc32.NewIEEE()
+	var w io.Writer = crc
+	var md hash.Hash
+	if withMD5 {
+		md = md5.New()
+		w = io.MultiWriter(crc, md)
+	}
+
+	buf := make([]byte, 1<<20)
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+		n, err := f.Read(buf)
+		if n > 0 {
+			_, _ = w.Write(buf[:n])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("sidecar: read %s: %w", path, err)
+		}
+	}
+
+	var sum []byte
+	if md != nil {
+		sum = md.Sum(nil)
+	}
+	return crc.Sum32(), sum, nil
+}
+
+// writeAtomic writes body to path through a temp file and a rename, so a
+// crash never leaves a truncated sidecar that a retry would reuse.
+func writeAtomic(path, body string) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(body), 0644); err != nil {
+		return fmt.Errorf("sidecar: write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("sidecar: write %s: %w", path, err)
+	}
+	return nil
+}
+
+func allExist(paths []string) bool {
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package sidecar
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	src := t.TempDir()
+	a := filepath.Join(src, "a.txt")
+	b := filepath.Join(src, "b.txt")
+	if err := os.WriteFile(a, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries := []Entry{{Path: a, Name: "a.txt"}, {Path: b, Name: "sub/b.txt"}}
+	dir := filepath.Join(t.TempDir(), "sums")
+
+	paths, err := Write(context.Background(), entries, dir, "job", true, true, true)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(paths) != 2 || filepath.Base(paths[0]) != "job.sfv" || filepath.Base(paths[1]) != "job.md5" {
+		t.Fatalf("paths = %v, want job.sfv and job.md5", paths)
+	}
+
+	sfv, _ := os.ReadFile(paths[0])
+	wantSFV := "; Generated by postie\na.txt 3610A686\nsub/b.txt 3A771143\n"
+	if string(sfv) != wantSFV {
+		t.Errorf("sfv =\n%s\nwant\n%s", sfv, wantSFV)
+	}
+	md5, _ := os.ReadFile(paths[1])
+	wantMD5 := "5d41402abc4b2a76b9719d911017c592 *a.txt\n7d793037a0760186574b0282f2f435e7 *sub/b.txt\n"
+	if string(md5) != wantMD5 {
+		t.Errorf("md5 =\n%s\nwant\n%s", md5, wantMD5)
+	}
+
+	// Without reuse, existing sidecars are rewritten.
+	if err := os.WriteFile(paths[0], []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Write(context.Background(), entries, dir, "job", true, true, false); err != nil {
+		t.Fatalf("Write without reuse: %v", err)
+	}
+	if sfv, _ := os.ReadFile(paths[0]); string(sfv) != wantSFV {
+		t.Errorf("sfv = %q, want it rewritten", sfv)
+	}
+
+	// A retry keeps the sidecars already written.
+	if err := os.Remove(a); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Write(context.Background(), entries, dir, "job", true, true, true); err != nil {
+		t.Errorf("Write with existing sidecars: %v", err)
+	}
+}
+
+func TestWrite_OnlySFV(t *testing.T) {
+	src := t.TempDir()
+	a := filepath.Join(src, "a.txt")
+	if err := os.WriteFile(a, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := Write(context.Background(), []Entry{{Path: a, Name: "a.txt"}}, t.TempDir(), "a.txt", true, false, true)
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(paths) != 1 || filepath.Base(paths[0]) != "a.txt.sfv" {
+		t.Errorf("paths = %v, want only a.txt.sfv", paths)
+	}
+}
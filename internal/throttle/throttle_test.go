@@ -1,4 +1,4 @@
-package poster
+package throttle
 
 import (
 	"testing"
@@ -7,12 +7,12 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestNewThrottle(t *testing.T) {
+func TestNew(t *testing.T) {
 	rate := int64(1000)
 	interval := time.Second
 
 	// Test with normal rate
-	throttle := NewThrottle(rate, interval)
+	throttle := New(rate, interval)
 
 	assert.Equal(t, rate, throttle.rate, "Rate should be initialized correctly")
 	assert.Equal(t, interval, throttle.interval, "Interval should be initialized correctly")
@@ -20,17 +20,17 @@ func TestNewThrottle(t *testing.T) {
 	assert.False(t, throttle.disabled.Load(), "Throttle should not be disabled with positive rate")
 
 	// Test with rate 0 (disabled)
-	throttleDisabled := NewThrottle(0, interval)
+	throttleDisabled := New(0, interval)
 	assert.True(t, throttleDisabled.disabled.Load(), "Throttle should be disabled when rate is 0")
 
 	// Test with negative rate (disabled)
-	throttleNegative := NewThrottle(-100, interval)
+	throttleNegative := New(-100, interval)
 	assert.True(t, throttleNegative.disabled.Load(), "Throttle should be disabled when rate is negative")
 }
 
 func newTestThrottle(rate int64, interval time.Duration) (*Throttle, *[]time.Duration) {
 	var slept []time.Duration
-	th := NewThrottle(rate, interval)
+	th := New(rate, interval)
 	th.sleepFn = func(d time.Duration) { slept = append(slept, d) }
 	return th, &slept
 }
@@ -62,7 +62,7 @@ func TestThrottleWait(t *testing.T) {
 
 func TestThrottleDisabled(t *testing.T) {
 	// Test throttle with rate 0 (disabled)
-	throttle := NewThrottle(0, time.Second)
+	throttle := New(0, time.Second)
 
 	// All waits should be immediate when throttling is disabled
 	start := time.Now()
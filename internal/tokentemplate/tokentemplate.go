@@ -0,0 +1,49 @@
+// Package tokentemplate tokenizes the {name} templates of the post headers
+// and nzb_output.path_template.
+package tokentemplate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parse splits s into literal text and token names. The result alternates
+// literal text (even indexes) and token names (odd), and always starts and
+// ends with literal text, which may be empty. Tokens are written as {name}
+// and must be keys of tokens; a literal brace is written as {{ or }}.
+// Unknown tokens and unbalanced braces are rejected.
+func Parse[V any](s string, tokens map[string]V) ([]string, error) {
+	var parts []string
+	var lit strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '{':
+			if i+1 < len(s) && s[i+1] == '{' {
+				lit.WriteByte('{')
+				i++
+				continue
+			}
+			end := strings.IndexByte(s[i+1:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed token at offset %d in template %q", i, s)
+			}
+			name := s[i+1 : i+1+end]
+			if _, ok := tokens[name]; !ok {
+				return nil, fmt.Errorf("unknown token {%s} in template %q", name, s)
+			}
+			parts = append(parts, lit.String(), name)
+			lit.Reset()
+			i += end + 1
+		case '}':
+			if i+1 < len(s) && s[i+1] == '}' {
+				lit.WriteByte('}')
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("unmatched } at offset %d in template %q", i, s)
+		default:
+			lit.WriteByte(c)
+		}
+	}
+	return append(parts, lit.String()), nil
+}
@@ -0,0 +1,37 @@
+package tokentemplate
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tokens := map[string]int{"a": 0, "b": 0}
+
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", []string{""}},
+		{"plain", []string{"plain"}},
+		{"{a}", []string{"", "a", ""}},
+		{"x{a}y{b}", []string{"x", "a", "y", "b", ""}},
+		{"{{a}} {a}", []string{"{a} ", "a", ""}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, tokens)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Parse(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"{c}", "{a", "a}", "{}"} {
+		if _, err := Parse(in, tokens); err == nil {
+			t.Errorf("Parse(%q): expected an error", in)
+		}
+	}
+}
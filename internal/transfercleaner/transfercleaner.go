@@ -1,7 +1,8 @@
 // Package transfercleaner performs post-verification cleanup for a durable
 // transfer: once every file of a transfer has been verified, it runs the
 // optional post-upload script, deletes originals whose policy requests it,
-// removes generated PAR2 files (unless maintained), and removes the manifests.
+// removes generated PAR2 files (unless maintained) and packed volumes, and
+// removes the manifests (unless maintained).
 //
 // Safety rules:
 //   - Cleanup runs ONLY when all files of the transfer reached a terminal state
@@ -15,34 +16,43 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"path/filepath"
 
 	"github.com/javi11/postie/internal/manifest"
 	"github.com/javi11/postie/internal/transferstore"
 )
 
-// ScriptRunner runs the post-upload script for a verified transfer. It may be
-// nil (no script).
-type ScriptRunner func(ctx context.Context, transferID string, files []transferstore.TransferFile) error
+// PostVerifyHook runs once a transfer is verified, before its files are
+// removed: the post-upload script and NZB sink deliveries. It may be nil.
+type PostVerifyHook func(ctx context.Context, transferID string, files []transferstore.TransferFile) error
 
 // Cleaner removes recovery artifacts after a transfer is fully verified.
 type Cleaner struct {
-	store        *transferstore.Store
-	maintainPar2 bool
-	runScript    ScriptRunner
-	removeFile   func(string) error
+	store             *transferstore.Store
+	maintainPar2      bool
+	maintainManifests bool
+	postVerify        PostVerifyHook
+	removeFile        func(string) error
 }
 
 // New creates a Cleaner. When maintainPar2 is true, generated PAR2 files are
-// kept after verification. runScript may be nil.
-func New(store *transferstore.Store, maintainPar2 bool, runScript ScriptRunner) *Cleaner {
+// kept after verification. postVerify may be nil.
+func New(store *transferstore.Store, maintainPar2 bool, postVerify PostVerifyHook) *Cleaner {
 	return &Cleaner{
 		store:        store,
 		maintainPar2: maintainPar2,
-		runScript:    runScript,
+		postVerify:   postVerify,
 		removeFile:   os.Remove,
 	}
 }
 
+// SetMaintainManifests keeps the manifests of verified transfers on disk so
+// their NZBs can be rebuilt later. The transfer_files rows are still dropped;
+// the manifests remain under their transfer directory.
+func (c *Cleaner) SetMaintainManifests(maintain bool) {
+	c.maintainManifests = maintain
+}
+
 // CleanupTransfer cleans up a transfer if and only if every file is verified.
 // It returns done=true only when cleanup actually ran to completion. It is a
 // no-op (done=false) while any file is still pending/verifying, and it retains
@@ -71,11 +81,11 @@ func (c *Cleaner) CleanupTransfer(ctx context.Context, transferID string) (bool,
 		}
 	}
 
-	// All files verified — run the post-upload script first (best effort), then
+	// All files verified — run the post-verify hook first (best effort), then
 	// delete sources/manifests.
-	if c.runScript != nil {
-		if err := c.runScript(ctx, transferID, files); err != nil {
-			slog.WarnContext(ctx, "Post-upload script failed during cleanup", "transfer", transferID, "error", err)
+	if c.postVerify != nil {
+		if err := c.postVerify(ctx, transferID, files); err != nil {
+			slog.WarnContext(ctx, "Post-verify hook failed during cleanup", "transfer", transferID, "error", err)
 		}
 	}
 
@@ -89,9 +99,17 @@ func (c *Cleaner) CleanupTransfer(ctx context.Context, transferID string) (bool,
 			if !c.maintainPar2 {
 				c.remove(ctx, f.SourcePath, "generated par2")
 			}
+		case string(manifest.RolePackedVolume):
+			// Volumes are postie's own temp files; the pack directory goes
+			// with its last volume.
+			c.remove(ctx, f.SourcePath, "packed volume")
+			_ = c.removeFile(filepath.Dir(f.SourcePath))
+		}
+		// Manifests are postie's own recovery files; removed once verified
+		// unless they are maintained for NZB rebuilds.
+		if !c.maintainManifests {
+			c.remove(ctx, f.ManifestPath, "manifest")
 		}
-		// Manifests are postie's own recovery files; always removed once verified.
-		c.remove(ctx, f.ManifestPath, "manifest")
 	}
 
 	// Drop the now-cleaned rows so the table stays bounded and cleanup is not
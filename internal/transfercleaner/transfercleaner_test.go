@@ -110,6 +110,37 @@ func TestCleanup_MaintainPar2_RetainsPar2(t *testing.T) {
 	}
 }
 
+func TestCleanup_RemovesPackedVolumesAndPackDir(t *testing.T) {
+	store := newTestStore(t)
+	dir := t.TempDir()
+	packDir := filepath.Join(dir, "pack")
+	if err := os.Mkdir(packDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	vol := makeFile(t, packDir, "set.zip.001")
+	if err := store.UpsertFile(context.Background(), transferstore.TransferFile{
+		TransferID:        "t",
+		FileID:            "vol",
+		ManifestPath:      makeFile(t, dir, "vol.manifest"),
+		SourcePath:        vol,
+		FileRole:          "packed_volume",
+		VerificationState: transferstore.StateVerified,
+	}); err != nil {
+		t.Fatalf("UpsertFile: %v", err)
+	}
+
+	c := New(store, true, nil) // maintainPar2 does not keep volumes
+	if _, err := c.CleanupTransfer(context.Background(), "t"); err != nil {
+		t.Fatal(err)
+	}
+	if exists(vol) {
+		t.Error("packed volume should be deleted after verification")
+	}
+	if exists(packDir) {
+		t.Error("empty pack directory should be deleted with its last volume")
+	}
+}
+
 func TestCleanup_NoDeletePolicy_RetainsOriginal(t *testing.T) {
 	store := newTestStore(t)
 	dir := t.TempDir()
@@ -127,6 +158,26 @@ func TestCleanup_NoDeletePolicy_RetainsOriginal(t *testing.T) {
 	}
 }
 
+func TestCleanup_MaintainManifests_RetainsManifest(t *testing.T) {
+	store := newTestStore(t)
+	dir := t.TempDir()
+	ctx := context.Background()
+	_, origMan := seedFile(t, store, dir, "t", "orig", "original", transferstore.StateVerified, "")
+
+	c := New(store, false, nil)
+	c.SetMaintainManifests(true)
+	done, err := c.CleanupTransfer(ctx, "t")
+	if err != nil || !done {
+		t.Fatalf("CleanupTransfer done=%v err=%v", done, err)
+	}
+	if !exists(origMan) {
+		t.Error("manifest should be retained when maintain_manifests=true")
+	}
+	if files, _ := store.ListFilesByTransfer(ctx, "t"); len(files) != 0 {
+		t.Errorf("rows should still be dropped, got %d", len(files))
+	}
+}
+
 func TestCleanup_AnyFailed_RetainsEverything(t *testing.T) {
 	store := newTestStore(t)
 	dir := t.TempDir()
@@ -174,7 +225,7 @@ func TestCleanup_NotAllVerified_NoOp(t *testing.T) {
 	}
 }
 
-func TestCleanup_RunsScriptOnceWhenAllVerified(t *testing.T) {
+func TestCleanup_RunsPostVerifyHookOnceWhenAllVerified(t *testing.T) {
 	store := newTestStore(t)
 	dir := t.TempDir()
 	ctx := context.Background()
@@ -182,12 +233,12 @@ func TestCleanup_RunsScriptOnceWhenAllVerified(t *testing.T) {
 	seedFile(t, store, dir, "t", "f2", "original", transferstore.StateVerified, "")
 
 	var calls []string
-	runScript := func(_ context.Context, transferID string, files []transferstore.TransferFile) error {
+	postVerify := func(_ context.Context, transferID string, files []transferstore.TransferFile) error {
 		calls = append(calls, transferID)
 		return nil
 	}
 
-	c := New(store, true, runScript)
+	c := New(store, true, postVerify)
 	if _, err := c.CleanupTransfer(ctx, "t"); err != nil {
 		t.Fatalf("CleanupTransfer: %v", err)
 	}
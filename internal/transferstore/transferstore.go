@@ -86,6 +86,17 @@ type VerificationFailure struct {
 	LastCheckedAt  *time.Time
 }
 
+// FilePropagation is one row of the transfer_propagation table: how many of a
+// file's articles a single verify server had at the first verification check.
+type FilePropagation struct {
+	TransferID string
+	FileID     string
+	Provider   string
+	Present    int
+	Total      int
+	CheckedAt  time.Time
+}
+
 // Store provides durable access to transfer files and verification failures.
 type Store struct {
 	db *sql.DB
@@ -299,9 +310,74 @@ func (s *Store) SetCompletedItemForTransfer(ctx context.Context, transferID, com
 	_, err := s.db.ExecContext(ctx,
 		"UPDATE transfer_files SET completed_item_id = ?, updated_at = ? WHERE transfer_id = ?",
 		completedItemID, fmtTime(time.Now()), transferID)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		"UPDATE transfer_propagation SET completed_item_id = ? WHERE transfer_id = ?",
+		completedItemID, transferID)
 	return err
 }
 
+// SetFilePropagation replaces the per-provider propagation rows of a file. The
+// completed item id is copied from the transfer_files row (when already
+// linked) so the report survives DeleteFilesByTransfer.
+func (s *Store) SetFilePropagation(ctx context.Context, transferID, fileID string, rows []FilePropagation) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM transfer_propagation WHERE transfer_id = ? AND file_id = ?", transferID, fileID); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		checkedAt := r.CheckedAt
+		if checkedAt.IsZero() {
+			checkedAt = time.Now()
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO transfer_propagation
+				(transfer_id, file_id, provider, completed_item_id, present_articles, total_articles, checked_at)
+			VALUES (?, ?, ?, (SELECT completed_item_id FROM transfer_files WHERE transfer_id = ? AND file_id = ?), ?, ?, ?)`,
+			transferID, fileID, r.Provider, transferID, fileID, r.Present, r.Total, fmtTime(checkedAt)); err != nil {
+			return fmt.Errorf("insert propagation for %s: %w", r.Provider, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListFilePropagation returns the propagation rows of a transfer, ordered by
+// file and provider.
+func (s *Store) ListFilePropagation(ctx context.Context, transferID string) ([]FilePropagation, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT transfer_id, file_id, provider, present_articles, total_articles, checked_at
+		FROM transfer_propagation WHERE transfer_id = ?
+		ORDER BY file_id, provider`, transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []FilePropagation
+	for rows.Next() {
+		var (
+			p         FilePropagation
+			checkedAt string
+		)
+		if err := rows.Scan(&p.TransferID, &p.FileID, &p.Provider, &p.Present, &p.Total, &checkedAt); err != nil {
+			return nil, err
+		}
+		if p.CheckedAt, err = parseTime(checkedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
 // SetCompletedItemVerificationStatus updates the verification_status of a
 // completed item (the user-facing queue row) to reflect durable verification.
 func (s *Store) SetCompletedItemVerificationStatus(ctx context.Context, completedItemID, status string) error {
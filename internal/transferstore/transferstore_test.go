@@ -265,6 +265,51 @@ func TestSetCompletedItemForTransfer(t *testing.T) {
 	}
 }
 
+func TestFilePropagation(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	_ = s.UpsertFile(ctx, TransferFile{TransferID: "t", FileID: "f1", ManifestPath: "m", SourcePath: "s", FileRole: "original", ArticleCount: 10})
+
+	if err := s.SetFilePropagation(ctx, "t", "f1", []FilePropagation{
+		{Provider: "a.example", Present: 10, Total: 10},
+		{Provider: "b.example", Present: 4, Total: 10},
+	}); err != nil {
+		t.Fatalf("SetFilePropagation: %v", err)
+	}
+	// A second check replaces the file's rows instead of adding to them.
+	if err := s.SetFilePropagation(ctx, "t", "f1", []FilePropagation{
+		{Provider: "a.example", Present: 10, Total: 10},
+		{Provider: "b.example", Present: 7, Total: 10},
+	}); err != nil {
+		t.Fatalf("SetFilePropagation again: %v", err)
+	}
+
+	got, err := s.ListFilePropagation(ctx, "t")
+	if err != nil {
+		t.Fatalf("ListFilePropagation: %v", err)
+	}
+	if len(got) != 2 || got[0].Provider != "a.example" || got[1].Present != 7 || got[1].Total != 10 {
+		t.Fatalf("unexpected rows %+v", got)
+	}
+
+	// Linking the completed item afterwards reaches the propagation rows, and
+	// they outlive the transfer_files rows.
+	if err := s.SetCompletedItemForTransfer(ctx, "t", "ci-1"); err != nil {
+		t.Fatalf("SetCompletedItemForTransfer: %v", err)
+	}
+	if err := s.DeleteFilesByTransfer(ctx, "t"); err != nil {
+		t.Fatalf("DeleteFilesByTransfer: %v", err)
+	}
+	var n int
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM transfer_propagation WHERE completed_item_id = ?", "ci-1").Scan(&n); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("propagation rows for ci-1 = %d, want 2", n)
+	}
+}
+
 func TestSetCompletedItemVerificationStatus(t *testing.T) {
 	s := newTestStore(t)
 	ctx := context.Background()
@@ -26,6 +26,8 @@ type Recorder struct {
 	transferID string
 	baseDir    string
 	store      *transferstore.Store
+	// packed holds the paths of volumes written by the packing stage.
+	packed map[string]bool
 }
 
 // New creates a Recorder for transferID that writes manifests under baseDir and
@@ -41,8 +43,20 @@ func fileID(sourcePath string) string {
 	return hex.EncodeToString(sum[:8])
 }
 
+// SetPackedVolumes marks paths as packed volumes, so they are recorded with
+// the packed_volume role. It must be called before the volumes are posted.
+func (r *Recorder) SetPackedVolumes(paths []string) {
+	r.packed = make(map[string]bool, len(paths))
+	for _, p := range paths {
+		r.packed[p] = true
+	}
+}
+
 // roleFor classifies a file by its on-disk path.
-func roleFor(sourcePath string) manifest.FileRole {
+func (r *Recorder) roleFor(sourcePath string) manifest.FileRole {
+	if r.packed[sourcePath] {
+		return manifest.RolePackedVolume
+	}
 	if par2.IsPar2File(sourcePath) {
 		return manifest.RoleGeneratedPar2
 	}
@@ -55,7 +69,7 @@ func roleFor(sourcePath string) manifest.FileRole {
 // posted so the manifest is durable first.
 func (r *Recorder) RecordFile(ctx context.Context, sourcePath string, articles []*article.Article) error {
 	fid := fileID(sourcePath)
-	role := roleFor(sourcePath)
+	role := r.roleFor(sourcePath)
 	manifestPath := manifest.FilePath(r.baseDir, r.transferID, fid)
 
 	w, err := manifest.NewWriter(manifestPath)
@@ -53,6 +53,14 @@ type Cleaner interface {
 	CleanupTransfer(ctx context.Context, transferID string) (bool, error)
 }
 
+// ProviderStater STATs a batch against every verify server separately. The
+// returned map is keyed by provider name and holds, per provider, the
+// message-IDs NOT confirmed present there (the StatBatch contract, per
+// provider). Every checked provider must have an entry, even when empty.
+type ProviderStater interface {
+	StatBatchByProvider(ctx context.Context, messageIDs []string) (map[string]map[string]struct{}, error)
+}
+
 // Config controls verification timing and concurrency. Zero values fall back to
 // conservative defaults via withDefaults.
 type Config struct {
@@ -121,6 +129,9 @@ type Service struct {
 	now      func() time.Time
 	cleaner  Cleaner
 	busy     func() bool
+	// byProvider, when set, replaces stater.StatBatch for a file's first
+	// check so propagation can be recorded per backbone.
+	byProvider ProviderStater
 	// busySkips counts consecutive cycles deferred by the busy-gate; only
 	// touched from the Run goroutine.
 	busySkips int
@@ -130,6 +141,12 @@ type Service struct {
 // becomes fully verified. Optional; nil disables cleanup.
 func (s *Service) SetCleaner(c Cleaner) { s.cleaner = c }
 
+// SetProviderStater installs a per-provider stater used for each file's first
+// verification check. An article then counts as missing only when no provider
+// has it, and the per-provider counts are stored as the file's propagation
+// report. Optional; nil keeps the merged StatBatch.
+func (s *Service) SetProviderStater(p ProviderStater) { s.byProvider = p }
+
 // SetBusyCheck installs a predicate consulted before each verification cycle;
 // while it returns true the sweep is deferred to the next tick. Used when the
 // verify pool is the upload pool, so background STAT sweeps do not steal
@@ -329,7 +346,15 @@ func (s *Service) VerifyFile(ctx context.Context, tf transferstore.TransferFile)
 	}
 	defer func() { _ = r.Close() }()
 
-	var missing []manifest.ArticleRecord
+	var (
+		missing []manifest.ArticleRecord
+		total   int
+		// present counts, per provider, the articles that provider has.
+		present map[string]int
+	)
+	if s.byProvider != nil {
+		present = make(map[string]int)
+	}
 
 	// flush STATs a chunk of records in one batched sweep and collects misses.
 	flush := func(chunk []manifest.ArticleRecord) error {
@@ -340,9 +365,20 @@ func (s *Service) VerifyFile(ctx context.Context, tf transferstore.TransferFile)
 		for i, rec := range chunk {
 			ids[i] = rec.MessageID
 		}
-		missingIDs, err := s.stater.StatBatch(ctx, ids)
-		if err != nil {
-			return err
+		total += len(ids)
+		var missingIDs map[string]struct{}
+		if s.byProvider != nil {
+			perProvider, err := s.byProvider.StatBatchByProvider(ctx, ids)
+			if err != nil {
+				return err
+			}
+			missingIDs = mergeMissing(ids, perProvider, present)
+		} else {
+			var err error
+			missingIDs, err = s.stater.StatBatch(ctx, ids)
+			if err != nil {
+				return err
+			}
 		}
 		for _, rec := range chunk {
 			if _, ok := missingIDs[rec.MessageID]; ok {
@@ -390,6 +426,10 @@ func (s *Service) VerifyFile(ctx context.Context, tf transferstore.TransferFile)
 		return err
 	}
 
+	if present != nil {
+		s.recordPropagation(ctx, tf, present, total)
+	}
+
 	if len(missing) == 0 {
 		if err := s.store.SetVerificationState(ctx, tf.TransferID, tf.FileID, transferstore.StateVerified, nil, ""); err != nil {
 			return err
@@ -416,6 +456,48 @@ func (s *Service) VerifyFile(ctx context.Context, tf transferstore.TransferFile)
 	return s.store.SetVerificationState(ctx, tf.TransferID, tf.FileID, transferstore.StateVerifying, &next, "")
 }
 
+// mergeMissing combines per-provider STAT results: an article is missing only
+// when no provider confirmed it. present is incremented for every provider
+// that has an article.
+func mergeMissing(ids []string, perProvider map[string]map[string]struct{}, present map[string]int) map[string]struct{} {
+	missing := make(map[string]struct{})
+	for provider, missingOn := range perProvider {
+		present[provider] += len(ids) - len(missingOn)
+	}
+	for _, id := range ids {
+		found := false
+		for _, missingOn := range perProvider {
+			if _, ok := missingOn[id]; !ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing[id] = struct{}{}
+		}
+	}
+	return missing
+}
+
+// recordPropagation stores the per-provider propagation report of a file's
+// first check. Failures are logged only: the report is informational and must
+// not hold up verification.
+func (s *Service) recordPropagation(ctx context.Context, tf transferstore.TransferFile, present map[string]int, total int) {
+	now := s.now()
+	rows := make([]transferstore.FilePropagation, 0, len(present))
+	for provider, n := range present {
+		rows = append(rows, transferstore.FilePropagation{
+			Provider:  provider,
+			Present:   n,
+			Total:     total,
+			CheckedAt: now,
+		})
+	}
+	if err := s.store.SetFilePropagation(ctx, tf.TransferID, tf.FileID, rows); err != nil {
+		slog.WarnContext(ctx, "verification: failed to record propagation", "transfer", tf.TransferID, "file", tf.FileID, "error", err)
+	}
+}
+
 // failFileTerminally marks a file verification_failed with reason and
 // finalizes its transfer so the completed item leaves pending_verification.
 func (s *Service) failFileTerminally(ctx context.Context, tf transferstore.TransferFile, reason string) error {
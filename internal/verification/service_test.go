@@ -206,6 +206,54 @@ func TestVerifyFile_PersistsMisses(t *testing.T) {
 	}
 }
 
+// fakeProviderStater has one fakeStater per provider.
+type fakeProviderStater map[string]*fakeStater
+
+func (f fakeProviderStater) StatBatchByProvider(ctx context.Context, messageIDs []string) (map[string]map[string]struct{}, error) {
+	out := make(map[string]map[string]struct{}, len(f))
+	for name, st := range f {
+		missing, err := st.StatBatch(ctx, messageIDs)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = missing
+	}
+	return out, nil
+}
+
+func TestVerifyFile_RecordsPropagationPerProvider(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	writeManifest(t, store, "t", "f", 5)
+
+	// m1 is only on backbone b; m3 is on neither.
+	svc := New(store, newFakeStater(), &fakeReposter{}, Config{StatBatchSize: 2}, "w")
+	svc.SetProviderStater(fakeProviderStater{
+		"a": newFakeStater(mid(1), mid(3)),
+		"b": newFakeStater(mid(3)),
+	})
+	tf, _ := store.GetFile(ctx, "t", "f")
+	if err := svc.VerifyFile(ctx, tf); err != nil {
+		t.Fatalf("VerifyFile: %v", err)
+	}
+
+	if n, _ := store.CountFailures(ctx, "t", "f", transferstore.FailurePending); n != 1 {
+		t.Errorf("pending failures = %d, want 1 (only the article missing everywhere)", n)
+	}
+
+	rows, err := store.ListFilePropagation(ctx, "t")
+	if err != nil {
+		t.Fatalf("ListFilePropagation: %v", err)
+	}
+	got := make(map[string][2]int)
+	for _, r := range rows {
+		got[r.Provider] = [2]int{r.Present, r.Total}
+	}
+	if got["a"] != [2]int{3, 5} || got["b"] != [2]int{4, 5} {
+		t.Errorf("propagation = %v, want a=3/5 b=4/5", got)
+	}
+}
+
 func TestProcessDueFailures_RepostThenResolve(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
@@ -72,6 +72,18 @@ func New(
 	}
 }
 
+// addOptions returns the queue options of this watcher's jobs: its root, so
+// the processor derives the relative output path from the right watch folder,
+// and its nzb_meta.
+func (w *Watcher) addOptions() queue.AddOptions {
+	opts := queue.AddOptions{InputFolder: w.watchFolder}
+	if !w.cfg.NzbMeta.IsZero() {
+		meta := w.cfg.NzbMeta
+		opts.NzbMeta = &meta
+	}
+	return opts
+}
+
 // isSymlink checks if the given path is a symbolic link using Lstat.
 // Returns true if the path is a symlink, false otherwise.
 func isSymlink(path string) (bool, error) {
@@ -215,7 +227,7 @@ func (w *Watcher) scanDirectory(ctx context.Context) error {
 		// Add file to queue with this watcher's root so the processor derives the
 		// relative output path from the correct watch folder (issue #168: only the
 		// first configured watcher's root was known to the processor).
-		err = w.queue.AddFileWithOptions(ctx, path, info.Size(), queue.AddOptions{InputFolder: w.watchFolder})
+		err = w.queue.AddFileWithOptions(ctx, path, info.Size(), w.addOptions())
 
 		if err != nil {
 			slog.ErrorContext(ctx, "Error adding file to queue", "path", path, "error", err)
@@ -356,7 +368,7 @@ func (w *Watcher) scanDirectoryGroupByFolder(ctx context.Context) error {
 
 		// Add the folder to the queue with a special marker to indicate it's a folder
 		// folderQueuePath was already computed above with "FOLDER:" + folderPath prefix
-		err = w.queue.AddFileWithOptions(ctx, folderQueuePath, folderSize, queue.AddOptions{InputFolder: w.watchFolder})
+		err = w.queue.AddFileWithOptions(ctx, folderQueuePath, folderSize, w.addOptions())
 		if err != nil {
 			slog.ErrorContext(ctx, "Error adding folder to queue", "folder", folderPath, "error", err)
 			continue
@@ -390,7 +402,7 @@ func (w *Watcher) scanDirectoryGroupByFolder(ctx context.Context) error {
 			continue
 		}
 
-		if err := w.queue.AddFileWithOptions(ctx, path, size, queue.AddOptions{InputFolder: w.watchFolder}); err != nil {
+		if err := w.queue.AddFileWithOptions(ctx, path, size, w.addOptions()); err != nil {
 			slog.ErrorContext(ctx, "Error adding file to queue", "path", path, "error", err)
 			continue
 		}
@@ -1130,3 +1130,36 @@ func TestScan_JobsCarryWatcherInputFolder(t *testing.T) {
 		}
 	})
 }
+
+// TestScan_JobsCarryWatcherNzbMeta verifies that a watcher's nzb_meta is set
+// on the jobs it enqueues.
+func TestScan_JobsCarryWatcherNzbMeta(t *testing.T) {
+	watcher, tempDir := createTestWatcher(t)
+	watcher.cfg.NzbMeta = config.NzbMeta{Category: "tv", Tags: []string{"hd"}}
+
+	content := bytes.Repeat([]byte("x"), 128)
+	modTime := time.Now().Add(-10 * time.Second)
+	filePath := createTestFile(t, tempDir, "episode.mkv", content, modTime)
+	createTestFile(t, tempDir, "episode2.mkv", content, modTime)
+
+	mockQueue := &mockQueueWithDuplicateCheck{
+		addFileCalls: make([]string, 0),
+	}
+	watcher.queue = mockQueue
+
+	ctx := context.Background()
+	if err := watcher.scanDirectory(ctx); err != nil {
+		t.Fatalf("First scan failed: %v", err)
+	}
+	if err := watcher.scanDirectory(ctx); err != nil {
+		t.Fatalf("Second scan failed: %v", err)
+	}
+
+	opts, ok := mockQueue.addFileOpts[filePath]
+	if !ok {
+		t.Fatalf("Expected %s to be enqueued via AddFileWithOptions, calls: %v", filePath, mockQueue.addFileCalls)
+	}
+	if opts.NzbMeta == nil || opts.NzbMeta.Category != "tv" || len(opts.NzbMeta.Tags) != 1 {
+		t.Errorf("Expected the watcher's nzb_meta on the job, got %+v", opts.NzbMeta)
+	}
+}
@@ -0,0 +1,542 @@
+// Package nntptest provides an in-process NNTP server for tests and local
+// development. It accepts POST, RFC 4644 streaming (MODE STREAM, CHECK,
+// TAKETHIS), STAT, ARTICLE, HEAD and BODY, stores articles in memory or on
+// disk, and can inject the faults real providers produce: articles that are
+// acknowledged but never show up, delayed propagation, 441 rejections and
+// connection resets.
+package nntptest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Faults configures fault injection. Rates are probabilities between 0 and 1,
+// rolled independently for every eligible command.
+type Faults struct {
+	// DropRate is the fraction of posts that are acknowledged but never
+	// stored, like an article lost between backbones.
+	DropRate float64
+	// RejectRate is the fraction of posts answered with 441 (439 for
+	// TAKETHIS).
+	RejectRate float64
+	// ResetRate is the fraction of article commands (POST, TAKETHIS, CHECK,
+	// STAT, ARTICLE, HEAD, BODY) on which the connection is reset instead of
+	// answered.
+	ResetRate float64
+	// PropagationDelay is how long a stored article stays invisible to STAT,
+	// ARTICLE, HEAD and BODY after it was posted.
+	PropagationDelay time.Duration
+}
+
+// Options configures a Server.
+type Options struct {
+	// Username and Password, when Username is set, are required through
+	// AUTHINFO before any article command.
+	Username string
+	Password string
+	// Store holds posted articles. Defaults to a MemoryStore.
+	Store Store
+	// Faults is the initial fault configuration; see SetFaults.
+	Faults Faults
+	// Seed makes fault injection reproducible. Zero picks a random seed.
+	Seed uint64
+}
+
+// Stats counts what the server did since it started.
+type Stats struct {
+	Connections int64 `json:"connections"`
+	Posted      int64 `json:"posted"`
+	Dropped     int64 `json:"dropped"`
+	Rejected    int64 `json:"rejected"`
+	Resets      int64 `json:"resets"`
+}
+
+// Server is an in-process NNTP server listening on a TCP address.
+type Server struct {
+	ln       net.Listener
+	username string
+	password string
+	store    Store
+
+	mu        sync.Mutex
+	faults    Faults
+	rng       *rand.Rand
+	visibleAt map[string]time.Time
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+
+	connections atomic.Int64
+	posted      atomic.Int64
+	dropped     atomic.Int64
+	rejected    atomic.Int64
+	resets      atomic.Int64
+	generated   atomic.Int64
+}
+
+// NewServer starts a server on a random loopback port.
+func NewServer(opts Options) (*Server, error) {
+	return Listen("127.0.0.1:0", opts)
+}
+
+// Listen starts a server on addr.
+func Listen(addr string, opts Options) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("nntptest listen: %w", err)
+	}
+
+	store := opts.Store
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+
+	s := &Server{
+		ln:        ln,
+		username:  opts.Username,
+		password:  opts.Password,
+		store:     store,
+		faults:    opts.Faults,
+		rng:       rand.New(rand.NewPCG(seed, seed)),
+		visibleAt: make(map[string]time.Time),
+		conns:     make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the host:port the server listens on.
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Port returns the TCP port the server listens on.
+func (s *Server) Port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+// SetFaults replaces the fault configuration for subsequent commands.
+func (s *Server) SetFaults(f Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = f
+}
+
+// Stats returns the server counters.
+func (s *Server) Stats() Stats {
+	return Stats{
+		Connections: s.connections.Load(),
+		Posted:      s.posted.Load(),
+		Dropped:     s.dropped.Load(),
+		Rejected:    s.rejected.Load(),
+		Resets:      s.resets.Load(),
+	}
+}
+
+// Article returns a stored article regardless of its propagation delay.
+// messageID may be given with or without angle brackets.
+func (s *Server) Article(messageID string) ([]byte, bool) {
+	article, ok, err := s.store.Get(bracketID(messageID))
+	if err != nil {
+		return nil, false
+	}
+	return article, ok
+}
+
+// Close stops accepting connections, closes the open ones and waits for
+// their handlers to return.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := s.ln.Close()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return // listener closed
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		s.connections.Add(1)
+		go s.handle(conn)
+	}
+}
+
+// roll reports whether a fault with the given rate fires.
+func (s *Server) roll(rate func(Faults) float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := rate(s.faults)
+	return r > 0 && s.rng.Float64() < r
+}
+
+func (s *Server) propagationDelay() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.faults.PropagationDelay
+}
+
+// session is the state of one client connection.
+type session struct {
+	s      *Server
+	conn   net.Conn
+	r      *bufio.Reader
+	w      *bufio.Writer
+	user   string
+	authed bool
+}
+
+// errReset ends a session after the connection was reset on purpose.
+var errReset = errors.New("connection reset by fault injection")
+
+func (s *Server) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	ss := &session{
+		s:      s,
+		conn:   conn,
+		r:      bufio.NewReader(conn),
+		w:      bufio.NewWriter(conn),
+		authed: s.username == "",
+	}
+	// RFC 3977 §5.1 greeting
+	if err := ss.reply("200 nntptest server ready, posting allowed"); err != nil {
+		return
+	}
+
+	for {
+		line, err := ss.r.ReadString('\n')
+		if err != nil {
+			return // connection closed
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			continue
+		}
+		if err := ss.command(line); err != nil {
+			if errors.Is(err, errReset) {
+				s.resets.Add(1)
+			}
+			return
+		}
+	}
+}
+
+func (ss *session) reply(format string, args ...any) error {
+	if _, err := fmt.Fprintf(ss.w, format+"\r\n", args...); err != nil {
+		return err
+	}
+	return ss.w.Flush()
+}
+
+// reset drops the connection with a TCP RST instead of an orderly close.
+func (ss *session) reset() error {
+	if tcp, ok := ss.conn.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+	_ = ss.conn.Close()
+	return errReset
+}
+
+// maybeReset resets the connection if the reset fault fires.
+func (ss *session) maybeReset() error {
+	if ss.s.roll(func(f Faults) float64 { return f.ResetRate }) {
+		slog.Debug("nntptest: resetting connection", "remote", ss.conn.RemoteAddr())
+		return ss.reset()
+	}
+	return nil
+}
+
+func (ss *session) command(line string) error {
+	cmd, arg, _ := strings.Cut(line, " ")
+	cmd = strings.ToUpper(cmd)
+	arg = strings.TrimSpace(arg)
+
+	switch cmd {
+	case "QUIT":
+		_ = ss.reply("205 closing connection")
+		return errors.New("quit")
+	case "CAPABILITIES":
+		return ss.reply("101 Capability list:\r\nVERSION 2\r\nREADER\r\nPOST\r\nSTREAMING\r\nDATE\r\nAUTHINFO USER\r\n.")
+	case "DATE":
+		// nntppool sends DATE as its connectivity ping (RFC 3977 §7.1)
+		return ss.reply("111 %s", time.Now().UTC().Format("20060102150405"))
+	case "HELP":
+		return ss.reply("100 Help text follows\r\n.")
+	case "AUTHINFO":
+		return ss.authinfo(arg)
+	case "MODE":
+		switch strings.ToUpper(arg) {
+		case "READER":
+			return ss.reply("200 posting allowed")
+		case "STREAM":
+			return ss.reply("203 streaming permitted")
+		}
+		return ss.reply("501 unknown MODE variant")
+	}
+
+	if !ss.authed {
+		return ss.reply("480 authentication required")
+	}
+
+	switch cmd {
+	case "POST":
+		return ss.post()
+	case "TAKETHIS":
+		return ss.takethis(arg)
+	case "CHECK":
+		if err := ss.maybeReset(); err != nil {
+			return err
+		}
+		if _, ok, _ := ss.s.store.Get(bracketID(arg)); ok {
+			return ss.reply("438 %s", arg)
+		}
+		return ss.reply("238 %s", arg)
+	case "STAT", "ARTICLE", "HEAD", "BODY":
+		return ss.retrieve(cmd, arg)
+	default:
+		return ss.reply("500 unknown command")
+	}
+}
+
+func (ss *session) authinfo(arg string) error {
+	kind, value, _ := strings.Cut(arg, " ")
+	switch strings.ToUpper(kind) {
+	case "USER":
+		ss.user = value
+		return ss.reply("381 password required")
+	case "PASS":
+		if ss.s.username != "" && (ss.user != ss.s.username || value != ss.s.password) {
+			return ss.reply("481 authentication failed")
+		}
+		ss.authed = true
+		return ss.reply("281 authentication accepted")
+	}
+	return ss.reply("501 unknown AUTHINFO variant")
+}
+
+func (ss *session) post() error {
+	if err := ss.reply("340 send article to be posted"); err != nil {
+		return err
+	}
+	article, err := readArticle(ss.r)
+	if err != nil {
+		return err
+	}
+	if err := ss.maybeReset(); err != nil {
+		return err
+	}
+
+	id := headerValue(article, "Message-ID")
+	if id == "" {
+		id = fmt.Sprintf("<%d.nntptest@localhost>", ss.s.generated.Add(1))
+		article = append([]byte("Message-ID: "+id+"\r\n"), article...)
+	}
+
+	if ok, reason := ss.s.accept(id, article); !ok {
+		return ss.reply("441 %s", reason)
+	}
+	return ss.reply("240 article received %s", id)
+}
+
+func (ss *session) takethis(arg string) error {
+	article, err := readArticle(ss.r)
+	if err != nil {
+		return err
+	}
+	if err := ss.maybeReset(); err != nil {
+		return err
+	}
+
+	if ok, _ := ss.s.accept(bracketID(arg), article); !ok {
+		return ss.reply("439 %s", arg)
+	}
+	return ss.reply("239 %s", arg)
+}
+
+// accept applies the post faults and stores the article. It reports false and
+// a reason when the article is rejected.
+func (s *Server) accept(id string, article []byte) (bool, string) {
+	if s.roll(func(f Faults) float64 { return f.RejectRate }) {
+		s.rejected.Add(1)
+		slog.Debug("nntptest: rejecting article", "messageID", id)
+		return false, "posting failed"
+	}
+	if _, exists, _ := s.store.Get(id); exists {
+		s.rejected.Add(1)
+		return false, "duplicate article"
+	}
+	if s.roll(func(f Faults) float64 { return f.DropRate }) {
+		s.dropped.Add(1)
+		slog.Debug("nntptest: dropping article", "messageID", id)
+		return true, ""
+	}
+
+	if err := s.store.Put(id, article); err != nil {
+		s.rejected.Add(1)
+		slog.Error("nntptest: storing article", "messageID", id, "error", err)
+		return false, "posting failed"
+	}
+	if delay := s.propagationDelay(); delay > 0 {
+		s.mu.Lock()
+		s.visibleAt[id] = time.Now().Add(delay)
+		s.mu.Unlock()
+	}
+	s.posted.Add(1)
+	return true, ""
+}
+
+// visible reports whether a stored article has propagated.
+func (s *Server) visible(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at, ok := s.visibleAt[id]
+	if !ok {
+		return true
+	}
+	if time.Now().Before(at) {
+		return false
+	}
+	delete(s.visibleAt, id)
+	return true
+}
+
+func (ss *session) retrieve(cmd, arg string) error {
+	if err := ss.maybeReset(); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(arg, "<") {
+		// Article numbers need a selected group, which this server has none of.
+		return ss.reply("412 no newsgroup selected")
+	}
+
+	article, ok, err := ss.s.store.Get(arg)
+	if err != nil {
+		return ss.reply("403 %v", err)
+	}
+	if !ok || !ss.s.visible(arg) {
+		return ss.reply("430 no such article")
+	}
+
+	head, body := splitArticle(article)
+	switch cmd {
+	case "STAT":
+		return ss.reply("223 0 %s", arg)
+	case "ARTICLE":
+		return ss.multiline(fmt.Sprintf("220 0 %s", arg), article)
+	case "HEAD":
+		return ss.multiline(fmt.Sprintf("221 0 %s", arg), head)
+	default:
+		return ss.multiline(fmt.Sprintf("222 0 %s", arg), body)
+	}
+}
+
+// multiline writes a status line followed by a dot-stuffed data block.
+func (ss *session) multiline(status string, data []byte) error {
+	_, _ = ss.w.WriteString(status + "\r\n")
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i+1], data[i+1:]
+		} else {
+			data = nil
+		}
+		if line[0] == '.' {
+			_ = ss.w.WriteByte('.')
+		}
+		_, _ = ss.w.Write(bytes.TrimRight(line, "\r\n"))
+		_, _ = ss.w.WriteString("\r\n")
+	}
+	_, _ = ss.w.WriteString(".\r\n")
+	return ss.w.Flush()
+}
+
+// readArticle reads a dot-terminated article and undoes dot-stuffing.
+func readArticle(r *bufio.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(bytes.TrimRight(line, "\r\n"), []byte(".")) {
+			return buf.Bytes(), nil
+		}
+		if bytes.HasPrefix(line, []byte("..")) {
+			line = line[1:]
+		}
+		buf.Write(line)
+	}
+}
+
+// splitArticle splits an article into its header block (including the final
+// CRLF of the last header) and its body.
+func splitArticle(article []byte) (head, body []byte) {
+	if i := bytes.Index(article, []byte("\r\n\r\n")); i >= 0 {
+		return article[:i+2], article[i+4:]
+	}
+	return article, nil
+}
+
+// headerValue returns the value of the named header, or "" if it is absent.
+func headerValue(article []byte, name string) string {
+	head, _ := splitArticle(article)
+	for _, line := range strings.Split(string(head), "\r\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func bracketID(id string) string {
+	if strings.HasPrefix(id, "<") {
+		return id
+	}
+	return "<" + id + ">"
+}
@@ -0,0 +1,243 @@
+package nntptest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javi11/nntppool/v4"
+	"github.com/mnightingale/rapidyenc"
+)
+
+func newTestClient(t *testing.T, srv *Server, user, pass string) *nntppool.Client {
+	t.Helper()
+	client, err := nntppool.NewClient(context.Background(), []nntppool.Provider{{
+		Host:        srv.Addr(),
+		Auth:        nntppool.Auth{Username: user, Password: pass},
+		Connections: 2,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func postTestArticle(ctx context.Context, client *nntppool.Client, id string) error {
+	payload := []byte(strings.Repeat("payload.", 512))
+	_, err := client.PostYenc(ctx, nntppool.PostHeaders{
+		From:       "poster <poster@example.com>",
+		Subject:    "test [1/1]",
+		Newsgroups: []string{"alt.binaries.test"},
+		MessageID:  "<" + id + ">",
+	}, bytes.NewReader(payload), rapidyenc.Meta{
+		FileName:   "test.bin",
+		FileSize:   int64(len(payload)),
+		PartNumber: 1,
+		TotalParts: 1,
+		PartSize:   int64(len(payload)),
+	})
+	return err
+}
+
+func TestServer_PostAndRetrieve(t *testing.T) {
+	srv, err := NewServer(Options{Username: "user", Password: "pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = srv.Close() }()
+
+	ctx := context.Background()
+	client := newTestClient(t, srv, "user", "pass")
+
+	if err := postTestArticle(ctx, client, "one@test"); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if _, err := client.Stat(ctx, "one@test"); err != nil {
+		t.Errorf("STAT of a posted article: %v", err)
+	}
+	if _, err := client.Stat(ctx, "missing@test"); !errors.Is(err, nntppool.ErrArticleNotFound) {
+		t.Errorf("STAT of a missing article: expected ErrArticleNotFound, got %v", err)
+	}
+
+	head, err := client.Head(ctx, "one@test")
+	if err != nil {
+		t.Fatalf("HEAD: %v", err)
+	}
+	if got := head.Headers["Subject"]; len(got) == 0 || got[0] != "test [1/1]" {
+		t.Errorf("HEAD Subject = %v", got)
+	}
+
+	article, ok := srv.Article("one@test")
+	if !ok || !bytes.Contains(article, []byte("=ybegin")) {
+		t.Errorf("stored article missing or not yEnc encoded: %q", article)
+	}
+	if s := srv.Stats(); s.Posted != 1 {
+		t.Errorf("Posted = %d, want 1", s.Posted)
+	}
+}
+
+func TestServer_RejectsBadCredentials(t *testing.T) {
+	srv, err := NewServer(Options{Username: "user", Password: "pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = srv.Close() }()
+
+	conn := dialRaw(t, srv)
+	conn.send("AUTHINFO USER user", "381")
+	conn.send("AUTHINFO PASS wrong", "481")
+	conn.send("POST", "480")
+}
+
+func TestServer_Faults(t *testing.T) {
+	t.Run("reject", func(t *testing.T) {
+		srv, err := NewServer(Options{Faults: Faults{RejectRate: 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = srv.Close() }()
+
+		err = postTestArticle(context.Background(), newTestClient(t, srv, "", ""), "a@test")
+		if !errors.Is(err, nntppool.ErrPostingFailed) {
+			t.Fatalf("expected ErrPostingFailed, got %v", err)
+		}
+		if s := srv.Stats(); s.Rejected == 0 || s.Posted != 0 {
+			t.Errorf("unexpected stats %+v", s)
+		}
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		srv, err := NewServer(Options{Faults: Faults{DropRate: 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = srv.Close() }()
+
+		ctx := context.Background()
+		client := newTestClient(t, srv, "", "")
+		if err := postTestArticle(ctx, client, "a@test"); err != nil {
+			t.Fatalf("a dropped article must still be acknowledged: %v", err)
+		}
+		if _, err := client.Stat(ctx, "a@test"); !errors.Is(err, nntppool.ErrArticleNotFound) {
+			t.Errorf("expected the dropped article to be missing, got %v", err)
+		}
+	})
+
+	t.Run("propagation delay", func(t *testing.T) {
+		srv, err := NewServer(Options{Faults: Faults{PropagationDelay: 200 * time.Millisecond}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = srv.Close() }()
+
+		conn := dialRaw(t, srv)
+		conn.send("POST", "340")
+		conn.send("Message-ID: <late@test>\r\nSubject: x\r\n\r\nbody\r\n.", "240")
+		conn.send("STAT <late@test>", "430")
+		time.Sleep(250 * time.Millisecond)
+		conn.send("STAT <late@test>", "223")
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		srv, err := NewServer(Options{Faults: Faults{ResetRate: 1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = srv.Close() }()
+
+		conn := dialRaw(t, srv)
+		if _, err := conn.Write([]byte("STAT <a@test>\r\n")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.r.ReadString('\n'); err == nil {
+			t.Fatal("expected the connection to be reset")
+		}
+		if s := srv.Stats(); s.Resets != 1 {
+			t.Errorf("Resets = %d, want 1", s.Resets)
+		}
+	})
+}
+
+func TestServer_DotStuffingAndDiskStore(t *testing.T) {
+	store, err := NewDiskStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewServer(Options{Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = srv.Close() }()
+
+	conn := dialRaw(t, srv)
+	conn.send("POST", "340")
+	conn.send("Message-ID: <a/b@test>\r\n\r\n..leading dot\r\nplain\r\n.", "240")
+
+	if article, _, _ := store.Get("<a/b@test>"); !bytes.Contains(article, []byte("\r\n.leading dot\r\n")) {
+		t.Errorf("article not unstuffed on disk: %q", article)
+	}
+
+	conn.send("BODY <a/b@test>", "222")
+	if got := conn.readBlock(); got != "..leading dot\r\nplain\r\n" {
+		t.Errorf("BODY = %q, want the dot re-stuffed", got)
+	}
+}
+
+type rawConn struct {
+	net.Conn
+	t *testing.T
+	r *bufio.Reader
+}
+
+func dialRaw(t *testing.T, srv *Server) *rawConn {
+	t.Helper()
+	conn, err := net.Dial("tcp", srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	c := &rawConn{Conn: conn, t: t, r: bufio.NewReader(conn)}
+	c.expect("200")
+	return c
+}
+
+func (c *rawConn) send(line, wantCode string) {
+	c.t.Helper()
+	if _, err := c.Write([]byte(line + "\r\n")); err != nil {
+		c.t.Fatal(err)
+	}
+	c.expect(wantCode)
+}
+
+func (c *rawConn) expect(wantCode string) {
+	c.t.Helper()
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if !strings.HasPrefix(line, wantCode+" ") {
+		c.t.Fatalf("got %q, want %s", strings.TrimSpace(line), wantCode)
+	}
+}
+
+// readBlock reads a multi-line data block without undoing dot-stuffing.
+func (c *rawConn) readBlock() string {
+	c.t.Helper()
+	var sb strings.Builder
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		if line == ".\r\n" {
+			return sb.String()
+		}
+		sb.WriteString(line)
+	}
+}
@@ -0,0 +1,90 @@
+package nntptest
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store persists posted articles keyed by Message-ID (with angle brackets).
+// Articles are stored as received, headers and body, without dot-stuffing.
+type Store interface {
+	Put(messageID string, article []byte) error
+	Get(messageID string) ([]byte, bool, error)
+}
+
+// MemoryStore keeps articles in memory.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	articles map[string][]byte
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{articles: make(map[string][]byte)}
+}
+
+func (s *MemoryStore) Put(messageID string, article []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.articles[messageID] = article
+	return nil
+}
+
+func (s *MemoryStore) Get(messageID string) ([]byte, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	article, ok := s.articles[messageID]
+	return article, ok, nil
+}
+
+// Len returns the number of stored articles.
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.articles)
+}
+
+// DiskStore keeps one file per article in a directory, named after the
+// escaped Message-ID, so a server restarted on the same directory still
+// serves earlier posts.
+type DiskStore struct {
+	dir string
+}
+
+// NewDiskStore creates dir if needed and returns a store backed by it.
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating article directory: %w", err)
+	}
+	return &DiskStore{dir: dir}, nil
+}
+
+func (s *DiskStore) path(messageID string) string {
+	id := strings.TrimSuffix(strings.TrimPrefix(messageID, "<"), ">")
+	return filepath.Join(s.dir, url.PathEscape(id)+".eml")
+}
+
+func (s *DiskStore) Put(messageID string, article []byte) error {
+	path := s.path(messageID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, article, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *DiskStore) Get(messageID string) ([]byte, bool, error) {
+	article, err := os.ReadFile(s.path(messageID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return article, true, nil
+}
@@ -0,0 +1,91 @@
+package postie
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/javi11/postie/internal/sidecar"
+	"github.com/javi11/postie/pkg/fileinfo"
+)
+
+// createChecksums writes the sidecars enabled in posting.checksums for files,
+// named after name and listing each file relative to baseDir. Like PAR2 files
+// they are written to outputDir when maintain_par2_files is set, otherwise to a
+// temp directory. Only sidecars in the temp directory, which is keyed by the
+// files, are reused; outputDir may hold those of an earlier, different job of
+// the same name. The poster posts them under checksum_obfuscation_policy. On
+// failure the job continues without them.
+func (p *Postie) createChecksums(ctx context.Context, files []fileinfo.FileInfo, baseDir, name, outputDir string) []string {
+	sums := p.postingCfg.Checksums
+	if !sums.Enabled() {
+		return nil
+	}
+
+	dir, reuse := p.checksumDir(name, files), true
+	if p.maintainPar2Files() {
+		dir, reuse = outputDir, false
+	}
+
+	entries := make([]sidecar.Entry, 0, len(files))
+	for _, f := range files {
+		entries = append(entries, sidecar.Entry{Path: f.Path, Name: sidecarName(baseDir, f.Path)})
+	}
+
+	paths, err := sidecar.Write(ctx, entries, dir, name, sums.SFV, sums.MD5, reuse)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.ErrorContext(ctx, "Error during checksum creation. Upload will continue without checksum files.", "error", err)
+		}
+		return nil
+	}
+	p.poster.AddChecksumFiles(paths)
+	return paths
+}
+
+// removeChecksums deletes sidecars written by createChecksums once posted,
+// unless they are kept next to the NZB with maintain_par2_files.
+func (p *Postie) removeChecksums(ctx context.Context, paths []string) {
+	if len(paths) == 0 || p.maintainPar2Files() {
+		return
+	}
+	for _, path := range paths {
+		safeRemoveFile(ctx, path)
+	}
+	_ = os.Remove(filepath.Dir(paths[0]))
+}
+
+// checksumDir returns the temp directory sidecars of a job are written to.
+// It is derived from the job's files, so a retried job reuses them.
+func (p *Postie) checksumDir(name string, files []fileinfo.FileInfo) string {
+	base := os.TempDir()
+	if p.par2Cfg != nil && p.par2Cfg.TempDir != "" {
+		base = p.par2Cfg.TempDir
+	}
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00", name)
+	for _, f := range files {
+		_, _ = fmt.Fprintf(h, "%s\x00%d\x00", f.Path, f.Size)
+	}
+	return filepath.Join(base, "postie-checksums-"+hex.EncodeToString(h.Sum(nil))[:16])
+}
+
+func (p *Postie) maintainPar2Files() bool {
+	return p.par2Cfg != nil && p.par2Cfg.MaintainPar2Files != nil && *p.par2Cfg.MaintainPar2Files
+}
+
+// sidecarName is the name a sidecar lists path under: its path relative to
+// baseDir, or its base name when it lies outside baseDir.
+func sidecarName(baseDir, path string) string {
+	if rel, err := filepath.Rel(baseDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.Base(path)
+}
@@ -0,0 +1,78 @@
+package postie
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/javi11/postie/internal/config"
+)
+
+func TestPostFolder_ChecksumSidecars(t *testing.T) {
+	watchRoot := t.TempDir()
+	files, cleanup := makeSourceFiles(t, watchRoot, "Show", "ep.mkv")
+	defer cleanup()
+
+	rec := &recordingPoster{}
+	p := newTestPostie(&mockPar2Executor{}, true, true)
+	p.poster = rec
+	p.postingCfg.Checksums = config.ChecksumConfig{SFV: true, MD5: true}
+
+	// A sidecar left in the output directory by an earlier job of the same
+	// name is regenerated, not reused.
+	outputDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(outputDir, "Show"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "Show", "Show.sfv"), []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Post(context.Background(), files, watchRoot, outputDir, true); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	// maintain_par2_files keeps the sidecars next to the NZB.
+	sfvPath := filepath.Join(outputDir, "Show", "Show.sfv")
+	md5Path := filepath.Join(outputDir, "Show", "Show.md5")
+	if !slices.Contains(rec.posted, sfvPath) || !slices.Contains(rec.posted, md5Path) {
+		t.Fatalf("posted %v, want Show.sfv and Show.md5", rec.posted)
+	}
+	sfv, err := os.ReadFile(sfvPath)
+	if err != nil {
+		t.Fatalf("read sfv: %v", err)
+	}
+	if want := "; Generated by postie\nep.mkv FEC530A9\n"; string(sfv) != want {
+		t.Errorf("sfv = %q, want %q", sfv, want)
+	}
+}
+
+func TestPostInParallel_ChecksumSidecarsRemoved(t *testing.T) {
+	watchRoot := t.TempDir()
+	files, cleanup := makeSourceFiles(t, watchRoot, "Show", "ep.mkv")
+	defer cleanup()
+
+	rec := &recordingPoster{}
+	p := newTestPostie(&mockPar2Executor{}, false, false)
+	p.poster = rec
+	p.par2Cfg.TempDir = t.TempDir()
+	p.postingCfg.Checksums = config.ChecksumConfig{MD5: true}
+
+	if _, err := p.Post(context.Background(), files, watchRoot, t.TempDir(), false); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	var md5Path string
+	for _, path := range rec.posted {
+		if filepath.Base(path) == "ep.mkv.md5" {
+			md5Path = path
+		}
+	}
+	if md5Path == "" {
+		t.Fatalf("posted %v, want ep.mkv.md5", rec.posted)
+	}
+	if _, err := os.Stat(filepath.Dir(md5Path)); !os.IsNotExist(err) {
+		t.Errorf("checksum directory should be removed after posting, stat err = %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package postie
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/nzb"
+	"github.com/javi11/postie/internal/packer"
+	"github.com/javi11/postie/internal/poster"
+	"github.com/javi11/postie/pkg/fileinfo"
+)
+
+// postPacked packs files into volumes named after name, creates the PAR2 set
+// over the volumes and posts both as one NZB at nzbPath, or where layout puts
+// it. The NZB head carries the password of zip volumes.
+func (p *Postie) postPacked(ctx context.Context, files []fileinfo.FileInfo, rootDir, name, nzbPath string, layout nzb.OutputLayout) (string, error) {
+	packCfg := p.postingCfg.Packing
+
+	entries := make([]packer.Entry, 0, len(files))
+	for _, f := range files {
+		entries = append(entries, packer.Entry{Path: f.Path, Name: archiveName(rootDir, f, len(files) == 1)})
+	}
+
+	// A job or nzb_meta password is the archive password too.
+	password := packCfg.Password
+	if p.nzbMeta.Password != "" {
+		password = p.nzbMeta.Password
+	}
+	if packCfg.Mode == config.PackingModeZip && password == "" {
+		password = packer.NewPassword()
+	}
+
+	packDir := p.packDir(name, files)
+	slog.InfoContext(ctx, "Packing files into volumes", "name", name, "files", len(files), "mode", packCfg.Mode, "dir", packDir)
+
+	pack, err := packer.Pack(ctx, entries, packDir, name, packCfg.Mode, packCfg.Volume(), password)
+	if err != nil {
+		return "", fmt.Errorf("error packing files: %w", err)
+	}
+
+	volumes := make([]fileinfo.FileInfo, 0, len(pack.Volumes))
+	for _, v := range pack.Volumes {
+		info, err := os.Stat(v)
+		if err != nil {
+			return "", fmt.Errorf("error packing files: %w", err)
+		}
+		volumes = append(volumes, fileinfo.FileInfo{Path: v, Size: uint64(info.Size())})
+	}
+	if p.recorder != nil {
+		p.recorder.SetPackedVolumes(pack.Volumes)
+	}
+
+	var (
+		createdPar2Paths []string
+		checksumPaths    []string
+		postingSucceeded bool
+	)
+	defer func() {
+		if !postingSucceeded {
+			// Keep volumes and PAR2 files on failure for retry attempts
+			return
+		}
+
+		if p.par2Cfg.MaintainPar2Files == nil || !*p.par2Cfg.MaintainPar2Files {
+			for _, path := range createdPar2Paths {
+				safeRemoveFile(ctx, path)
+			}
+		}
+		p.removeChecksums(ctx, checksumPaths)
+
+		// In durable mode the volumes are the transfer's recovery source and
+		// are removed by the transfer cleaner once verified.
+		if p.recorder != nil {
+			if err := packer.Forget(packDir); err != nil {
+				slog.WarnContext(ctx, "Failed to remove pack record", "dir", packDir, "error", err)
+			}
+			return
+		}
+		if err := os.RemoveAll(packDir); err != nil {
+			slog.WarnContext(ctx, "Failed to remove packed volumes", "dir", packDir, "error", err)
+		}
+	}()
+
+	nzbGen := p.newNzbGenerator(layout)
+	defer nzbGen.Close()
+	if pack.Password != "" {
+		nzbGen.SetMeta("password", pack.Password)
+	}
+
+	filesPath := append([]string(nil), pack.Volumes...)
+	if *p.par2Cfg.Enabled {
+		var par2OutputDir string
+		if p.par2Cfg.MaintainPar2Files != nil && *p.par2Cfg.MaintainPar2Files {
+			par2OutputDir = filepath.Dir(nzbPath)
+		}
+
+		createdPar2Paths, err = p.par2runner.CreateSet(ctx, volumes, par2OutputDir, name, packDir)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return "", err
+			}
+			slog.ErrorContext(ctx, "Error during par2 creation. Upload will continue without par2.", "error", err)
+		} else {
+			filesPath = append(filesPath, createdPar2Paths...)
+		}
+	}
+
+	checksumPaths = p.createChecksums(ctx, volumes, packDir, name, filepath.Dir(nzbPath))
+	filesPath = append(filesPath, checksumPaths...)
+
+	var deferredErr *poster.DeferredCheckError
+	if err := p.poster.Post(ctx, filesPath, packDir, nzbGen); err != nil {
+		if errors.As(err, &deferredErr) {
+			slog.InfoContext(ctx, "Some articles deferred for later verification", "name", name, "deferred", len(deferredErr.FailedArticles))
+		} else {
+			if !errors.Is(err, context.Canceled) {
+				slog.ErrorContext(ctx, "Error during upload of packed volumes", "name", name, "error", err)
+			}
+			return "", err
+		}
+	}
+
+	finalPath, err := nzbGen.Generate(nzbPath)
+	if err != nil {
+		return "", fmt.Errorf("error generating NZB file: %w", err)
+	}
+
+	postingSucceeded = true
+
+	if deferredErr != nil {
+		return finalPath, deferredErr
+	}
+	return finalPath, nil
+}
+
+// packDir returns the directory the volumes of a pack are written to. It is
+// derived from the packed files and settings, so a retried job finds the
+// volumes of an earlier attempt.
+func (p *Postie) packDir(name string, files []fileinfo.FileInfo) string {
+	base := p.postingCfg.Packing.TempDir
+	if base == "" && p.par2Cfg != nil {
+		base = p.par2Cfg.TempDir
+	}
+	if base == "" {
+		base = os.TempDir()
+	}
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00%d\x00%s\x00", p.postingCfg.Packing.Mode, p.postingCfg.Packing.Volume(), name)
+	for _, f := range files {
+		_, _ = fmt.Fprintf(h, "%s\x00%d\x00", f.Path, f.Size)
+	}
+	return filepath.Join(base, "postie-pack-"+hex.EncodeToString(h.Sum(nil))[:16])
+}
+
+// archiveName is the path of f inside a pack: its base name when packed on
+// its own, otherwise its path relative to the folder being posted.
+func archiveName(rootDir string, f fileinfo.FileInfo, single bool) string {
+	if single {
+		return filepath.Base(f.Path)
+	}
+	if f.RelativePath != "" {
+		return filepath.ToSlash(f.RelativePath)
+	}
+	if rel, err := filepath.Rel(rootDir, f.Path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.Base(f.Path)
+}
@@ -0,0 +1,87 @@
+package postie
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/nzb"
+	"github.com/javi11/postie/pkg/fileinfo"
+)
+
+// recordingPoster is a mockPoster that remembers the files it was asked to post.
+type recordingPoster struct {
+	mockPoster
+	mu     sync.Mutex
+	posted []string
+}
+
+func (m *recordingPoster) Post(ctx context.Context, files []string, rootDir string, nzbGen nzb.NZBGenerator) error {
+	m.mu.Lock()
+	m.posted = append(m.posted, files...)
+	m.mu.Unlock()
+	return m.mockPoster.Post(ctx, files, rootDir, nzbGen)
+}
+
+func (m *recordingPoster) PostWithRelativePaths(ctx context.Context, files []string, rootDir string, nzbGen nzb.NZBGenerator, relativePaths map[string]string) error {
+	m.mu.Lock()
+	m.posted = append(m.posted, files...)
+	m.mu.Unlock()
+	return m.mockPoster.PostWithRelativePaths(ctx, files, rootDir, nzbGen, relativePaths)
+}
+
+func TestPostFolder_PackedZip(t *testing.T) {
+	watchRoot := t.TempDir()
+	folderPath := filepath.Join(watchRoot, "Show")
+	if err := os.MkdirAll(filepath.Join(folderPath, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var files []fileinfo.FileInfo
+	for _, rel := range []string{"a.txt", "sub/b.txt", "sub/c.txt"} {
+		path := filepath.Join(folderPath, rel)
+		content := strings.Repeat(rel, 200)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, fileinfo.FileInfo{Path: path, Size: uint64(len(content)), RelativePath: "Show/" + rel})
+	}
+
+	rec := &recordingPoster{}
+	p := newTestPostie(&mockPar2Executor{}, true, false)
+	p.poster = rec
+	p.postingCfg.Packing = config.PackingConfig{
+		Mode:       config.PackingModeZip,
+		VolumeSize: 1000,
+		TempDir:    t.TempDir(),
+	}
+
+	outputDir := t.TempDir()
+	nzbPath, err := p.Post(context.Background(), files, watchRoot, outputDir, true)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	if len(rec.posted) < 2 {
+		t.Fatalf("posted %v, want several volumes", rec.posted)
+	}
+	for i, path := range rec.posted {
+		if want := "Show.zip.00" + string(rune('1'+i)); filepath.Base(path) != want {
+			t.Errorf("posted file %d = %s, want %s", i, filepath.Base(path), want)
+		}
+	}
+	if _, err := os.Stat(filepath.Dir(rec.posted[0])); !os.IsNotExist(err) {
+		t.Errorf("pack directory should be removed after a standalone upload, stat err = %v", err)
+	}
+
+	parsed, err := nzb.Parse(nzbPath)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if parsed.Meta["password"] == "" {
+		t.Error("NZB head has no password for zip volumes")
+	}
+}
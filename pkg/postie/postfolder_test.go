@@ -8,6 +8,7 @@ import (
 
 	"github.com/javi11/postie/internal/article"
 	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/filehash"
 	"github.com/javi11/postie/internal/nzb"
 	"github.com/javi11/postie/internal/poster"
 	"github.com/javi11/postie/pkg/fileinfo"
@@ -27,8 +28,10 @@ func (m *mockPoster) PostWithRelativePaths(_ context.Context, files []string, _
 	return nil
 }
 
-func (m *mockPoster) Stats() poster.StatsSnapshot { return poster.StatsSnapshot{} }
-func (m *mockPoster) Close()                      {}
+func (m *mockPoster) Stats() poster.StatsSnapshot          { return poster.StatsSnapshot{} }
+func (m *mockPoster) FileHashes() map[string]filehash.Sums { return nil }
+func (m *mockPoster) AddChecksumFiles([]string)            {}
+func (m *mockPoster) Close()                               {}
 
 // addFakeArticles injects one minimal article per file so nzbGen.Generate succeeds.
 func addFakeArticles(nzbGen nzb.NZBGenerator, files []string) {
@@ -306,3 +309,34 @@ func TestPostFolderCrossVolumePathSeparation(t *testing.T) {
 		}
 	}
 }
+
+// TestPostFolder_NzbMeta verifies that the job's NZB meta overrides the
+// configured defaults and is written to the NZB head.
+func TestPostFolder_NzbMeta(t *testing.T) {
+	watchRoot := t.TempDir()
+	files, cleanup := makeSourceFiles(t, watchRoot, "Show", "ep.mkv")
+	defer cleanup()
+
+	p := newTestPostie(&mockPar2Executor{}, true, false)
+	p.nzbMeta = config.NzbMeta{Category: "tv", Tags: []string{"default"}}
+	p.SetNzbMeta(config.NzbMeta{Title: "Show S01", Tags: []string{"hd", "web"}})
+
+	nzbPath, err := p.Post(context.Background(), files, watchRoot, t.TempDir(), true)
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	parsed, err := nzb.Parse(nzbPath)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := map[string]string{"title": "Show S01", "category": "tv", "tag": "hd, web"}
+	for key, value := range want {
+		if parsed.Meta[key] != value {
+			t.Errorf("meta %s = %q, want %q", key, parsed.Meta[key], value)
+		}
+	}
+	if _, ok := parsed.Meta["password"]; ok {
+		t.Error("NZB head has a password although none was set")
+	}
+}
@@ -13,7 +13,9 @@ import (
 	"time"
 
 	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/filehash"
 	"github.com/javi11/postie/internal/nzb"
+	"github.com/javi11/postie/internal/nzbpath"
 	"github.com/javi11/postie/internal/par2"
 	"github.com/javi11/postie/internal/pool"
 	"github.com/javi11/postie/internal/poster"
@@ -30,6 +32,7 @@ type Postie struct {
 	poster                    poster.Poster
 	compressionCfg            config.NzbCompressionConfig
 	postUploadScriptCfg       config.PostUploadScriptConfig
+	nzbSinksCfg               config.NzbSinksConfig
 	maintainOriginalExtension bool
 	postCheckCfg              config.PostCheck
 	jobProgress               progress.JobProgress
@@ -43,6 +46,14 @@ type Postie struct {
 	// after successful verification (persisted into the transfer's cleanup
 	// policy at completion). Set by the caller before Post.
 	deleteOriginal bool
+	// nzbMeta is written to the <head> of every NZB: the configured nzb_meta,
+	// overridden by the job's own values.
+	nzbMeta config.NzbMeta
+	// nzbOutputCfg and nzbPathTemplate lay out the NZBs below the output
+	// directory; the template is nil when none is configured.
+	nzbOutputCfg    config.NzbOutputConfig
+	nzbPathTemplate *nzbpath.Template
+	transferID      string
 }
 
 // SetDeleteOriginal records whether the job's original files should be deleted
@@ -52,10 +63,18 @@ func (p *Postie) SetDeleteOriginal(v bool) {
 	p.deleteOriginal = v
 }
 
+// SetNzbMeta sets the job's NZB <head> metadata. Fields set in meta replace
+// the configured nzb_meta defaults. Must be called before Post.
+func (p *Postie) SetNzbMeta(meta config.NzbMeta) {
+	p.nzbMeta = p.nzbMeta.Merge(meta)
+}
+
 // QueueInterface defines the queue methods needed by Postie
 type QueueInterface interface {
 	UpdateScriptStatus(ctx context.Context, itemID string, status string, retryCount int, lastError string, nextRetryAt *time.Time, firstFailureAt *time.Time) error
 	MarkScriptCompleted(ctx context.Context, itemID string) error
+	UpdateSinkStatus(ctx context.Context, itemID, sink, nzbPath, status string, retryCount int, lastError string, nextRetryAt *time.Time, firstFailureAt *time.Time) error
+	MarkSinkCompleted(ctx context.Context, itemID, sink, nzbPath string) error
 }
 
 // New creates a Postie that owns a private transfer runtime. It is retained as
@@ -116,6 +135,15 @@ func NewWithRuntime(
 	// doubles as the poster's manifest sink; pass an untyped-nil sink when
 	// absent to avoid a non-nil interface wrapping a nil pointer.
 	recorder := rt.NewManifestRecorder(transferID)
+
+	nzbOutputCfg := cfg.GetNzbOutputConfig()
+	var nzbPathTemplate *nzbpath.Template
+	if nzbOutputCfg.PathTemplate != "" {
+		nzbPathTemplate, err = nzbpath.Parse(nzbOutputCfg.PathTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid nzb_output path_template: %w", err)
+		}
+	}
 	var sink poster.ManifestSink
 	if recorder != nil {
 		sink = recorder
@@ -137,14 +165,44 @@ func NewWithRuntime(
 		postingCfg:                postingConfig,
 		compressionCfg:            compressionConfig,
 		postUploadScriptCfg:       postUploadScriptConfig,
+		nzbSinksCfg:               cfg.GetNzbSinksConfig(),
 		maintainOriginalExtension: maintainOriginalExtension,
 		postCheckCfg:              cfg.GetPostCheckConfig(),
 		jobProgress:               jobProgress,
 		queue:                     queue,
 		recorder:                  recorder,
+		nzbMeta:                   cfg.GetNzbMeta(),
+		nzbOutputCfg:              nzbOutputCfg,
+		nzbPathTemplate:           nzbPathTemplate,
+		transferID:                transferID,
 	}, nil
 }
 
+// newNzbGenerator creates the generator of one NZB, carrying the job's meta.
+func (p *Postie) newNzbGenerator(layout nzb.OutputLayout) nzb.NZBGenerator {
+	nzbGen := nzb.NewGenerator(p.postingCfg.ArticleSizeInBytes, p.compressionCfg, p.maintainOriginalExtension)
+	nzbGen.SetHeadMeta(p.nzbMeta)
+	nzbGen.SetOutputLayout(layout)
+	return nzbGen
+}
+
+// nzbOutputLayout returns the nzb_output layout of an NZB written below
+// outputDir for a source posted from rootDir. folder is the {folder} of the
+// template: the source's directory below rootDir, or the posted folder.
+func (p *Postie) nzbOutputLayout(outputDir, rootDir, folder string) nzb.OutputLayout {
+	return nzb.OutputLayout{
+		Dir:      outputDir,
+		Template: p.nzbPathTemplate,
+		Vars: nzbpath.Vars{
+			Folder:     folder,
+			Root:       filepath.Base(rootDir),
+			Category:   p.nzbMeta.Category,
+			TransferID: p.transferID,
+		},
+		OnCollision: p.nzbOutputCfg.OnCollision,
+	}
+}
+
 // completeTransferUpload marks the transfer's files uploaded so the durable
 // verification service can verify them, scheduling the first check after the
 // configured propagation delay. No-op in standalone mode (no recorder).
@@ -159,6 +217,12 @@ func (p *Postie) completeTransferUpload(ctx context.Context) {
 	}
 }
 
+// FileHashes returns the whole-file checksums of every file posted by this
+// Postie, keyed by path.
+func (p *Postie) FileHashes() map[string]filehash.Sums {
+	return p.poster.FileHashes()
+}
+
 func (p *Postie) Close() {
 	p.poster.Close()
 	if p.jobProgress != nil {
@@ -271,7 +335,12 @@ func (p *Postie) Post(ctx context.Context, files []fileinfo.FileInfo, rootDir st
 
 		var nzbPath string
 		var err error
-		if *p.postingCfg.WaitForPar2 {
+		if p.postingCfg.Packing.Enabled() {
+			relativePath := relativePathFrom(rootDir, f.Path)
+			nzbPath, err = p.postPacked(ctx, []fileinfo.FileInfo{f}, rootDir, filepath.Base(f.Path),
+				filepath.Join(outputDir, relativePath, filepath.Base(f.Path)),
+				p.nzbOutputLayout(outputDir, rootDir, relativePath))
+		} else if *p.postingCfg.WaitForPar2 {
 			nzbPath, err = p.post(ctx, f, rootDir, outputDir)
 		} else {
 			nzbPath, err = p.postInParallel(ctx, f, rootDir, outputDir)
@@ -314,6 +383,7 @@ func (p *Postie) postInParallel(
 ) (string, error) {
 	var (
 		createdPar2Paths []string
+		checksumPaths    []string
 		err              error
 		postingSucceeded bool
 	)
@@ -332,9 +402,11 @@ func (p *Postie) postInParallel(
 				safeRemoveFile(ctx, path)
 			}
 		}
+		p.removeChecksums(ctx, checksumPaths)
 	}()
 
-	nzbGen := nzb.NewGenerator(p.postingCfg.ArticleSizeInBytes, p.compressionCfg, p.maintainOriginalExtension)
+	nzbGen := p.newNzbGenerator(p.nzbOutputLayout(outputDir, rootDir, relativePathFrom(rootDir, f.Path)))
+	defer nzbGen.Close()
 
 	errg := errgroup.Group{}
 
@@ -371,6 +443,22 @@ func (p *Postie) postInParallel(
 		return nil
 	})
 
+	errg.Go(func() error {
+		checksumPaths = p.createChecksums(ctx, []fileinfo.FileInfo{f}, filepath.Dir(f.Path), filepath.Base(f.Path),
+			filepath.Join(outputDir, relativePathFrom(rootDir, f.Path)))
+		if len(checksumPaths) == 0 {
+			return nil
+		}
+
+		if err := p.poster.Post(ctx, checksumPaths, rootDir, nzbGen); err != nil {
+			if !errors.Is(err, context.Canceled) {
+				slog.ErrorContext(ctx, "Error during upload of checksum files. Upload will continue without them.", "error", err)
+			}
+		}
+
+		return nil
+	})
+
 	var deferredErr *poster.DeferredCheckError
 
 	errg.Go(func() error {
@@ -423,6 +511,7 @@ func (p *Postie) post(
 ) (string, error) {
 	var (
 		createdPar2Paths []string
+		checksumPaths    []string
 		err              error
 		postingSucceeded bool
 	)
@@ -442,10 +531,12 @@ func (p *Postie) post(
 				safeRemoveFile(ctx, path)
 			}
 		}
+		p.removeChecksums(ctx, checksumPaths)
 	}()
 
 	filesPath := []string{f.Path}
-	nzbGen := nzb.NewGenerator(p.postingCfg.ArticleSizeInBytes, p.compressionCfg, p.maintainOriginalExtension)
+	nzbGen := p.newNzbGenerator(p.nzbOutputLayout(outputDir, rootDir, relativePathFrom(rootDir, f.Path)))
+	defer nzbGen.Close()
 
 	if *p.par2Cfg.Enabled {
 		// Determine PAR2 output directory based on maintain_par2_files setting
@@ -472,6 +563,10 @@ func (p *Postie) post(
 		filesPath = append(filesPath, createdPar2Paths...)
 	}
 
+	checksumPaths = p.createChecksums(ctx, []fileinfo.FileInfo{f}, filepath.Dir(f.Path), filepath.Base(f.Path),
+		filepath.Join(outputDir, relativePathFrom(rootDir, f.Path)))
+	filesPath = append(filesPath, checksumPaths...)
+
 	var deferredErr *poster.DeferredCheckError
 	if err := p.poster.Post(ctx, filesPath, rootDir, nzbGen); err != nil {
 		// Check if this is a non-fatal deferred check error
@@ -530,8 +625,14 @@ func (p *Postie) postFolder(ctx context.Context, files []fileinfo.FileInfo, root
 
 	slog.InfoContext(ctx, "Posting folder as single NZB", "folder", folderName, "files", len(files))
 
+	if p.postingCfg.Packing.Enabled() {
+		return p.postPacked(ctx, files, rootDir, folderName, filepath.Join(folderOutputDir, folderName+".nzb"),
+			p.nzbOutputLayout(outputDir, rootDir, folderName))
+	}
+
 	var (
 		createdPar2Paths []string
+		checksumPaths    []string
 		err              error
 		postingSucceeded bool
 	)
@@ -551,10 +652,12 @@ func (p *Postie) postFolder(ctx context.Context, files []fileinfo.FileInfo, root
 				safeRemoveFile(ctx, path)
 			}
 		}
+		p.removeChecksums(ctx, checksumPaths)
 	}()
 
 	// Create a single NZB generator for all files
-	nzbGen := nzb.NewGenerator(p.postingCfg.ArticleSizeInBytes, p.compressionCfg, p.maintainOriginalExtension)
+	nzbGen := p.newNzbGenerator(p.nzbOutputLayout(outputDir, rootDir, folderName))
+	defer nzbGen.Close()
 
 	// Collect all file paths and build relative paths map for subject generation
 	var allFilePaths []string
@@ -603,6 +706,10 @@ func (p *Postie) postFolder(ctx context.Context, files []fileinfo.FileInfo, root
 			}
 		}
 
+		// Checksum sidecars also live at the folder root.
+		checksumPaths = p.createChecksums(ctx, files, filepath.Join(rootDir, folderName), folderName, folderOutputDir)
+		allFilePaths = append(allFilePaths, checksumPaths...)
+
 		var deferredErr *poster.DeferredCheckError
 		// Post all files (including PAR2) together with relative paths for subjects
 		if err := p.poster.PostWithRelativePaths(ctx, allFilePaths, rootDir, nzbGen, relativePaths); err != nil {
@@ -678,6 +785,21 @@ func (p *Postie) postFolder(ctx context.Context, files []fileinfo.FileInfo, root
 			return nil
 		})
 
+		// Create and post checksum sidecars in parallel
+		errg.Go(func() error {
+			checksumPaths = p.createChecksums(ctx, files, filepath.Join(rootDir, folderName), folderName, folderOutputDir)
+			if len(checksumPaths) == 0 {
+				return nil
+			}
+
+			if err := p.poster.Post(ctx, checksumPaths, rootDir, nzbGen); err != nil {
+				if !errors.Is(err, context.Canceled) {
+					slog.ErrorContext(ctx, "Error during upload of checksum files. Upload will continue without them.", "error", err)
+				}
+			}
+			return nil
+		})
+
 		// Post main files with relative paths for subjects
 		errg.Go(func() error {
 			if err := p.poster.PostWithRelativePaths(ctx, allFilePaths, rootDir, nzbGen, relativePaths); err != nil {
@@ -46,6 +46,18 @@ func (s poolStater) StatBatch(ctx context.Context, messageIDs []string) (map[str
 		nntppool.StatManyOptions{Concurrency: s.concurrency})
 }
 
+// providerStater STATs each batch against every verify server separately so
+// the verification service can record propagation per backbone.
+type providerStater struct {
+	client      *pool.UploadClient
+	concurrency int
+}
+
+func (s providerStater) StatBatchByProvider(ctx context.Context, messageIDs []string) (map[string]map[string]struct{}, error) {
+	return s.client.StatMissingByProvider(ctx, messageIDs, len(messageIDs),
+		nntppool.StatManyOptions{Concurrency: s.concurrency})
+}
+
 // statConcurrency resolves the max_concurrent_checks setting for the
 // verification stater. 0 (auto) uses 16 on a dedicated verification pool but
 // only 2 when the verify pool is the upload pool, so background sweeps leave
@@ -70,12 +82,14 @@ func statConcurrency(configured int, verifyPool, uploadPool pool.NNTPClient) int
 	return c
 }
 
-// newPostVerifyScriptRunner returns a transfercleaner.ScriptRunner that runs
-// the post-upload script once a transfer is verified, resolving the NZB path
-// from the completed item and the source path from the transfer's files. Returns
-// nil when the script is disabled, so cleanup skips it entirely.
-func newPostVerifyScriptRunner(store *transferstore.Store, cfg config.PostUploadScriptConfig, q QueueInterface) func(ctx context.Context, transferID string, files []transferstore.TransferFile) error {
-	if !cfg.Enabled || cfg.Command == "" {
+// newPostVerifyHook returns a transfercleaner.PostVerifyHook that runs
+// the post-upload script and delivers the NZB to the configured sinks once a
+// transfer is verified, resolving the NZB path from the completed item and the
+// source path from the transfer's files. Returns nil when neither the script
+// nor any sink is configured, so cleanup skips it entirely.
+func newPostVerifyHook(store *transferstore.Store, cfg config.PostUploadScriptConfig, sinks config.NzbSinksConfig, q QueueInterface) transfercleaner.PostVerifyHook {
+	runScript := cfg.Enabled && cfg.Command != ""
+	if !runScript && len(sinks.Sinks) == 0 {
 		return nil
 	}
 	return func(ctx context.Context, transferID string, files []transferstore.TransferFile) error {
@@ -95,7 +109,12 @@ func newPostVerifyScriptRunner(store *transferstore.Store, cfg config.PostUpload
 		if err != nil {
 			return err
 		}
-		return runPostUploadScript(ctx, cfg, q, nzbPath, sourcePath, itemID)
+
+		var scriptErr error
+		if runScript {
+			scriptErr = runPostUploadScript(ctx, cfg, q, nzbPath, sourcePath, itemID)
+		}
+		return errors.Join(scriptErr, deliverToSinks(ctx, sinks, q, nzbPath, itemID))
 	}
 }
 
@@ -150,13 +169,13 @@ func NewRuntime(ctx context.Context, cfg config.Config, poolManager *pool.Manage
 			maxJobs = par2Cfg.MaxConcurrentJobs
 		}
 
-		// Build the process-wide upload engine sized from article size, the
+		// Build the process-wide upload engine sized from the largest article size, the
 		// configured buffer limit (0 = auto), and the total upload connection
 		// capacity reported by the pool.
 		if connCapacity := uploadConnectionCapacity(poolManager); connCapacity > 0 {
 			postingCfg := cfg.GetPostingConfig()
 			uploadEngine = poster.NewEngine(
-				postingCfg.ArticleSizeInBytes,
+				postingCfg.MaxArticleSize(),
 				postingCfg.UploadBufferMemoryLimit,
 				connCapacity,
 			)
@@ -183,13 +202,25 @@ func NewRuntime(ctx context.Context, cfg config.Config, poolManager *pool.Manage
 					verificationConfig(cfg.GetPostCheckConfig()),
 					"postie",
 				)
-				// Post-verification cleanup: run the post-upload script, delete
-				// originals per policy, remove generated PAR2 (unless maintained)
-				// and manifests — only once the transfer is verified.
+				// Post-verification cleanup: run the post-upload script and NZB
+				// sinks, delete originals per policy, remove generated PAR2 and manifests (unless
+				// maintained) — only once the transfer is verified.
 				maintainPar2 := par2Cfg != nil && par2Cfg.MaintainPar2Files != nil && *par2Cfg.MaintainPar2Files
 				scriptCfg := cfg.GetPostUploadScriptConfig()
-				runScript := newPostVerifyScriptRunner(store, scriptCfg, scriptQueue)
-				verifyService.SetCleaner(transfercleaner.New(store, maintainPar2, runScript))
+				postVerify := newPostVerifyHook(store, scriptCfg, cfg.GetNzbSinksConfig(), scriptQueue)
+				cleaner := transfercleaner.New(store, maintainPar2, postVerify)
+				postCheckCfg := cfg.GetPostCheckConfig()
+				cleaner.SetMaintainManifests(postCheckCfg.MaintainManifests != nil && *postCheckCfg.MaintainManifests)
+				verifyService.SetCleaner(cleaner)
+
+				// Dedicated verify servers: check each one separately and
+				// record which backbones have every file.
+				if vc, ok := verifyPool.(*pool.UploadClient); ok && verifyPool != uploadPool {
+					verifyService.SetProviderStater(providerStater{
+						client:      vc,
+						concurrency: statConcurrency(cfg.GetPostCheckConfig().MaxConcurrentChecks, verifyPool, uploadPool),
+					})
+				}
 
 				// No dedicated verify servers: STAT sweeps would steal upload
 				// connections, so defer verification while uploads saturate the
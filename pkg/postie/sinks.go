@@ -0,0 +1,65 @@
+package postie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/javi11/postie/internal/config"
+	"github.com/javi11/postie/internal/nzbsink"
+)
+
+// DeliverNzb delivers the NZB of a completed item to every configured NZB
+// sink. Like ExecutePostUploadScript it should be called once the item has
+// been marked as completed; failed deliveries are tracked for retry.
+func (p *Postie) DeliverNzb(ctx context.Context, nzbPath string, itemID string) error {
+	return deliverToSinks(ctx, p.nzbSinksCfg, p.queue, nzbPath, itemID)
+}
+
+// deliverToSinks delivers nzbPath to each sink of cfg and records the outcome
+// of every delivery in the queue, scheduling a retry for the failed ones. A
+// nil queue skips status tracking. It returns the delivery errors joined.
+func deliverToSinks(ctx context.Context, cfg config.NzbSinksConfig, q QueueInterface, nzbPath, itemID string) error {
+	var errs []error
+	for _, sinkCfg := range cfg.Sinks {
+		sink, err := nzbsink.New(sinkCfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := deliverWithTimeout(ctx, sink, cfg.Timeout.ToDuration(), nzbPath); err != nil {
+			slog.ErrorContext(ctx, "Error delivering NZB to sink", "sink", sink.Name(), "nzb_path", nzbPath, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+
+			if q != nil {
+				now := time.Now()
+				nextRetry := now.Add(cfg.RetryDelay.ToDuration())
+				if updateErr := q.UpdateSinkStatus(ctx, itemID, sink.Name(), nzbPath, "pending_retry", 0, err.Error(), &nextRetry, &now); updateErr != nil {
+					slog.ErrorContext(ctx, "Failed to track NZB sink failure", "sink", sink.Name(), "error", updateErr)
+				}
+			}
+			continue
+		}
+
+		if q != nil {
+			if updateErr := q.MarkSinkCompleted(ctx, itemID, sink.Name(), nzbPath); updateErr != nil {
+				slog.ErrorContext(ctx, "Failed to mark NZB sink delivery as completed", "sink", sink.Name(), "error", updateErr)
+			}
+		}
+		slog.InfoContext(ctx, "NZB delivered to sink", "sink", sink.Name(), "nzb_path", nzbPath)
+	}
+	return errors.Join(errs...)
+}
+
+// deliverWithTimeout delivers nzbPath to sink within timeout (no limit when zero).
+func deliverWithTimeout(ctx context.Context, sink nzbsink.Sink, timeout time.Duration, nzbPath string) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return sink.Deliver(ctx, nzbPath)
+}
@@ -0,0 +1,67 @@
+package postie
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/javi11/postie/internal/config"
+)
+
+// sinkStatusQueue records the NZB sink statuses reported to the queue.
+type sinkStatusQueue struct {
+	statuses map[string]string // sink -> status
+}
+
+func (q *sinkStatusQueue) UpdateScriptStatus(context.Context, string, string, int, string, *time.Time, *time.Time) error {
+	return nil
+}
+
+func (q *sinkStatusQueue) MarkScriptCompleted(context.Context, string) error { return nil }
+
+func (q *sinkStatusQueue) UpdateSinkStatus(_ context.Context, _, sink, _, status string, _ int, _ string, _ *time.Time, _ *time.Time) error {
+	q.statuses[sink] = status
+	return nil
+}
+
+func (q *sinkStatusQueue) MarkSinkCompleted(_ context.Context, _, sink, _ string) error {
+	q.statuses[sink] = "completed"
+	return nil
+}
+
+func TestDeliverNzb(t *testing.T) {
+	dir := t.TempDir()
+	nzbPath := filepath.Join(dir, "job.nzb")
+	if err := os.WriteFile(nzbPath, []byte("<nzb/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.WriteFile(blocked, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	q := &sinkStatusQueue{statuses: map[string]string{}}
+	p := &Postie{
+		queue: q,
+		nzbSinksCfg: config.NzbSinksConfig{
+			Sinks: []config.NzbSinkConfig{
+				{Name: "mirror", Type: config.NzbSinkTypeDirectory, Directories: []string{filepath.Join(dir, "mirror")}},
+				{Name: "broken", Type: config.NzbSinkTypeDirectory, Directories: []string{filepath.Join(blocked, "sub")}},
+			},
+			Timeout:    config.Duration("5s"),
+			RetryDelay: config.Duration("30s"),
+		},
+	}
+
+	if err := p.DeliverNzb(context.Background(), nzbPath, "item"); err == nil {
+		t.Error("expected the broken sink's error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "mirror", "job.nzb")); err != nil {
+		t.Errorf("NZB not delivered to the working sink: %v", err)
+	}
+	if q.statuses["mirror"] != "completed" || q.statuses["broken"] != "pending_retry" {
+		t.Errorf("statuses = %v, want mirror completed and broken pending_retry", q.statuses)
+	}
+}
@@ -22,11 +22,13 @@ import (
 	"runtime"
 	"testing"
 	"time"
+
+	"github.com/javi11/postie/pkg/nntptest"
 )
 
 var (
 	baseURL  = "http://127.0.0.1:8080"
-	fakeNntp *fakeNntpServer
+	fakeNntp *nntptest.Server
 )
 
 // TestMain is the entry point for the E2E test suite.
@@ -47,7 +49,7 @@ func run(m *testing.M) int {
 
 	// 2. Start fake NNTP server (must outlive all tests — every POST /api/config
 	// triggers validateServerConnections which dials the NNTP server)
-	fakeNntp, err = startFakeNntpServer()
+	fakeNntp, err = nntptest.NewServer(nntptest.Options{})
 	if err != nil {
 		log.Printf("start fake NNTP server: %v", err)
 		return 1
@@ -74,7 +76,7 @@ func run(m *testing.M) int {
 	}
 
 	// 5. Complete setup wizard (skips if already configured)
-	if err := completeSetupWizard(fakeNntp.port); err != nil {
+	if err := completeSetupWizard(fakeNntp.Port()); err != nil {
 		log.Printf("complete setup wizard: %v", err)
 		return 1
 	}